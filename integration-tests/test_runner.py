@@ -69,6 +69,9 @@ class IntegrationTestRunner:
         reset_cmd = f"kubectl exec -n {self.namespace} kafka-0 -- kafka-consumer-groups.sh --bootstrap-server localhost:9092 --group ma-signal-detector --reset-offsets --to-latest --topic crypto-prices --execute"
         os.system(reset_cmd)
 
+        reset_cmd = f"kubectl exec -n {self.namespace} kafka-0 -- kafka-consumer-groups.sh --bootstrap-server localhost:9092 --group momentum-detector --reset-offsets --to-latest --topic crypto-prices --execute"
+        os.system(reset_cmd)
+
         reset_cmd = f"kubectl exec -n {self.namespace} kafka-0 -- kafka-consumer-groups.sh --bootstrap-server localhost:9092 --group volume-spike-detector --reset-offsets --to-latest --topic crypto-prices --execute"
         os.system(reset_cmd)
 
@@ -84,6 +87,9 @@ class IntegrationTestRunner:
         if not self.restart_service("crypto-trackers-ma-signal-detector"):
             return False
 
+        if not self.restart_service("crypto-trackers-momentum-detector"):
+            return False
+
         if not self.restart_service("crypto-trackers-volume-spike-detector"):
             return False
 
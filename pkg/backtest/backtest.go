@@ -0,0 +1,208 @@
+package backtest
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+var requiredColumns = []string{"timestamp", "symbol", "price_usd"}
+
+type SignalRecorder struct {
+	signals []*events.TradingSignal
+	mutex   sync.Mutex
+}
+
+func NewSignalRecorder() *SignalRecorder {
+	return &SignalRecorder{}
+}
+
+func (r *SignalRecorder) PublishSignal(ctx context.Context, topic string, signal *events.TradingSignal) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.signals = append(r.signals, signal)
+	return nil
+}
+
+func (r *SignalRecorder) Close() error {
+	return nil
+}
+
+func (r *SignalRecorder) Signals() []*events.TradingSignal {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	signals := make([]*events.TradingSignal, len(r.signals))
+	copy(signals, r.signals)
+	return signals
+}
+
+// LoadEvents reads price events from CSV or JSONL files, picked by extension,
+// and returns them merged in timestamp order.
+func LoadEvents(paths []string) ([]*events.PriceEvent, error) {
+	var priceEvents []*events.PriceEvent
+
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+
+		var loaded []*events.PriceEvent
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			loaded, err = ReadCSV(file)
+		case ".jsonl", ".ndjson":
+			loaded, err = ReadJSONL(file)
+		default:
+			err = fmt.Errorf("unsupported file extension %q", filepath.Ext(path))
+		}
+		file.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		priceEvents = append(priceEvents, loaded...)
+	}
+
+	sort.SliceStable(priceEvents, func(i, j int) bool {
+		return priceEvents[i].Timestamp.Before(priceEvents[j].Timestamp)
+	})
+
+	return priceEvents, nil
+}
+
+func ReadJSONL(r io.Reader) ([]*events.PriceEvent, error) {
+	var priceEvents []*events.PriceEvent
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var event events.PriceEvent
+		if err := json.Unmarshal([]byte(text), &event); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		priceEvents = append(priceEvents, &event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return priceEvents, nil
+}
+
+// ReadCSV expects a header row using the PriceEvent JSON field names; only
+// timestamp, symbol and price_usd are required.
+func ReadCSV(r io.Reader) ([]*events.PriceEvent, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range requiredColumns {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing required column %q", required)
+		}
+	}
+
+	var priceEvents []*events.PriceEvent
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		event, err := parseCSVRecord(record, columns)
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		priceEvents = append(priceEvents, event)
+	}
+
+	return priceEvents, nil
+}
+
+func parseCSVRecord(record []string, columns map[string]int) (*events.PriceEvent, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	number := func(name string) (float64, error) {
+		value := field(name)
+		if value == "" {
+			return 0, nil
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q", name, value)
+		}
+		return parsed, nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, field("timestamp"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q", field("timestamp"))
+	}
+
+	event := &events.PriceEvent{
+		Timestamp: timestamp,
+		Symbol:    field("symbol"),
+		Source:    field("source"),
+	}
+
+	if event.PriceUSD, err = number("price_usd"); err != nil {
+		return nil, err
+	}
+	if event.Volume24h, err = number("volume_24h"); err != nil {
+		return nil, err
+	}
+	if event.MarketCap, err = number("market_cap"); err != nil {
+		return nil, err
+	}
+	if event.PriceChange24h, err = number("price_change_24h"); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func Run(priceEvents []*events.PriceEvent, process func(*events.PriceEvent) error) error {
+	for _, event := range priceEvents {
+		if err := process(event); err != nil {
+			return fmt.Errorf("failed to process %s event at %s: %w", event.Symbol, event.Timestamp.Format(time.RFC3339), err)
+		}
+	}
+	return nil
+}
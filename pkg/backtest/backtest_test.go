@@ -0,0 +1,113 @@
+package backtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+func TestReadCSV(t *testing.T) {
+	t.Run("parses columns by header", func(t *testing.T) {
+		input := "symbol,timestamp,price_usd,volume_24h\n" +
+			"BTC,2024-06-16T14:30:00Z,67450.23,28450000000\n" +
+			"ETH,2024-06-16T14:30:00Z,3500.5,\n"
+
+		priceEvents, err := ReadCSV(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(priceEvents) != 2 {
+			t.Fatalf("expected 2 events, got %d", len(priceEvents))
+		}
+		if priceEvents[0].Symbol != "BTC" || priceEvents[0].PriceUSD != 67450.23 || priceEvents[0].Volume24h != 28450000000 {
+			t.Errorf("unexpected first event: %+v", priceEvents[0])
+		}
+		if priceEvents[1].Volume24h != 0 {
+			t.Errorf("expected empty volume to parse as 0, got %f", priceEvents[1].Volume24h)
+		}
+	})
+
+	t.Run("missing required column", func(t *testing.T) {
+		_, err := ReadCSV(strings.NewReader("timestamp,symbol\n2024-06-16T14:30:00Z,BTC\n"))
+		if err == nil {
+			t.Error("expected error for missing price_usd column")
+		}
+	})
+
+	t.Run("invalid timestamp", func(t *testing.T) {
+		_, err := ReadCSV(strings.NewReader("timestamp,symbol,price_usd\nyesterday,BTC,1\n"))
+		if err == nil {
+			t.Error("expected error for invalid timestamp")
+		}
+	})
+}
+
+func TestReadJSONL(t *testing.T) {
+	input := `{"timestamp":"2024-06-16T14:30:00Z","symbol":"BTC","price_usd":67450.23}
+
+{"timestamp":"2024-06-16T14:31:00Z","symbol":"BTC","price_usd":67460.00}
+`
+
+	priceEvents, err := ReadJSONL(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(priceEvents) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(priceEvents))
+	}
+
+	if _, err := ReadJSONL(strings.NewReader("{not json}\n")); err == nil {
+		t.Error("expected error for malformed line")
+	}
+}
+
+func TestLoadEvents_MergesFilesInTimeOrder(t *testing.T) {
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "btc.csv")
+	os.WriteFile(csvPath, []byte("timestamp,symbol,price_usd\n2024-06-16T14:32:00Z,BTC,3\n2024-06-16T14:30:00Z,BTC,1\n"), 0o644)
+
+	jsonlPath := filepath.Join(dir, "eth.jsonl")
+	os.WriteFile(jsonlPath, []byte(`{"timestamp":"2024-06-16T14:31:00Z","symbol":"ETH","price_usd":2}`+"\n"), 0o644)
+
+	priceEvents, err := LoadEvents([]string{csvPath, jsonlPath})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for i, expected := range []float64{1, 2, 3} {
+		if priceEvents[i].PriceUSD != expected {
+			t.Errorf("expected price %f at %d, got %f", expected, i, priceEvents[i].PriceUSD)
+		}
+	}
+
+	if _, err := LoadEvents([]string{filepath.Join(dir, "prices.parquet")}); err == nil {
+		t.Error("expected error for unsupported file")
+	}
+}
+
+func TestRun_RecordsSignals(t *testing.T) {
+	recorder := NewSignalRecorder()
+	priceEvents := []*events.PriceEvent{
+		{Timestamp: time.Now(), Symbol: "BTC", PriceUSD: 1},
+		{Timestamp: time.Now(), Symbol: "BTC", PriceUSD: 2},
+	}
+
+	err := Run(priceEvents, func(event *events.PriceEvent) error {
+		return recorder.PublishSignal(context.Background(), "trading-signals", &events.TradingSignal{Symbol: event.Symbol})
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(recorder.Signals()) != 2 {
+		t.Errorf("expected 2 recorded signals, got %d", len(recorder.Signals()))
+	}
+}
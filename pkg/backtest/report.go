@@ -0,0 +1,224 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+// Report output formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// CheckFormat returns an error unless format is FormatText or FormatJSON.
+func CheckFormat(format string) error {
+	switch format {
+	case FormatText, FormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q, expected %s or %s", format, FormatText, FormatJSON)
+	}
+}
+
+type Horizon struct {
+	Label    string
+	Duration time.Duration
+}
+
+var DefaultHorizons = []Horizon{
+	{Label: "1h", Duration: time.Hour},
+	{Label: "24h", Duration: 24 * time.Hour},
+	{Label: "7d", Duration: 7 * 24 * time.Hour},
+}
+
+type SignalOutcome struct {
+	Signal         *events.TradingSignal `json:"signal"`
+	EntryPrice     float64               `json:"entry_price"`
+	ForwardReturns map[string]float64    `json:"forward_returns"`
+}
+
+type HorizonStats struct {
+	Horizon   string  `json:"horizon"`
+	Direction string  `json:"direction"`
+	Evaluated int     `json:"evaluated"`
+	Hits      int     `json:"hits"`
+	HitRate   float64 `json:"hit_rate"`
+	AvgReturn float64 `json:"avg_return"`
+}
+
+type Report struct {
+	Events           int             `json:"events"`
+	Signals          []SignalOutcome `json:"signals"`
+	SignalsPerSymbol map[string]int  `json:"signals_per_symbol"`
+	Stats            []HorizonStats  `json:"stats"`
+}
+
+// Evaluate computes forward returns for each signal from the first price of
+// the same symbol at or after signal time plus each horizon. Horizons that run
+// past the end of the data are left out. A bullish signal is a hit when the
+// return is positive and a bearish one when it is negative.
+func Evaluate(priceEvents []*events.PriceEvent, signals []*events.TradingSignal, horizons []Horizon) *Report {
+	prices := make(map[string][]*events.PriceEvent)
+	for _, event := range priceEvents {
+		prices[event.Symbol] = append(prices[event.Symbol], event)
+	}
+
+	report := &Report{
+		Events:           len(priceEvents),
+		Signals:          make([]SignalOutcome, 0, len(signals)),
+		SignalsPerSymbol: make(map[string]int),
+	}
+
+	type statsKey struct {
+		horizon   string
+		direction string
+	}
+	stats := make(map[statsKey]*HorizonStats)
+
+	for _, signal := range signals {
+		report.SignalsPerSymbol[signal.Symbol]++
+
+		outcome := SignalOutcome{
+			Signal:         signal,
+			ForwardReturns: make(map[string]float64),
+		}
+
+		entry := priceAtOrAfter(prices[signal.Symbol], signal.Timestamp)
+		if entry == nil || entry.PriceUSD == 0 {
+			report.Signals = append(report.Signals, outcome)
+			continue
+		}
+		outcome.EntryPrice = entry.PriceUSD
+
+		for _, horizon := range horizons {
+			exit := priceAtOrAfter(prices[signal.Symbol], signal.Timestamp.Add(horizon.Duration))
+			if exit == nil {
+				continue
+			}
+
+			forwardReturn := (exit.PriceUSD - entry.PriceUSD) / entry.PriceUSD
+			outcome.ForwardReturns[horizon.Label] = forwardReturn
+
+			if signal.Direction != "bullish" && signal.Direction != "bearish" {
+				continue
+			}
+
+			key := statsKey{horizon: horizon.Label, direction: signal.Direction}
+			entryStats, ok := stats[key]
+			if !ok {
+				entryStats = &HorizonStats{Horizon: horizon.Label, Direction: signal.Direction}
+				stats[key] = entryStats
+			}
+
+			entryStats.Evaluated++
+			entryStats.AvgReturn += forwardReturn
+			if (signal.Direction == "bullish" && forwardReturn > 0) || (signal.Direction == "bearish" && forwardReturn < 0) {
+				entryStats.Hits++
+			}
+		}
+
+		report.Signals = append(report.Signals, outcome)
+	}
+
+	for _, horizon := range horizons {
+		for _, direction := range []string{"bullish", "bearish"} {
+			entryStats, ok := stats[statsKey{horizon: horizon.Label, direction: direction}]
+			if !ok {
+				continue
+			}
+			entryStats.HitRate = float64(entryStats.Hits) / float64(entryStats.Evaluated)
+			entryStats.AvgReturn /= float64(entryStats.Evaluated)
+			report.Stats = append(report.Stats, *entryStats)
+		}
+	}
+
+	return report
+}
+
+func priceAtOrAfter(priceEvents []*events.PriceEvent, timestamp time.Time) *events.PriceEvent {
+	index := sort.Search(len(priceEvents), func(i int) bool {
+		return !priceEvents[i].Timestamp.Before(timestamp)
+	})
+	if index == len(priceEvents) {
+		return nil
+	}
+	return priceEvents[index]
+}
+
+// Write writes the report in format, which must pass CheckFormat.
+func (r *Report) Write(w io.Writer, format string, horizons []Horizon) error {
+	switch format {
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatText:
+		return r.WriteText(w, horizons)
+	default:
+		return CheckFormat(format)
+	}
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r *Report) WriteText(w io.Writer, horizons []Horizon) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "TIME\tSYMBOL\tTYPE\tDIRECTION\tSTRENGTH\tENTRY")
+	for _, horizon := range horizons {
+		fmt.Fprintf(tw, "\t%s", horizon.Label)
+	}
+	fmt.Fprintln(tw)
+
+	for _, outcome := range r.Signals {
+		signal := outcome.Signal
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%.2f",
+			signal.Timestamp.Format(time.RFC3339), signal.Symbol, signalLabel(signal),
+			signal.Direction, signal.SignalStrength, outcome.EntryPrice)
+		for _, horizon := range horizons {
+			if forwardReturn, ok := outcome.ForwardReturns[horizon.Label]; ok {
+				fmt.Fprintf(tw, "\t%+.2f%%", forwardReturn*100)
+			} else {
+				fmt.Fprintf(tw, "\t-")
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+
+	fmt.Fprintf(tw, "\nEvents processed: %d, signals: %d\n", r.Events, len(r.Signals))
+
+	symbols := make([]string, 0, len(r.SignalsPerSymbol))
+	for symbol := range r.SignalsPerSymbol {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	fmt.Fprintln(tw, "\nSYMBOL\tSIGNALS")
+	for _, symbol := range symbols {
+		fmt.Fprintf(tw, "%s\t%d\n", symbol, r.SignalsPerSymbol[symbol])
+	}
+
+	fmt.Fprintln(tw, "\nHORIZON\tDIRECTION\tEVALUATED\tHIT RATE\tAVG RETURN")
+	for _, stats := range r.Stats {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.1f%%\t%+.2f%%\n",
+			stats.Horizon, stats.Direction, stats.Evaluated, stats.HitRate*100, stats.AvgReturn*100)
+	}
+
+	return tw.Flush()
+}
+
+func signalLabel(signal *events.TradingSignal) string {
+	if crossoverType, ok := signal.Details["crossover_type"].(string); ok {
+		return crossoverType
+	}
+	return signal.SignalType
+}
@@ -0,0 +1,86 @@
+package backtest
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+func TestEvaluate(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	var priceEvents []*events.PriceEvent
+	for hour := 0; hour <= 48; hour++ {
+		priceEvents = append(priceEvents, &events.PriceEvent{
+			Timestamp: start.Add(time.Duration(hour) * time.Hour),
+			Symbol:    "BTC",
+			PriceUSD:  100 + float64(hour),
+		})
+	}
+
+	signals := []*events.TradingSignal{
+		{Timestamp: start, Symbol: "BTC", Direction: "bullish", SignalType: events.SignalTypeMACrossover},
+		{Timestamp: start.Add(24 * time.Hour), Symbol: "BTC", Direction: "bearish", SignalType: events.SignalTypeMACrossover},
+	}
+
+	report := Evaluate(priceEvents, signals, DefaultHorizons)
+
+	if report.SignalsPerSymbol["BTC"] != 2 {
+		t.Errorf("expected 2 BTC signals, got %d", report.SignalsPerSymbol["BTC"])
+	}
+
+	first := report.Signals[0]
+	if first.EntryPrice != 100 {
+		t.Errorf("expected entry price 100, got %f", first.EntryPrice)
+	}
+	if math.Abs(first.ForwardReturns["1h"]-0.01) > 1e-9 {
+		t.Errorf("expected 1h return 1%%, got %f", first.ForwardReturns["1h"])
+	}
+	if math.Abs(first.ForwardReturns["24h"]-0.24) > 1e-9 {
+		t.Errorf("expected 24h return 24%%, got %f", first.ForwardReturns["24h"])
+	}
+	if _, ok := first.ForwardReturns["7d"]; ok {
+		t.Error("expected no 7d return past the end of the data")
+	}
+
+	hitRates := make(map[string]float64)
+	for _, stats := range report.Stats {
+		hitRates[stats.Horizon+"/"+stats.Direction] = stats.HitRate
+	}
+	if hitRates["1h/bullish"] != 1 {
+		t.Errorf("expected bullish 1h hit rate 1, got %f", hitRates["1h/bullish"])
+	}
+	if hitRates["1h/bearish"] != 0 {
+		t.Errorf("expected bearish 1h hit rate 0 in rising market, got %f", hitRates["1h/bearish"])
+	}
+
+	var output bytes.Buffer
+	if err := report.WriteText(&output, DefaultHorizons); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(output.String(), "HIT RATE") {
+		t.Errorf("expected summary table in output, got:\n%s", output.String())
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	report := Evaluate(nil, nil, DefaultHorizons)
+
+	for _, format := range []string{FormatText, FormatJSON} {
+		var out bytes.Buffer
+		if err := report.Write(&out, format, DefaultHorizons); err != nil || out.Len() == 0 {
+			t.Errorf("%s: expected output, got %v", format, err)
+		}
+	}
+
+	if err := CheckFormat("xml"); err == nil {
+		t.Error("expected unknown format to be rejected")
+	}
+	if err := report.Write(&bytes.Buffer{}, "xml", DefaultHorizons); err == nil {
+		t.Error("expected Write to reject an unknown format")
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"crypto-trackers/pkg/kafkaio"
+	"crypto-trackers/pkg/wire"
+
+	"github.com/IBM/sarama"
+)
+
+const usage = `kafka-dlq inspects dead-letter topics and re-injects their messages.
+
+Usage:
+  kafka-dlq list -topic crypto-prices.dlq [-since 24h] [-reason undecodable] [-group ma-signal-detector] [-values]
+  kafka-dlq reinject -topic ma-signal-detector.dlq -group ma-signal-detector [-since 24h] [-to ma-signal-detector.retry] [-dry-run]
+  kafka-dlq reinject -topic crypto-prices.dlq -reason undecodable -to crypto-prices [-since 24h] [-dry-run]
+`
+
+// kafka-dlq reads messages forwarded to a dead-letter topic by a consumer,
+// either because they could not be decoded or because their handler kept
+// failing, and sends them back once the cause is fixed. Messages a handler
+// failed on go to their consumer group's retry topic, so other groups that
+// already handled them do not see them twice. Undecodable messages would
+// only fail again unchanged, so they are only sent to an explicit -to topic,
+// typically after repairing them.
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+	if command != "list" && command != "reinject" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	brokers := flags.String("brokers", getEnv("KAFKA_BOOTSTRAP_SERVERS", "kafka-service:9092"), "comma-separated Kafka brokers")
+	topic := flags.String("topic", "", "dead-letter topic to read, e.g. crypto-prices.dlq")
+	since := flags.Duration("since", 24*time.Hour, "read dead letters published within this long ago")
+	reason := flags.String("reason", "", "only dead letters with this reason: undecodable or handler_failed")
+	group := flags.String("group", "", "only dead letters from this consumer group")
+	values := flags.Bool("values", false, "list: print each message's value")
+	to := flags.String("to", "", "reinject: topic to send to; empty uses the consumer group's retry topic")
+	dryRun := flags.Bool("dry-run", false, "reinject: list what would be sent without sending it")
+	flags.Parse(os.Args[2:])
+
+	if *topic == "" {
+		log.Fatal("-topic is required")
+	}
+	if command == "reinject" && *group == "" && *reason != kafkaio.ReasonUndecodable {
+		log.Fatalf("-group is required to re-inject %s dead letters", kafkaio.ReasonHandlerFailed)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var producer *kafkaio.Producer
+	if command == "reinject" && !*dryRun {
+		var err error
+		if producer, err = kafkaio.NewProducer(strings.Split(*brokers, ","), wire.JSON()); err != nil {
+			log.Fatalf("Failed to create producer: %v", err)
+		}
+		defer producer.Close()
+	}
+
+	// Every consumer group reading a topic quarantines the same undecodable
+	// message, so each original message is only re-injected once
+	seen := make(map[string]bool)
+	matched, sent, failed := 0, 0, 0
+
+	handler := func(message *sarama.ConsumerMessage) error {
+		deadLetter, err := kafkaio.ParseDeadLetter(message)
+		if err != nil {
+			failed++
+			return fmt.Errorf("not a dead letter: %w", err)
+		}
+		if (*reason != "" && deadLetter.Reason != *reason) || (*group != "" && deadLetter.ConsumerGroup != *group) {
+			return nil
+		}
+		matched++
+
+		target := *to
+		if target == "" && deadLetter.Reason == kafkaio.ReasonHandlerFailed {
+			target = kafkaio.RetryTopic(deadLetter.ConsumerGroup)
+		}
+		fmt.Printf("%s %s/%d@%d %s %s attempts=%d -> %s: %s\n",
+			deadLetter.FailedAt.Format(time.RFC3339), deadLetter.OriginalTopic, deadLetter.OriginalPartition,
+			deadLetter.OriginalOffset, deadLetter.ConsumerGroup, deadLetter.Reason, deadLetter.Attempts, describeTarget(target), deadLetter.Error)
+		if *values {
+			fmt.Printf("  %s\n", message.Value)
+		}
+		if command != "reinject" {
+			return nil
+		}
+		if target == "" {
+			failed++
+			return fmt.Errorf("refusing to re-inject %s message unchanged, pass -to", deadLetter.Reason)
+		}
+		if *dryRun {
+			return nil
+		}
+
+		original := fmt.Sprintf("%s/%d@%d", deadLetter.OriginalTopic, deadLetter.OriginalPartition, deadLetter.OriginalOffset)
+		if seen[original] {
+			return nil
+		}
+		if err := producer.Reinject(ctx, target, deadLetter); err != nil {
+			failed++
+			return err
+		}
+		seen[original] = true
+		sent++
+		return nil
+	}
+
+	if _, err := kafkaio.ReplayMessages(ctx, strings.Split(*brokers, ","), *topic, time.Now().Add(-*since), handler); err != nil {
+		log.Fatalf("Reading %s failed: %v", *topic, err)
+	}
+
+	if command == "reinject" && !*dryRun {
+		log.Printf("Re-injected %d of %d dead letters from %s, %d failed", sent, matched, *topic, failed)
+	} else {
+		log.Printf("Found %d dead letters in %s", matched, *topic)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// describeTarget names where a dead letter would be re-injected.
+func describeTarget(target string) string {
+	if target == "" {
+		return "(needs -to)"
+	}
+	return target
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,354 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	SignalTypeMACrossover       = "moving_average_crossover"
+	SignalTypeVolumeSpike       = "volume_spike"
+	SignalTypeRSIThreshold      = "rsi_threshold"
+	SignalTypeMACDCrossover     = "macd_crossover"
+	SignalTypeBollingerBreakout = "bollinger_breakout"
+	SignalTypeBollingerSqueeze  = "bollinger_squeeze"
+	SignalTypeConfluence        = "confluence"
+)
+
+// Details is the typed form of TradingSignal.Details for one signal type.
+type Details interface {
+	SignalType() string
+	Validate() error
+}
+
+type detailsSchema struct {
+	new      func() Details
+	required []string
+}
+
+// detailSchemas lists every signal type the topic carries. Fields not listed
+// as required decode as their zero value when absent.
+var detailSchemas = map[string]detailsSchema{
+	SignalTypeMACrossover: {
+		new:      func() Details { return &MACrossoverDetails{} },
+		required: []string{"ma_type", "fast_period", "slow_period", "fast_ma", "slow_ma", "crossover_type"},
+	},
+	SignalTypeVolumeSpike: {
+		new: func() Details { return &VolumeSpikeDetails{} },
+		required: []string{"current_volume", "avg_volume_7d", "spike_multiplier", "threshold_exceeded",
+			"window_hours", "spike_mode", "spike_score", "baseline_volume"},
+	},
+	SignalTypeRSIThreshold: {
+		new:      func() Details { return &RSIThresholdDetails{} },
+		required: []string{"rsi", "previous_rsi", "rsi_period", "overbought_level", "oversold_level", "threshold_type"},
+	},
+	SignalTypeMACDCrossover: {
+		new:      func() Details { return &MACDCrossoverDetails{} },
+		required: []string{"macd", "signal_line", "histogram", "fast_period", "slow_period", "signal_period", "crossover_type"},
+	},
+	SignalTypeBollingerBreakout: {
+		new:      func() Details { return &BollingerBreakoutDetails{} },
+		required: append(bollingerBandFields(), "breakout_type"),
+	},
+	SignalTypeBollingerSqueeze: {
+		new:      func() Details { return &BollingerSqueezeDetails{} },
+		required: append(bollingerBandFields(), "bandwidth_percentile", "squeeze_percentile", "squeeze_lookback"),
+	},
+	SignalTypeConfluence: {
+		new:      func() Details { return &ConfluenceDetails{} },
+		required: []string{"combined_score", "detector_count", "detectors", "signals", "window_minutes"},
+	},
+}
+
+func bollingerBandFields() []string {
+	return []string{"price", "upper_band", "middle_band", "lower_band", "percent_b", "bandwidth", "period", "std_devs"}
+}
+
+// MACrossoverDetails describes a fast average crossing a slow one.
+type MACrossoverDetails struct {
+	MAType        string  `json:"ma_type"`
+	FastPeriod    int     `json:"fast_period"`
+	SlowPeriod    int     `json:"slow_period"`
+	FastMA        float64 `json:"fast_ma"`
+	SlowMA        float64 `json:"slow_ma"`
+	CrossoverType string  `json:"crossover_type"`
+}
+
+func (d *MACrossoverDetails) SignalType() string { return SignalTypeMACrossover }
+
+func (d *MACrossoverDetails) Validate() error {
+	if d.FastPeriod <= 0 || d.SlowPeriod <= d.FastPeriod {
+		return fmt.Errorf("periods must satisfy 0 < fast_period < slow_period, got %d and %d", d.FastPeriod, d.SlowPeriod)
+	}
+	return oneOf("crossover_type", d.CrossoverType, "golden_cross", "death_cross")
+}
+
+// VolumeSpikeDetails describes 24h volume standing out from its baseline.
+// PriceChange24h and NeutralBand, which set the direction, may be absent.
+type VolumeSpikeDetails struct {
+	CurrentVolume     float64 `json:"current_volume"`
+	AvgVolume7d       float64 `json:"avg_volume_7d"`
+	SpikeMultiplier   float64 `json:"spike_multiplier"`
+	ThresholdExceeded float64 `json:"threshold_exceeded"`
+	WindowHours       float64 `json:"window_hours"`
+	PriceChange24h    float64 `json:"price_change_24h"`
+	NeutralBand       float64 `json:"neutral_band"`
+	SpikeMode         string  `json:"spike_mode"`
+	SpikeScore        float64 `json:"spike_score"`
+	BaselineVolume    float64 `json:"baseline_volume"`
+}
+
+func (d *VolumeSpikeDetails) SignalType() string { return SignalTypeVolumeSpike }
+
+func (d *VolumeSpikeDetails) Validate() error {
+	if d.CurrentVolume < 0 || d.AvgVolume7d < 0 || d.BaselineVolume < 0 {
+		return fmt.Errorf("volumes must not be negative")
+	}
+	if d.WindowHours <= 0 {
+		return fmt.Errorf("window_hours must be positive, got %v", d.WindowHours)
+	}
+	return oneOf("spike_mode", d.SpikeMode, "ratio", "zscore", "mad", "ewma")
+}
+
+// RSIThresholdDetails describes the RSI crossing into overbought or oversold
+// territory.
+type RSIThresholdDetails struct {
+	RSI             float64 `json:"rsi"`
+	PreviousRSI     float64 `json:"previous_rsi"`
+	RSIPeriod       int     `json:"rsi_period"`
+	OverboughtLevel float64 `json:"overbought_level"`
+	OversoldLevel   float64 `json:"oversold_level"`
+	ThresholdType   string  `json:"threshold_type"`
+}
+
+func (d *RSIThresholdDetails) SignalType() string { return SignalTypeRSIThreshold }
+
+func (d *RSIThresholdDetails) Validate() error {
+	if d.RSI < 0 || d.RSI > 100 {
+		return fmt.Errorf("rsi must be between 0 and 100, got %v", d.RSI)
+	}
+	if d.RSIPeriod <= 0 {
+		return fmt.Errorf("rsi_period must be positive, got %d", d.RSIPeriod)
+	}
+	return oneOf("threshold_type", d.ThresholdType, "overbought", "oversold")
+}
+
+// MACDCrossoverDetails describes the MACD line crossing its signal line.
+type MACDCrossoverDetails struct {
+	MACD          float64 `json:"macd"`
+	SignalLine    float64 `json:"signal_line"`
+	Histogram     float64 `json:"histogram"`
+	FastPeriod    int     `json:"fast_period"`
+	SlowPeriod    int     `json:"slow_period"`
+	SignalPeriod  int     `json:"signal_period"`
+	CrossoverType string  `json:"crossover_type"`
+}
+
+func (d *MACDCrossoverDetails) SignalType() string { return SignalTypeMACDCrossover }
+
+func (d *MACDCrossoverDetails) Validate() error {
+	if d.FastPeriod <= 0 || d.SlowPeriod <= d.FastPeriod || d.SignalPeriod <= 0 {
+		return fmt.Errorf("periods must satisfy 0 < fast_period < slow_period and signal_period > 0")
+	}
+	return oneOf("crossover_type", d.CrossoverType, "bullish_crossover", "bearish_crossover")
+}
+
+// BollingerBands are the band values shared by both Bollinger signal types.
+type BollingerBands struct {
+	Price      float64 `json:"price"`
+	UpperBand  float64 `json:"upper_band"`
+	MiddleBand float64 `json:"middle_band"`
+	LowerBand  float64 `json:"lower_band"`
+	PercentB   float64 `json:"percent_b"`
+	Bandwidth  float64 `json:"bandwidth"`
+	Period     int     `json:"period"`
+	StdDevs    float64 `json:"std_devs"`
+}
+
+func (b BollingerBands) validate() error {
+	if b.LowerBand > b.MiddleBand || b.MiddleBand > b.UpperBand {
+		return fmt.Errorf("bands must satisfy lower_band <= middle_band <= upper_band")
+	}
+	if b.Period <= 0 || b.StdDevs <= 0 {
+		return fmt.Errorf("period and std_devs must be positive, got %d and %v", b.Period, b.StdDevs)
+	}
+	return nil
+}
+
+// BollingerBreakoutDetails describes a close outside the bands.
+type BollingerBreakoutDetails struct {
+	BollingerBands
+	BreakoutType string `json:"breakout_type"`
+}
+
+func (d *BollingerBreakoutDetails) SignalType() string { return SignalTypeBollingerBreakout }
+
+func (d *BollingerBreakoutDetails) Validate() error {
+	if err := d.BollingerBands.validate(); err != nil {
+		return err
+	}
+	return oneOf("breakout_type", d.BreakoutType, "upper_breakout", "lower_breakout")
+}
+
+// BollingerSqueezeDetails describes bandwidth dropping to a low percentile of
+// its recent history.
+type BollingerSqueezeDetails struct {
+	BollingerBands
+	BandwidthPercentile float64 `json:"bandwidth_percentile"`
+	SqueezePercentile   float64 `json:"squeeze_percentile"`
+	SqueezeLookback     int     `json:"squeeze_lookback"`
+}
+
+func (d *BollingerSqueezeDetails) SignalType() string { return SignalTypeBollingerSqueeze }
+
+func (d *BollingerSqueezeDetails) Validate() error {
+	if err := d.BollingerBands.validate(); err != nil {
+		return err
+	}
+	if d.SqueezeLookback <= 0 {
+		return fmt.Errorf("squeeze_lookback must be positive, got %d", d.SqueezeLookback)
+	}
+	return nil
+}
+
+// ConfluenceDetails describes several detectors signalling the same symbol
+// within a window. CombinedScore is the magnitude of their strength weights
+// signed by direction.
+type ConfluenceDetails struct {
+	CombinedScore float64            `json:"combined_score"`
+	DetectorCount int                `json:"detector_count"`
+	Detectors     []string           `json:"detectors"`
+	Signals       []ConfluenceSignal `json:"signals"`
+	WindowMinutes float64            `json:"window_minutes"`
+}
+
+// ConfluenceSignal is the latest signal from one detector in a confluence.
+type ConfluenceSignal struct {
+	ServiceID      string    `json:"service_id"`
+	SignalType     string    `json:"signal_type"`
+	Direction      string    `json:"direction"`
+	SignalStrength string    `json:"signal_strength"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+func (d *ConfluenceDetails) SignalType() string { return SignalTypeConfluence }
+
+func (d *ConfluenceDetails) Validate() error {
+	if d.DetectorCount < 2 {
+		return fmt.Errorf("detector_count must be at least 2, got %d", d.DetectorCount)
+	}
+	if len(d.Detectors) != d.DetectorCount || len(d.Signals) != d.DetectorCount {
+		return fmt.Errorf("detectors and signals must both list detector_count entries")
+	}
+	if d.CombinedScore < 0 {
+		return fmt.Errorf("combined_score must not be negative, got %v", d.CombinedScore)
+	}
+	if d.WindowMinutes <= 0 {
+		return fmt.Errorf("window_minutes must be positive, got %v", d.WindowMinutes)
+	}
+	return nil
+}
+
+// DecodeDetails returns s.Details as the typed struct for s.SignalType, for
+// example *MACrossoverDetails. It fails for unknown signal types, missing or
+// mistyped fields and values the detectors never produce.
+func (s *TradingSignal) DecodeDetails() (Details, error) {
+	schema, ok := detailSchemas[s.SignalType]
+	if !ok {
+		return nil, fmt.Errorf("unknown signal type %q", s.SignalType)
+	}
+
+	var missing []string
+	for _, field := range schema.required {
+		if _, ok := s.Details[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s details missing %s", s.SignalType, strings.Join(missing, ", "))
+	}
+
+	data, err := json.Marshal(s.Details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s details: %w", s.SignalType, err)
+	}
+	details := schema.new()
+	if err := json.Unmarshal(data, details); err != nil {
+		return nil, fmt.Errorf("invalid %s details: %w", s.SignalType, err)
+	}
+	if err := details.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s details: %w", s.SignalType, err)
+	}
+	return details, nil
+}
+
+// SetDetails validates details and stores them as s.Details, setting
+// s.SignalType to match.
+func (s *TradingSignal) SetDetails(details Details) error {
+	if err := details.Validate(); err != nil {
+		return fmt.Errorf("invalid %s details: %w", details.SignalType(), err)
+	}
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s details: %w", details.SignalType(), err)
+	}
+	var encoded map[string]interface{}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return fmt.Errorf("failed to encode %s details: %w", details.SignalType(), err)
+	}
+
+	s.SignalType = details.SignalType()
+	s.Details = encoded
+	return nil
+}
+
+func oneOf(field, value string, allowed ...string) error {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %s, got %q", field, strings.Join(allowed, ", "), value)
+}
+
+// upgradeLegacyDetails fills the fields unversioned signals lack. The MA
+// detector used to publish only sma_20 and sma_50, and the volume detector
+// only the ratio of current to 7-day average volume. Legacy keys are kept
+// so existing routing conditions still match.
+func upgradeLegacyDetails(s *TradingSignal) {
+	if s.Details == nil {
+		return
+	}
+
+	switch s.SignalType {
+	case SignalTypeMACrossover:
+		fast, hasFast := s.Details["sma_20"]
+		slow, hasSlow := s.Details["sma_50"]
+		if !hasFast || !hasSlow {
+			return
+		}
+		setDefault(s.Details, "ma_type", "sma")
+		setDefault(s.Details, "fast_period", 20.0)
+		setDefault(s.Details, "slow_period", 50.0)
+		setDefault(s.Details, "fast_ma", fast)
+		setDefault(s.Details, "slow_ma", slow)
+	case SignalTypeVolumeSpike:
+		setDefault(s.Details, "window_hours", 168.0)
+		setDefault(s.Details, "spike_mode", "ratio")
+		if multiplier, ok := s.Details["spike_multiplier"]; ok {
+			setDefault(s.Details, "spike_score", multiplier)
+		}
+		if average, ok := s.Details["avg_volume_7d"]; ok {
+			setDefault(s.Details, "baseline_volume", average)
+		}
+	}
+}
+
+func setDefault(details map[string]interface{}, key string, value interface{}) {
+	if _, ok := details[key]; !ok {
+		details[key] = value
+	}
+}
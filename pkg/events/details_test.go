@@ -0,0 +1,217 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTradingSignal_SetDetails(t *testing.T) {
+	tests := []Details{
+		&MACrossoverDetails{MAType: "ema", FastPeriod: 12, SlowPeriod: 26, FastMA: 101.5, SlowMA: 100.2, CrossoverType: "golden_cross"},
+		&VolumeSpikeDetails{CurrentVolume: 3e9, AvgVolume7d: 1e9, SpikeMultiplier: 3, ThresholdExceeded: 2,
+			WindowHours: 24, PriceChange24h: -4.2, NeutralBand: 1, SpikeMode: "zscore", SpikeScore: 4.1, BaselineVolume: 1.1e9},
+		&RSIThresholdDetails{RSI: 28.4, PreviousRSI: 31.2, RSIPeriod: 14, OverboughtLevel: 70, OversoldLevel: 30, ThresholdType: "oversold"},
+		&MACDCrossoverDetails{MACD: -0.4, SignalLine: -0.5, Histogram: 0.1, FastPeriod: 12, SlowPeriod: 26, SignalPeriod: 9, CrossoverType: "bullish_crossover"},
+		&BollingerBreakoutDetails{
+			BollingerBands: BollingerBands{Price: 110, UpperBand: 108, MiddleBand: 100, LowerBand: 92, PercentB: 1.125, Bandwidth: 0.16, Period: 20, StdDevs: 2},
+			BreakoutType:   "upper_breakout",
+		},
+		&BollingerSqueezeDetails{
+			BollingerBands:      BollingerBands{Price: 100, UpperBand: 101, MiddleBand: 100, LowerBand: 99, PercentB: 0.5, Bandwidth: 0.02, Period: 20, StdDevs: 2},
+			BandwidthPercentile: 0,
+			SqueezePercentile:   10,
+			SqueezeLookback:     120,
+		},
+		&ConfluenceDetails{
+			CombinedScore: 5,
+			DetectorCount: 2,
+			Detectors:     []string{"ma-signal-detector", "volume-spike-detector"},
+			Signals: []ConfluenceSignal{
+				{ServiceID: "ma-signal-detector", SignalType: SignalTypeMACrossover, Direction: "bullish", SignalStrength: "strong",
+					Timestamp: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)},
+				{ServiceID: "volume-spike-detector", SignalType: SignalTypeVolumeSpike, Direction: "bullish", SignalStrength: "medium",
+					Timestamp: time.Date(2024, 1, 15, 10, 5, 0, 0, time.UTC)},
+			},
+			WindowMinutes: 60,
+		},
+	}
+
+	for _, details := range tests {
+		t.Run(details.SignalType(), func(t *testing.T) {
+			signal := validSignal()
+			if err := signal.SetDetails(details); err != nil {
+				t.Fatalf("failed to set details: %v", err)
+			}
+			if signal.SignalType != details.SignalType() {
+				t.Errorf("expected signal type %s, got %s", details.SignalType(), signal.SignalType)
+			}
+			if err := signal.Validate(); err != nil {
+				t.Fatalf("expected valid signal, got %v", err)
+			}
+
+			// Round-trip through JSON as a consumer would see it
+			data, err := json.Marshal(signal)
+			if err != nil {
+				t.Fatalf("failed to marshal signal: %v", err)
+			}
+			var decoded TradingSignal
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("failed to unmarshal signal: %v", err)
+			}
+			typed, err := decoded.DecodeDetails()
+			if err != nil {
+				t.Fatalf("failed to decode details: %v", err)
+			}
+			if got, _ := json.Marshal(typed); string(got) != mustMarshal(t, details) {
+				t.Errorf("expected details %s, got %s", mustMarshal(t, details), got)
+			}
+		})
+	}
+}
+
+func TestTradingSignal_SetDetailsRejectsInvalid(t *testing.T) {
+	signal := validSignal()
+	err := signal.SetDetails(&MACrossoverDetails{MAType: "sma", FastPeriod: 50, SlowPeriod: 20, CrossoverType: "golden_cross"})
+	if err == nil {
+		t.Fatal("expected error for fast period longer than slow")
+	}
+	if signal.SignalType != SignalTypeVolumeSpike {
+		t.Errorf("expected signal to be left unchanged, got type %s", signal.SignalType)
+	}
+}
+
+func TestTradingSignal_DecodeDetails(t *testing.T) {
+	signal := validSignal()
+	details, err := signal.DecodeDetails()
+	if err != nil {
+		t.Fatalf("failed to decode details: %v", err)
+	}
+	spike, ok := details.(*VolumeSpikeDetails)
+	if !ok {
+		t.Fatalf("expected *VolumeSpikeDetails, got %T", details)
+	}
+	if spike.SpikeMultiplier != 3.2 || spike.SpikeMode != "ratio" || spike.WindowHours != 168 {
+		t.Errorf("unexpected details %+v", spike)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*TradingSignal)
+	}{
+		{"unknown signal type", func(s *TradingSignal) { s.SignalType = "head_and_shoulders" }},
+		{"missing field", func(s *TradingSignal) { delete(s.Details, "baseline_volume") }},
+		{"string for number", func(s *TradingSignal) { s.Details["spike_multiplier"] = "3.2" }},
+		{"negative volume", func(s *TradingSignal) { s.Details["current_volume"] = -1.0 }},
+		{"fractional period", func(s *TradingSignal) {
+			s.SignalType = SignalTypeMACrossover
+			s.Details = map[string]interface{}{
+				"ma_type": "sma", "fast_period": 20.5, "slow_period": 50.0,
+				"fast_ma": 1.0, "slow_ma": 2.0, "crossover_type": "golden_cross",
+			}
+		}},
+		{"unknown crossover", func(s *TradingSignal) {
+			s.SignalType = SignalTypeMACrossover
+			s.Details = map[string]interface{}{
+				"ma_type": "sma", "fast_period": 20.0, "slow_period": 50.0,
+				"fast_ma": 1.0, "slow_ma": 2.0, "crossover_type": "silver_cross",
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal := validSignal()
+			tt.modify(signal)
+			if _, err := signal.DecodeDetails(); err == nil {
+				t.Error("expected decode error")
+			}
+		})
+	}
+}
+
+func TestTradingSignal_UnmarshalLegacy(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		check func(*testing.T, Details)
+	}{
+		{
+			name: "ma crossover",
+			json: `{"timestamp":"2024-01-15T10:00:00Z","symbol":"BTC","signal_type":"moving_average_crossover",
+				"signal_strength":"strong","direction":"bullish","service_id":"ma-detector-v1",
+				"details":{"sma_20":45123.5,"sma_50":44987.2,"crossover_type":"golden_cross"}}`,
+			check: func(t *testing.T, details Details) {
+				ma := details.(*MACrossoverDetails)
+				if ma.MAType != "sma" || ma.FastPeriod != 20 || ma.SlowPeriod != 50 {
+					t.Errorf("expected SMA 20/50, got %s %d/%d", ma.MAType, ma.FastPeriod, ma.SlowPeriod)
+				}
+				if ma.FastMA != 45123.5 || ma.SlowMA != 44987.2 {
+					t.Errorf("expected averages from sma_20 and sma_50, got %v and %v", ma.FastMA, ma.SlowMA)
+				}
+			},
+		},
+		{
+			name: "volume spike",
+			json: `{"timestamp":"2024-01-15T10:00:00Z","symbol":"ETH","signal_type":"volume_spike",
+				"signal_strength":"medium","direction":"bullish","service_id":"volume-detector-v1",
+				"details":{"current_volume":5000000000,"avg_volume_7d":2000000000,"spike_multiplier":2.5,"threshold_exceeded":2.0}}`,
+			check: func(t *testing.T, details Details) {
+				spike := details.(*VolumeSpikeDetails)
+				if spike.SpikeMode != "ratio" || spike.WindowHours != 168 {
+					t.Errorf("expected ratio mode over 168 hours, got %s over %v", spike.SpikeMode, spike.WindowHours)
+				}
+				if spike.SpikeScore != 2.5 || spike.BaselineVolume != 2e9 {
+					t.Errorf("expected score and baseline from the ratio, got %v and %v", spike.SpikeScore, spike.BaselineVolume)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var signal TradingSignal
+			if err := json.Unmarshal([]byte(tt.json), &signal); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+			if signal.SchemaVersion != 1 {
+				t.Errorf("expected schema version 1, got %d", signal.SchemaVersion)
+			}
+			if err := signal.Validate(); err != nil {
+				t.Fatalf("expected legacy signal to validate, got %v", err)
+			}
+			details, err := signal.DecodeDetails()
+			if err != nil {
+				t.Fatalf("failed to decode details: %v", err)
+			}
+			tt.check(t, details)
+		})
+	}
+}
+
+func TestTradingSignal_UnmarshalVersioned(t *testing.T) {
+	// Versioned signals are taken as written, so missing fields are caught
+	data := `{"schema_version":1,"timestamp":"2024-01-15T10:00:00Z","symbol":"BTC","signal_type":"moving_average_crossover",
+		"signal_strength":"strong","direction":"bullish","service_id":"ma-detector-v1",
+		"details":{"sma_20":45123.5,"sma_50":44987.2,"crossover_type":"golden_cross"}}`
+
+	var signal TradingSignal
+	if err := json.Unmarshal([]byte(data), &signal); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if _, ok := signal.Details["ma_type"]; ok {
+		t.Error("expected versioned details not to be upgraded")
+	}
+	if err := signal.Validate(); err == nil {
+		t.Error("expected versioned signal with legacy details to fail validation")
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	return string(data)
+}
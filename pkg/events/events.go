@@ -0,0 +1,139 @@
+// Package events defines the messages the services exchange over Kafka. Every
+// service decodes and encodes them through these types, so a schema change
+// is made here once.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	TopicPrices  = "crypto-prices"
+	TopicSignals = "trading-signals"
+
+	StrengthWeak   = "weak"
+	StrengthMedium = "medium"
+	StrengthStrong = "strong"
+
+	DirectionBullish = "bullish"
+	DirectionBearish = "bearish"
+	DirectionNeutral = "neutral"
+
+	// SchemaVersion is the TradingSignal schema this build writes and the
+	// newest one it reads. Bump it when Details change incompatibly, and
+	// roll out consumers before producers.
+	SchemaVersion = 1
+)
+
+// PriceEvent is a price snapshot published by data-ingestion to
+// TopicPrices, keyed by symbol.
+type PriceEvent struct {
+	Timestamp      time.Time `json:"timestamp"`
+	Symbol         string    `json:"symbol"`
+	PriceUSD       float64   `json:"price_usd"`
+	Volume24h      float64   `json:"volume_24h"`
+	MarketCap      float64   `json:"market_cap"`
+	PriceChange24h float64   `json:"price_change_24h"`
+	Source         string    `json:"source"`
+}
+
+// Validate rejects events the detectors cannot use: a missing symbol or
+// timestamp, or a negative or non-finite price or volume.
+func (e *PriceEvent) Validate() error {
+	if e.Symbol == "" {
+		return errors.New("price event has no symbol")
+	}
+	if e.Timestamp.IsZero() {
+		return fmt.Errorf("price event for %s has no timestamp", e.Symbol)
+	}
+	if !nonNegative(e.PriceUSD) {
+		return fmt.Errorf("price event for %s has invalid price_usd %v", e.Symbol, e.PriceUSD)
+	}
+	if !nonNegative(e.Volume24h) {
+		return fmt.Errorf("price event for %s has invalid volume_24h %v", e.Symbol, e.Volume24h)
+	}
+	return nil
+}
+
+// TradingSignal is published by the detectors to TopicSignals, keyed by
+// symbol. Details carries the values for SignalType; use SetDetails and
+// DecodeDetails rather than reading or writing the map directly.
+type TradingSignal struct {
+	SchemaVersion  int                    `json:"schema_version"`
+	Timestamp      time.Time              `json:"timestamp"`
+	Symbol         string                 `json:"symbol"`
+	SignalType     string                 `json:"signal_type"`
+	SignalStrength string                 `json:"signal_strength"`
+	Direction      string                 `json:"direction"`
+	Details        map[string]interface{} `json:"details"`
+	ServiceID      string                 `json:"service_id"`
+}
+
+// UnmarshalJSON decodes a signal, upgrading it with UpgradeLegacy.
+func (s *TradingSignal) UnmarshalJSON(data []byte) error {
+	type plain TradingSignal
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+
+	s.UpgradeLegacy()
+	return nil
+}
+
+// UpgradeLegacy upgrades an unversioned signal, from a detector that
+// predates schema_version, to version 1. Decoders of formats other than
+// JSON call it once the signal is filled in.
+func (s *TradingSignal) UpgradeLegacy() {
+	if s.SchemaVersion == 0 {
+		upgradeLegacyDetails(s)
+		s.SchemaVersion = 1
+	}
+}
+
+// Validate rejects signals missing a symbol, type, timestamp or service,
+// signals from a schema version this build does not know, signals whose
+// strength or direction is not one of the known values, and signals whose
+// details do not match their type.
+func (s *TradingSignal) Validate() error {
+	if s.Symbol == "" {
+		return errors.New("trading signal has no symbol")
+	}
+	if s.SignalType == "" {
+		return fmt.Errorf("trading signal for %s has no signal_type", s.Symbol)
+	}
+	if s.Timestamp.IsZero() {
+		return fmt.Errorf("%s signal for %s has no timestamp", s.SignalType, s.Symbol)
+	}
+	if s.ServiceID == "" {
+		return fmt.Errorf("%s signal for %s has no service_id", s.SignalType, s.Symbol)
+	}
+	if s.SchemaVersion < 1 || s.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("%s signal for %s has unsupported schema_version %d (expected 1 to %d)",
+			s.SignalType, s.Symbol, s.SchemaVersion, SchemaVersion)
+	}
+
+	switch s.SignalStrength {
+	case StrengthWeak, StrengthMedium, StrengthStrong:
+	default:
+		return fmt.Errorf("%s signal for %s has unknown signal_strength %q", s.SignalType, s.Symbol, s.SignalStrength)
+	}
+
+	switch s.Direction {
+	case DirectionBullish, DirectionBearish, DirectionNeutral:
+	default:
+		return fmt.Errorf("%s signal for %s has unknown direction %q", s.SignalType, s.Symbol, s.Direction)
+	}
+
+	if _, err := s.DecodeDetails(); err != nil {
+		return fmt.Errorf("%s signal for %s: %w", s.SignalType, s.Symbol, err)
+	}
+	return nil
+}
+
+func nonNegative(value float64) bool {
+	return value >= 0 && !math.IsInf(value, 0)
+}
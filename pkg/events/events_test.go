@@ -0,0 +1,103 @@
+package events
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func validSignal() *TradingSignal {
+	return &TradingSignal{
+		SchemaVersion:  SchemaVersion,
+		Timestamp:      time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		Symbol:         "BTC",
+		SignalType:     SignalTypeVolumeSpike,
+		SignalStrength: StrengthStrong,
+		Direction:      DirectionBullish,
+		Details: map[string]interface{}{
+			"current_volume":     3.2e9,
+			"avg_volume_7d":      1e9,
+			"spike_multiplier":   3.2,
+			"threshold_exceeded": 2.0,
+			"window_hours":       168.0,
+			"spike_mode":         "ratio",
+			"spike_score":        3.2,
+			"baseline_volume":    1e9,
+		},
+		ServiceID: "volume-spike-detector",
+	}
+}
+
+func TestTradingSignal_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*TradingSignal)
+		valid  bool
+	}{
+		{"valid", func(*TradingSignal) {}, true},
+		{"neutral", func(s *TradingSignal) { s.Direction = DirectionNeutral }, true},
+		{"missing schema version", func(s *TradingSignal) { s.SchemaVersion = 0 }, false},
+		{"newer schema version", func(s *TradingSignal) { s.SchemaVersion = SchemaVersion + 1 }, false},
+		{"unknown signal type", func(s *TradingSignal) { s.SignalType = "head_and_shoulders" }, false},
+		{"missing details", func(s *TradingSignal) { s.Details = nil }, false},
+		{"missing detail field", func(s *TradingSignal) { delete(s.Details, "spike_score") }, false},
+		{"mistyped detail field", func(s *TradingSignal) { s.Details["current_volume"] = "lots" }, false},
+		{"unknown spike mode", func(s *TradingSignal) { s.Details["spike_mode"] = "vibes" }, false},
+		{"missing symbol", func(s *TradingSignal) { s.Symbol = "" }, false},
+		{"missing signal type", func(s *TradingSignal) { s.SignalType = "" }, false},
+		{"missing timestamp", func(s *TradingSignal) { s.Timestamp = time.Time{} }, false},
+		{"missing service", func(s *TradingSignal) { s.ServiceID = "" }, false},
+		{"unknown strength", func(s *TradingSignal) { s.SignalStrength = "extreme" }, false},
+		{"unknown direction", func(s *TradingSignal) { s.Direction = "up" }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal := validSignal()
+			tt.modify(signal)
+
+			err := signal.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("expected valid signal, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestPriceEvent_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*PriceEvent)
+		valid  bool
+	}{
+		{"valid", func(*PriceEvent) {}, true},
+		{"falling price", func(e *PriceEvent) { e.PriceChange24h = -12.5 }, true},
+		{"missing symbol", func(e *PriceEvent) { e.Symbol = "" }, false},
+		{"missing timestamp", func(e *PriceEvent) { e.Timestamp = time.Time{} }, false},
+		{"negative price", func(e *PriceEvent) { e.PriceUSD = -1 }, false},
+		{"infinite volume", func(e *PriceEvent) { e.Volume24h = math.Inf(1) }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &PriceEvent{
+				Timestamp: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+				Symbol:    "BTC",
+				PriceUSD:  50000,
+				Volume24h: 1e9,
+			}
+			tt.modify(event)
+
+			err := event.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("expected valid event, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package kafkaio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"crypto-trackers/pkg/wire"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stallPolicy paces a claim stuck on a message that can be neither handled
+// nor dead-lettered, such as while the schema registry or the dead-letter
+// topic's broker is down.
+var stallPolicy = FailurePolicy{Backoff: time.Second, MaxBackoff: 30 * time.Second}
+
+// Consumer reads JSON or Avro messages of type T from a consumer group and
+// hands each one to eventHandler. Messages that fail to decode or validate
+// go to the poison queue if one is set, and are otherwise logged and
+// skipped. A message the handler fails on is handled as the FailurePolicy
+// says, and its offset is not marked until the handler succeeds or the
+// message is dead-lettered, so it is processed at least once.
+type Consumer[T any] struct {
+	client        sarama.ConsumerGroup
+	groupID       string
+	topics        []string
+	codec         *wire.Codec
+	eventHandler  func(*T) error
+	skipUntil     map[string]map[int32]int64
+	failurePolicy FailurePolicy
+	forwarder     Forwarder
+	poison        Forwarder
+	poisoned      *prometheus.CounterVec
+	retryBudget   time.Duration
+}
+
+type consumerGroupHandler[T any] struct {
+	groupID       string
+	codec         *wire.Codec
+	eventHandler  func(*T) error
+	skipUntil     map[string]map[int32]int64
+	failurePolicy FailurePolicy
+	forwarder     Forwarder
+	poison        Forwarder
+	poisoned      *prometheus.CounterVec
+	retryBudget   time.Duration
+}
+
+func NewConsumer[T any](brokers []string, groupID string, topics []string, codec *wire.Codec, eventHandler func(*T) error) (*Consumer[T], error) {
+	config := sarama.NewConfig()
+	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
+	config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	config.Consumer.Return.Errors = true
+
+	client, err := sarama.NewConsumerGroup(brokers, groupID, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka consumer group: %w", err)
+	}
+
+	return &Consumer[T]{
+		client:       client,
+		groupID:      groupID,
+		topics:       topics,
+		codec:        codec,
+		eventHandler: eventHandler,
+		// Retrying blocks the claim, and a member that takes longer than
+		// the rebalance timeout to give it up is evicted. Half leaves the
+		// rest for the handler calls and the dead-letter forward.
+		retryBudget: config.Consumer.Group.Rebalance.Timeout / 2,
+	}, nil
+}
+
+// SkipUntil drops messages below the given per-partition offsets, so events
+// already applied during a replay are not processed twice.
+func (c *Consumer[T]) SkipUntil(offsets map[string]map[int32]int64) {
+	c.skipUntil = offsets
+}
+
+// SetFailurePolicy sets how handler failures are retried, and the forwarder
+// that dead-letters messages once retries run out. Without a policy a failed
+// message is logged and skipped. Retries stop early, and the message is
+// dead-lettered, once their backoff would pass half the group's rebalance
+// timeout.
+func (c *Consumer[T]) SetFailurePolicy(policy FailurePolicy, forwarder Forwarder) {
+	if total := policy.totalBackoff(); c.retryBudget > 0 && total > c.retryBudget {
+		log.Printf("Retry backoff adds up to %s; retries stop after %s to stay within the rebalance timeout", total, c.retryBudget)
+	}
+	c.failurePolicy = policy
+	c.forwarder = forwarder
+}
+
+// SetPoisonQueue forwards messages that cannot be decoded or validated to
+// PoisonTopic of their topic, counting them by topic in poisoned. With a nil
+// forwarder they are only counted, and skipped.
+func (c *Consumer[T]) SetPoisonQueue(forwarder Forwarder, poisoned prometheus.CounterVec) {
+	c.poison = forwarder
+	c.poisoned = &poisoned
+}
+
+func (c *Consumer[T]) Start(ctx context.Context) error {
+	handler := &consumerGroupHandler[T]{
+		groupID:       c.groupID,
+		codec:         c.codec,
+		eventHandler:  c.eventHandler,
+		skipUntil:     c.skipUntil,
+		failurePolicy: c.failurePolicy,
+		forwarder:     c.forwarder,
+		poison:        c.poison,
+		poisoned:      c.poisoned,
+		retryBudget:   c.retryBudget,
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+			if err := c.client.Consume(ctx, c.topics, handler); err != nil {
+				log.Printf("Error consuming messages: %v", err)
+				return err
+			}
+		}
+	}
+}
+
+// Close leaves the consumer group once the current session ends, committing
+// the offsets it marked.
+func (c *Consumer[T]) Close() error {
+	return c.client.Close()
+}
+
+func (h *consumerGroupHandler[T]) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup commits marked offsets before the session ends, on a rebalance or
+// a graceful shutdown, rather than waiting for the next auto-commit.
+func (h *consumerGroupHandler[T]) Cleanup(session sarama.ConsumerGroupSession) error {
+	session.Commit()
+	return nil
+}
+
+// ConsumeClaim marks each message once it is handled. If a message can be
+// neither handled nor dead-lettered, the claim pauses on it and tries again
+// rather than ending the session, which would make the whole group
+// rebalance. If the session ends first, the message is left unmarked and
+// redelivered to the partition's next owner.
+func (h *consumerGroupHandler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			err := h.untilDone(session.Context(), message, func() error {
+				return h.handle(session.Context(), message)
+			})
+			if err != nil {
+				return nil
+			}
+			session.MarkMessage(message, "")
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// handle returns an error only if the message must not be marked.
+func (h *consumerGroupHandler[T]) handle(ctx context.Context, message *sarama.ConsumerMessage) error {
+	if end, ok := h.skipUntil[message.Topic][message.Partition]; ok && message.Offset < end {
+		return nil
+	}
+
+	event, err := decode[T](h.codec, message.Value)
+	if errors.Is(err, wire.ErrRegistryUnavailable) {
+		return err
+	}
+	if err != nil {
+		return h.quarantine(ctx, message, err)
+	}
+
+	attempts, err := h.handleWithRetries(ctx, event, message)
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	// Only the forward is tried again, so the handler is not run twice
+	return h.untilDone(ctx, message, func() error {
+		return h.deadLetter(ctx, message, attempts, err)
+	})
+}
+
+// untilDone runs step until it succeeds, waiting as stallPolicy says between
+// attempts. It returns an error only if ctx ends first.
+func (h *consumerGroupHandler[T]) untilDone(ctx context.Context, message *sarama.ConsumerMessage, step func() error) error {
+	for stall := 1; ; stall++ {
+		err := step()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		backoff := stallPolicy.backoff(stall)
+		log.Printf("Pausing at %s/%d offset %d for %s: %v", message.Topic, message.Partition, message.Offset, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (h *consumerGroupHandler[T]) handleWithRetries(ctx context.Context, event *T, message *sarama.ConsumerMessage) (int, error) {
+	err := h.eventHandler(event)
+	attempts := 1
+	start := time.Now()
+
+	for retry := 1; err != nil && retry <= h.failurePolicy.Retries; retry++ {
+		backoff := h.failurePolicy.backoff(retry)
+		if h.retryBudget > 0 && time.Since(start)+backoff > h.retryBudget {
+			log.Printf("Error handling message at %s/%d offset %d, giving up after %d attempts to stay within the rebalance timeout: %v",
+				message.Topic, message.Partition, message.Offset, attempts, err)
+			break
+		}
+		log.Printf("Error handling message at %s/%d offset %d, retrying in %s (%d of %d): %v",
+			message.Topic, message.Partition, message.Offset, backoff, retry, h.failurePolicy.Retries, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempts, err
+		case <-timer.C:
+		}
+
+		err = h.eventHandler(event)
+		attempts++
+	}
+	return attempts, err
+}
+
+func (h *consumerGroupHandler[T]) deadLetter(ctx context.Context, message *sarama.ConsumerMessage, attempts int, cause error) error {
+	topic := h.failurePolicy.DeadLetterTopic
+	if topic == "" || h.forwarder == nil {
+		log.Printf("Error handling message at %s/%d offset %d after %d attempts, skipping it: %v",
+			message.Topic, message.Partition, message.Offset, attempts, cause)
+		return nil
+	}
+
+	headers := deadLetterHeaders(message, h.groupID, ReasonHandlerFailed, attempts, cause)
+	if err := h.forwarder.Forward(ctx, topic, message, headers...); err != nil {
+		return fmt.Errorf("failed to dead-letter message after %d attempts (%v): %w", attempts, cause, err)
+	}
+
+	log.Printf("DEAD-LETTERED: Message at %s/%d offset %d sent to %s after %d attempts: %v",
+		message.Topic, message.Partition, message.Offset, topic, attempts, cause)
+	return nil
+}
+
+// quarantine forwards a message that cannot be decoded to its topic's poison
+// queue. Retrying would fail the same way, so it is never retried.
+func (h *consumerGroupHandler[T]) quarantine(ctx context.Context, message *sarama.ConsumerMessage, cause error) error {
+	if h.poison == nil {
+		log.Printf("Error decoding message at %s/%d offset %d, skipping it: %v", message.Topic, message.Partition, message.Offset, cause)
+	} else {
+		topic := PoisonTopic(message.Topic)
+		headers := deadLetterHeaders(message, h.groupID, ReasonUndecodable, 1, cause)
+		if err := h.poison.Forward(ctx, topic, message, headers...); err != nil {
+			return fmt.Errorf("failed to quarantine undecodable message (%v): %w", cause, err)
+		}
+		log.Printf("DEAD-LETTERED: Undecodable message at %s/%d offset %d sent to %s: %v",
+			message.Topic, message.Partition, message.Offset, topic, cause)
+	}
+
+	if h.poisoned != nil {
+		h.poisoned.WithLabelValues(message.Topic).Inc()
+	}
+	return nil
+}
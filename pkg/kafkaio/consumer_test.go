@@ -0,0 +1,426 @@
+package kafkaio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/wire"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func priceMessage(t *testing.T, offset int64, event *events.PriceEvent) *sarama.ConsumerMessage {
+	t.Helper()
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+	return &sarama.ConsumerMessage{Topic: events.TopicPrices, Partition: 0, Offset: offset, Value: data}
+}
+
+func TestConsumerGroupHandler_Handle(t *testing.T) {
+	var handled []*events.PriceEvent
+	handler := &consumerGroupHandler[events.PriceEvent]{
+		codec: wire.JSON(),
+		eventHandler: func(event *events.PriceEvent) error {
+			handled = append(handled, event)
+			return nil
+		},
+		skipUntil: map[string]map[int32]int64{events.TopicPrices: {0: 2}},
+	}
+
+	now := time.Now()
+	handler.handle(context.Background(), priceMessage(t, 1, &events.PriceEvent{Timestamp: now, Symbol: "BTC", PriceUSD: 1}))
+	handler.handle(context.Background(), priceMessage(t, 2, &events.PriceEvent{Timestamp: now, Symbol: "BTC", PriceUSD: 2}))
+	handler.handle(context.Background(), priceMessage(t, 3, &events.PriceEvent{Timestamp: now, PriceUSD: 3}))
+	handler.handle(context.Background(), &sarama.ConsumerMessage{Topic: events.TopicPrices, Offset: 4, Value: []byte("not json")})
+	handler.handle(context.Background(), priceMessage(t, 5, &events.PriceEvent{Timestamp: now, Symbol: "ETH", PriceUSD: 5}))
+
+	avro, err := wire.NewCodec(wire.FormatAvro, wire.NewLocalRegistry())
+	if err != nil {
+		t.Fatalf("failed to create codec: %v", err)
+	}
+	data, err := avro.Marshal(events.TopicPrices, &events.PriceEvent{Timestamp: now, Symbol: "SOL", PriceUSD: 6})
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+	handler.handle(context.Background(), &sarama.ConsumerMessage{Topic: events.TopicPrices, Offset: 6, Value: data})
+
+	if len(handled) != 3 {
+		t.Fatalf("expected 3 events past the skip offset that decode and validate, got %d", len(handled))
+	}
+	if handled[0].PriceUSD != 2 || handled[1].Symbol != "ETH" || handled[2].Symbol != "SOL" {
+		t.Errorf("expected events at offsets 2, 5 and 6, got %+v, %+v and %+v", handled[0], handled[1], handled[2])
+	}
+}
+
+func TestConsumerGroupHandler_Quarantine(t *testing.T) {
+	malformed := &sarama.ConsumerMessage{Topic: events.TopicPrices, Partition: 2, Offset: 9, Value: []byte("not json")}
+	invalid := priceMessage(t, 10, &events.PriceEvent{Timestamp: time.Now(), PriceUSD: 1})
+	newPoisoned := func() *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{Name: "kafka_poison_messages_total"}, []string{"topic"})
+	}
+
+	t.Run("forwarded to the topic's poison queue", func(t *testing.T) {
+		calls := 0
+		forwarder := &fakeForwarder{}
+		poisoned := newPoisoned()
+		handler := &consumerGroupHandler[events.PriceEvent]{
+			groupID: "ma-signal-detector", codec: wire.JSON(), eventHandler: failingHandler(0, &calls),
+			poison: forwarder, poisoned: poisoned,
+		}
+
+		for _, message := range []*sarama.ConsumerMessage{malformed, invalid} {
+			if err := handler.handle(context.Background(), message); err != nil {
+				t.Fatalf("expected quarantined message to be marked, got %v", err)
+			}
+		}
+		if calls != 0 || forwarder.forwarded != 2 {
+			t.Fatalf("expected 2 messages quarantined unhandled, got %d quarantined and %d calls", forwarder.forwarded, calls)
+		}
+		if forwarder.topic != "crypto-prices.dlq" || forwarder.headers[HeaderReason] != ReasonUndecodable {
+			t.Errorf("expected an undecodable message on crypto-prices.dlq, got %s %s", forwarder.topic, forwarder.headers[HeaderReason])
+		}
+		if forwarder.headers[HeaderOriginalOffset] != "10" || forwarder.headers[HeaderError] == "" {
+			t.Errorf("expected the original offset and decode error in headers, got %v", forwarder.headers)
+		}
+		if count := testutil.ToFloat64(poisoned.WithLabelValues(events.TopicPrices)); count != 2 {
+			t.Errorf("expected 2 poison messages counted, got %v", count)
+		}
+	})
+
+	t.Run("forward fails", func(t *testing.T) {
+		poisoned := newPoisoned()
+		handler := &consumerGroupHandler[events.PriceEvent]{
+			codec: wire.JSON(), poison: &fakeForwarder{err: errors.New("broker down")}, poisoned: poisoned,
+		}
+
+		if err := handler.handle(context.Background(), malformed); err == nil {
+			t.Error("expected error so the message is not marked")
+		}
+		if count := testutil.ToFloat64(poisoned.WithLabelValues(events.TopicPrices)); count != 0 {
+			t.Errorf("expected nothing counted until the message is quarantined, got %v", count)
+		}
+	})
+
+	t.Run("registry unavailable", func(t *testing.T) {
+		forwarder := &fakeForwarder{}
+		handler := &consumerGroupHandler[events.PriceEvent]{
+			codec: wire.JSON(), poison: forwarder, poisoned: newPoisoned(),
+		}
+		handler.codec, _ = wire.NewCodec(wire.FormatJSON, unavailableRegistry{})
+
+		if err := handler.handle(context.Background(), &sarama.ConsumerMessage{Topic: events.TopicPrices, Value: wire.Frame(99, nil)}); !errors.Is(err, wire.ErrRegistryUnavailable) {
+			t.Errorf("expected the message to be redelivered, got %v", err)
+		}
+		if forwarder.forwarded != 0 {
+			t.Error("expected a message whose schema could not be fetched not to be quarantined")
+		}
+	})
+}
+
+type unavailableRegistry struct{}
+
+func (unavailableRegistry) Register(subject, schema string) (int32, error) {
+	return 0, wire.ErrRegistryUnavailable
+}
+
+func (unavailableRegistry) Schema(id int32) (string, error) {
+	return "", wire.ErrRegistryUnavailable
+}
+
+// fakeForwarder fails every forward with err, or the first failures forwards
+// if err is nil.
+type fakeForwarder struct {
+	err       error
+	failures  int
+	topic     string
+	message   *sarama.ConsumerMessage
+	headers   map[string]string
+	forwarded int
+}
+
+func (f *fakeForwarder) Forward(ctx context.Context, topic string, message *sarama.ConsumerMessage, headers ...sarama.RecordHeader) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("broker down")
+	}
+	f.forwarded++
+	f.topic = topic
+	f.message = message
+	f.headers = make(map[string]string)
+	for _, header := range headers {
+		f.headers[string(header.Key)] = string(header.Value)
+	}
+	return nil
+}
+
+// shortStalls speeds up pausing on a stuck message for the test.
+func shortStalls(t *testing.T) {
+	t.Helper()
+	saved := stallPolicy
+	stallPolicy = FailurePolicy{Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+	t.Cleanup(func() { stallPolicy = saved })
+}
+
+func failingHandler(failures int, calls *int) func(*events.PriceEvent) error {
+	return func(*events.PriceEvent) error {
+		*calls++
+		if *calls <= failures {
+			return errors.New("publish failed")
+		}
+		return nil
+	}
+}
+
+func TestConsumerGroupHandler_HandleRetries(t *testing.T) {
+	policy := FailurePolicy{Retries: 2, Backoff: time.Millisecond, DeadLetterTopic: "ma-signal-detector.dlq"}
+	message := priceMessage(t, 7, &events.PriceEvent{Timestamp: time.Now(), Symbol: "BTC", PriceUSD: 1})
+
+	t.Run("succeeds on retry", func(t *testing.T) {
+		calls := 0
+		forwarder := &fakeForwarder{}
+		handler := &consumerGroupHandler[events.PriceEvent]{
+			codec: wire.JSON(), eventHandler: failingHandler(2, &calls),
+			failurePolicy: policy, forwarder: forwarder,
+		}
+
+		if err := handler.handle(context.Background(), message); err != nil {
+			t.Fatalf("expected message to be handled, got %v", err)
+		}
+		if calls != 3 || forwarder.forwarded != 0 {
+			t.Errorf("expected 3 calls and no dead letter, got %d calls and %d dead letters", calls, forwarder.forwarded)
+		}
+	})
+
+	t.Run("dead-letters after retries", func(t *testing.T) {
+		calls := 0
+		forwarder := &fakeForwarder{}
+		handler := &consumerGroupHandler[events.PriceEvent]{
+			groupID: "ma-signal-detector", codec: wire.JSON(), eventHandler: failingHandler(3, &calls),
+			failurePolicy: policy, forwarder: forwarder,
+		}
+
+		if err := handler.handle(context.Background(), message); err != nil {
+			t.Fatalf("expected dead-lettered message to be marked, got %v", err)
+		}
+		if calls != 3 || forwarder.forwarded != 1 {
+			t.Fatalf("expected 3 calls and 1 dead letter, got %d calls and %d dead letters", calls, forwarder.forwarded)
+		}
+		if forwarder.topic != "ma-signal-detector.dlq" || forwarder.message != message {
+			t.Errorf("expected the original message on the DLQ, got %s", forwarder.topic)
+		}
+
+		expected := map[string]string{
+			HeaderReason:            ReasonHandlerFailed,
+			HeaderOriginalTopic:     events.TopicPrices,
+			HeaderOriginalPartition: "0",
+			HeaderOriginalOffset:    "7",
+			HeaderConsumerGroup:     "ma-signal-detector",
+			HeaderError:             "publish failed",
+			HeaderAttempts:          "3",
+		}
+		for key, value := range expected {
+			if forwarder.headers[key] != value {
+				t.Errorf("expected header %s=%s, got %q", key, value, forwarder.headers[key])
+			}
+		}
+		if _, err := time.Parse(time.RFC3339, forwarder.headers[HeaderFailedAt]); err != nil {
+			t.Errorf("expected RFC 3339 failure time, got %q", forwarder.headers[HeaderFailedAt])
+		}
+	})
+
+	t.Run("dead letter fails then recovers", func(t *testing.T) {
+		shortStalls(t)
+		calls := 0
+		forwarder := &fakeForwarder{failures: 2}
+		handler := &consumerGroupHandler[events.PriceEvent]{
+			codec: wire.JSON(), eventHandler: failingHandler(3, &calls),
+			failurePolicy: policy, forwarder: forwarder,
+		}
+
+		if err := handler.handle(context.Background(), message); err != nil {
+			t.Fatalf("expected the forward to be tried until it succeeds, got %v", err)
+		}
+		if calls != 3 || forwarder.forwarded != 1 {
+			t.Errorf("expected the handler not to run again while forwarding, got %d calls and %d dead letters", calls, forwarder.forwarded)
+		}
+	})
+
+	t.Run("dead letter fails until the session ends", func(t *testing.T) {
+		shortStalls(t)
+		calls := 0
+		handler := &consumerGroupHandler[events.PriceEvent]{
+			codec: wire.JSON(), eventHandler: failingHandler(3, &calls),
+			failurePolicy: policy, forwarder: &fakeForwarder{err: errors.New("broker down")},
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := handler.handle(ctx, message); err == nil {
+			t.Error("expected error so the message is not marked")
+		}
+	})
+
+	t.Run("retries stop within the budget", func(t *testing.T) {
+		calls := 0
+		forwarder := &fakeForwarder{}
+		handler := &consumerGroupHandler[events.PriceEvent]{
+			codec: wire.JSON(), eventHandler: failingHandler(10, &calls),
+			failurePolicy: FailurePolicy{Retries: 5, Backoff: 20 * time.Millisecond, DeadLetterTopic: "dlq"},
+			forwarder:     forwarder, retryBudget: 50 * time.Millisecond,
+		}
+
+		if err := handler.handle(context.Background(), message); err != nil {
+			t.Fatalf("expected dead-lettered message to be marked, got %v", err)
+		}
+		// 20ms and 40ms backoffs fit; the third, 80ms, would pass the budget
+		if calls != 2 || forwarder.headers[HeaderAttempts] != "2" {
+			t.Errorf("expected 2 attempts before the budget ran out, got %d calls and attempts header %q", calls, forwarder.headers[HeaderAttempts])
+		}
+	})
+
+	t.Run("no dead-letter topic", func(t *testing.T) {
+		calls := 0
+		handler := &consumerGroupHandler[events.PriceEvent]{
+			codec: wire.JSON(), eventHandler: failingHandler(1, &calls),
+		}
+
+		if err := handler.handle(context.Background(), message); err != nil {
+			t.Errorf("expected message to be skipped, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected no retries without a policy, got %d calls", calls)
+		}
+	})
+
+	t.Run("cancelled during backoff", func(t *testing.T) {
+		calls := 0
+		forwarder := &fakeForwarder{}
+		handler := &consumerGroupHandler[events.PriceEvent]{
+			codec: wire.JSON(), eventHandler: failingHandler(1, &calls),
+			failurePolicy: FailurePolicy{Retries: 1, Backoff: time.Hour, DeadLetterTopic: "dlq"}, forwarder: forwarder,
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := handler.handle(ctx, message); err == nil {
+			t.Error("expected error so the message is redelivered")
+		}
+		if forwarder.forwarded != 0 {
+			t.Error("expected no dead letter when the session ends")
+		}
+	})
+}
+
+func TestFailurePolicy_Backoff(t *testing.T) {
+	policy := FailurePolicy{Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want {
+			t.Errorf("retry %d: expected %s, got %s", i+1, want, got)
+		}
+	}
+
+	policy.Retries = 4
+	if total := policy.totalBackoff(); total != 900*time.Millisecond {
+		t.Errorf("expected 900ms of backoff in all, got %s", total)
+	}
+}
+
+type fakeSession struct {
+	ctx       context.Context
+	mutex     sync.Mutex
+	marked    []int64
+	committed bool
+}
+
+func (s *fakeSession) Claims() map[string][]int32 { return nil }
+func (s *fakeSession) MemberID() string           { return "member" }
+func (s *fakeSession) GenerationID() int32        { return 1 }
+func (s *fakeSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+}
+func (s *fakeSession) Commit() {
+	s.committed = true
+}
+func (s *fakeSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
+}
+func (s *fakeSession) MarkMessage(message *sarama.ConsumerMessage, metadata string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.marked = append(s.marked, message.Offset)
+}
+func (s *fakeSession) Context() context.Context { return s.ctx }
+
+type fakeClaim struct {
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Topic() string                            { return events.TopicPrices }
+func (c *fakeClaim) Partition() int32                         { return 0 }
+func (c *fakeClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return 0 }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+func TestConsumerGroupHandler_ConsumeClaim(t *testing.T) {
+	shortStalls(t)
+	now := time.Now()
+	newClaim := func() *fakeClaim {
+		claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 3)}
+		claim.messages <- priceMessage(t, 0, &events.PriceEvent{Timestamp: now, Symbol: "BTC", PriceUSD: 1})
+		claim.messages <- priceMessage(t, 1, &events.PriceEvent{Timestamp: now, Symbol: "FAIL", PriceUSD: 1})
+		claim.messages <- priceMessage(t, 2, &events.PriceEvent{Timestamp: now, Symbol: "ETH", PriceUSD: 1})
+		close(claim.messages)
+		return claim
+	}
+	newHandler := func(forwarder *fakeForwarder) *consumerGroupHandler[events.PriceEvent] {
+		return &consumerGroupHandler[events.PriceEvent]{
+			codec: wire.JSON(),
+			eventHandler: func(event *events.PriceEvent) error {
+				if event.Symbol == "FAIL" {
+					return errors.New("publish failed")
+				}
+				return nil
+			},
+			failurePolicy: FailurePolicy{DeadLetterTopic: "dlq"},
+			forwarder:     forwarder,
+		}
+	}
+
+	t.Run("pauses until the dead-letter topic is back", func(t *testing.T) {
+		session := &fakeSession{ctx: context.Background()}
+		if err := newHandler(&fakeForwarder{failures: 3}).ConsumeClaim(session, newClaim()); err != nil {
+			t.Fatalf("expected the claim not to end the session, got %v", err)
+		}
+		if len(session.marked) != 3 {
+			t.Errorf("expected every offset marked once the forward went through, got %v", session.marked)
+		}
+	})
+
+	t.Run("leaves the message unmarked when the session ends", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		session := &fakeSession{ctx: ctx}
+		handler := newHandler(&fakeForwarder{err: errors.New("broker down")})
+		if err := handler.ConsumeClaim(session, newClaim()); err != nil {
+			t.Errorf("expected the claim to return quietly when the session ends, got %v", err)
+		}
+		if len(session.marked) != 1 || session.marked[0] != 0 {
+			t.Errorf("expected only offset 0 marked, got %v", session.marked)
+		}
+
+		if err := handler.Cleanup(session); err != nil || !session.committed {
+			t.Errorf("expected cleanup to commit offsets, got %v", err)
+		}
+	})
+}
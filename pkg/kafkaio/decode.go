@@ -0,0 +1,29 @@
+package kafkaio
+
+import (
+	"fmt"
+
+	"crypto-trackers/pkg/wire"
+)
+
+// Validator is implemented by messages that can check their own contents,
+// such as events.PriceEvent and events.TradingSignal.
+type Validator interface {
+	Validate() error
+}
+
+// decode unmarshals a JSON or Avro message value into a T and validates it
+// if T implements Validator.
+func decode[T any](codec *wire.Codec, data []byte) (*T, error) {
+	var message T
+	if err := codec.Unmarshal(data, &message); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %T: %w", message, err)
+	}
+
+	if validator, ok := any(&message).(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %T: %w", message, err)
+		}
+	}
+	return &message, nil
+}
@@ -0,0 +1,60 @@
+package kafkaio
+
+import (
+	"time"
+
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/wire"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DetectorSettings is how a detector consumes price events and handles the
+// ones it fails on.
+type DetectorSettings struct {
+	GroupID    string
+	RetryTopic string
+	// Retries, Backoff and DeadLetterTopic make up the FailurePolicy, with
+	// backoff capped at 30 seconds
+	Retries         int
+	Backoff         time.Duration
+	DeadLetterTopic string
+	// PoisonQueue forwards undecodable messages to the price topic's poison
+	// queue instead of skipping them
+	PoisonQueue bool
+}
+
+// NewDetectorConsumer consumes price events, and the group's retries of
+// them, for a detector publishing through producer. newHandler builds the
+// detector around the SignalProducer it is given and returns its handler.
+// That producer is an Outbox holding signals that fail to publish, so
+// retrying their price event republishes them without applying the event
+// twice. The Outbox is returned for closing on shutdown.
+func NewDetectorConsumer(brokers []string, codec *wire.Codec, producer *Producer, settings DetectorSettings, poisoned prometheus.CounterVec, newHandler func(SignalProducer) func(*events.PriceEvent) error) (*Outbox, *Consumer[events.PriceEvent], error) {
+	outbox := NewOutbox(producer)
+
+	consumer, err := NewConsumer(
+		brokers,
+		settings.GroupID,
+		[]string{events.TopicPrices, settings.RetryTopic},
+		codec,
+		outbox.Wrap(newHandler(outbox)),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	consumer.SetFailurePolicy(FailurePolicy{
+		Retries:         settings.Retries,
+		Backoff:         settings.Backoff,
+		MaxBackoff:      30 * time.Second,
+		DeadLetterTopic: settings.DeadLetterTopic,
+	}, producer)
+
+	var poison Forwarder
+	if settings.PoisonQueue {
+		poison = producer
+	}
+	consumer.SetPoisonQueue(poison, poisoned)
+
+	return outbox, consumer, nil
+}
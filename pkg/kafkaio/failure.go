@@ -0,0 +1,162 @@
+package kafkaio
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Headers added to messages forwarded to a dead-letter topic.
+const (
+	HeaderPrefix            = "dlq."
+	HeaderReason            = "dlq.reason"
+	HeaderOriginalTopic     = "dlq.original.topic"
+	HeaderOriginalPartition = "dlq.original.partition"
+	HeaderOriginalOffset    = "dlq.original.offset"
+	HeaderConsumerGroup     = "dlq.consumer.group"
+	HeaderError             = "dlq.error"
+	HeaderAttempts          = "dlq.attempts"
+	HeaderFailedAt          = "dlq.failed.at"
+)
+
+// Values of HeaderReason: the handler kept failing on the message, or it
+// could not be decoded at all.
+const (
+	ReasonHandlerFailed = "handler_failed"
+	ReasonUndecodable   = "undecodable"
+)
+
+// PoisonTopic is the dead-letter topic for messages on topic that cannot be
+// decoded, shared by every consumer group reading it.
+func PoisonTopic(topic string) string {
+	return topic + ".dlq"
+}
+
+// RetryTopic is the topic a consumer group reads alongside its own topics,
+// where dead letters it failed to handle are re-injected. Unlike the
+// original topic, no other consumer group sees them again.
+func RetryTopic(groupID string) string {
+	return groupID + ".retry"
+}
+
+// FailurePolicy decides what a Consumer does with a message its handler
+// fails on. The message is retried, then forwarded to DeadLetterTopic, and
+// its offset is only marked once one of those succeeds.
+type FailurePolicy struct {
+	// Retries is how many times the handler is called again after it
+	// first fails.
+	Retries int
+	// Backoff is the wait before the first retry. It doubles after each
+	// retry, up to MaxBackoff if that is set.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// DeadLetterTopic receives messages that still fail after the retries.
+	// If empty, they are logged and skipped.
+	DeadLetterTopic string
+}
+
+// backoff returns the wait before the given retry, counting from 1.
+func (p FailurePolicy) backoff(retry int) time.Duration {
+	backoff := p.Backoff
+	for i := 1; i < retry; i++ {
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return backoff
+}
+
+// totalBackoff is the longest the retries can wait in all.
+func (p FailurePolicy) totalBackoff() time.Duration {
+	var total time.Duration
+	for retry := 1; retry <= p.Retries; retry++ {
+		total += p.backoff(retry)
+	}
+	return total
+}
+
+// Forwarder copies a consumed message, with extra headers, to another topic.
+// Producer implements it.
+type Forwarder interface {
+	Forward(ctx context.Context, topic string, message *sarama.ConsumerMessage, headers ...sarama.RecordHeader) error
+}
+
+func deadLetterHeaders(message *sarama.ConsumerMessage, groupID, reason string, attempts int, cause error) []sarama.RecordHeader {
+	header := func(key, value string) sarama.RecordHeader {
+		return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+	}
+	return []sarama.RecordHeader{
+		header(HeaderReason, reason),
+		header(HeaderOriginalTopic, message.Topic),
+		header(HeaderOriginalPartition, strconv.FormatInt(int64(message.Partition), 10)),
+		header(HeaderOriginalOffset, strconv.FormatInt(message.Offset, 10)),
+		header(HeaderConsumerGroup, groupID),
+		header(HeaderError, cause.Error()),
+		header(HeaderAttempts, strconv.Itoa(attempts)),
+		header(HeaderFailedAt, time.Now().UTC().Format(time.RFC3339)),
+	}
+}
+
+// DeadLetter is a message read back from a dead-letter topic, with the
+// failure recorded in its headers.
+type DeadLetter struct {
+	Message           *sarama.ConsumerMessage
+	Reason            string
+	OriginalTopic     string
+	OriginalPartition int32
+	OriginalOffset    int64
+	ConsumerGroup     string
+	Error             string
+	Attempts          int
+	FailedAt          time.Time
+}
+
+// ParseDeadLetter reads the failure headers of a dead-lettered message.
+func ParseDeadLetter(message *sarama.ConsumerMessage) (*DeadLetter, error) {
+	headers := make(map[string]string)
+	for _, header := range message.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+
+	deadLetter := &DeadLetter{
+		Message:       message,
+		Reason:        headers[HeaderReason],
+		OriginalTopic: headers[HeaderOriginalTopic],
+		ConsumerGroup: headers[HeaderConsumerGroup],
+		Error:         headers[HeaderError],
+	}
+	if deadLetter.OriginalTopic == "" {
+		return nil, fmt.Errorf("missing %s header", HeaderOriginalTopic)
+	}
+
+	partition, err := strconv.ParseInt(headers[HeaderOriginalPartition], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %w", HeaderOriginalPartition, err)
+	}
+	deadLetter.OriginalPartition = int32(partition)
+	if deadLetter.OriginalOffset, err = strconv.ParseInt(headers[HeaderOriginalOffset], 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid %s header: %w", HeaderOriginalOffset, err)
+	}
+
+	// Attempts and failure time are informational, so bad values are left zero
+	deadLetter.Attempts, _ = strconv.Atoi(headers[HeaderAttempts])
+	deadLetter.FailedAt, _ = time.Parse(time.RFC3339, headers[HeaderFailedAt])
+	return deadLetter, nil
+}
+
+// withoutDeadLetterHeaders drops the failure headers, so a re-injected
+// message looks as it did before it failed.
+func withoutDeadLetterHeaders(headers []*sarama.RecordHeader) []sarama.RecordHeader {
+	var kept []sarama.RecordHeader
+	for _, header := range headers {
+		if !strings.HasPrefix(string(header.Key), HeaderPrefix) {
+			kept = append(kept, *header)
+		}
+	}
+	return kept
+}
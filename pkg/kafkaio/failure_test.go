@@ -0,0 +1,39 @@
+package kafkaio
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"crypto-trackers/pkg/events"
+
+	"github.com/IBM/sarama"
+)
+
+func TestParseDeadLetter(t *testing.T) {
+	original := &sarama.ConsumerMessage{Topic: events.TopicPrices, Partition: 2, Offset: 41, Value: []byte("not json")}
+	headers := deadLetterHeaders(original, "ma-signal-detector", ReasonUndecodable, 1, errors.New("invalid character"))
+
+	message := &sarama.ConsumerMessage{Topic: PoisonTopic(events.TopicPrices), Value: original.Value}
+	for i := range headers {
+		message.Headers = append(message.Headers, &headers[i])
+	}
+
+	deadLetter, err := ParseDeadLetter(message)
+	if err != nil {
+		t.Fatalf("failed to parse dead letter: %v", err)
+	}
+	if deadLetter.OriginalTopic != events.TopicPrices || deadLetter.OriginalPartition != 2 || deadLetter.OriginalOffset != 41 {
+		t.Errorf("expected crypto-prices/2 offset 41, got %s/%d offset %d", deadLetter.OriginalTopic, deadLetter.OriginalPartition, deadLetter.OriginalOffset)
+	}
+	if deadLetter.Reason != ReasonUndecodable || deadLetter.ConsumerGroup != "ma-signal-detector" || deadLetter.Error != "invalid character" {
+		t.Errorf("unexpected failure details %+v", deadLetter)
+	}
+	if deadLetter.Attempts != 1 || time.Since(deadLetter.FailedAt) > time.Minute {
+		t.Errorf("expected 1 attempt just now, got %d at %s", deadLetter.Attempts, deadLetter.FailedAt)
+	}
+
+	if _, err := ParseDeadLetter(original); err == nil {
+		t.Error("expected error for a message without dead-letter headers")
+	}
+}
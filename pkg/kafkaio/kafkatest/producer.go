@@ -0,0 +1,69 @@
+// Package kafkatest provides fakes of the kafkaio interfaces for tests.
+package kafkatest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"crypto-trackers/pkg/events"
+)
+
+// SignalProducer records published signals in memory. Like the real
+// producer it rejects signals that fail validation. Set Err to make every
+// publish fail with it.
+type SignalProducer struct {
+	Err error
+
+	mutex   sync.Mutex
+	signals []*events.TradingSignal
+}
+
+func (p *SignalProducer) PublishSignal(ctx context.Context, topic string, signal *events.TradingSignal) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if p.Err != nil {
+		return p.Err
+	}
+	if err := signal.Validate(); err != nil {
+		return fmt.Errorf("refusing to publish invalid signal: %w", err)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.signals = append(p.signals, signal)
+	return nil
+}
+
+func (p *SignalProducer) Close() error {
+	return nil
+}
+
+// Signals returns the signals published so far, oldest first.
+func (p *SignalProducer) Signals() []*events.TradingSignal {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	signals := make([]*events.TradingSignal, len(p.signals))
+	copy(signals, p.signals)
+	return signals
+}
+
+// SignalsOfType returns the published signals with the given signal type.
+func (p *SignalProducer) SignalsOfType(signalType string) []*events.TradingSignal {
+	var matched []*events.TradingSignal
+	for _, signal := range p.Signals() {
+		if signal.SignalType == signalType {
+			matched = append(matched, signal)
+		}
+	}
+	return matched
+}
+
+// Reset forgets the signals published so far.
+func (p *SignalProducer) Reset() {
+	p.mutex.Lock()
+	p.signals = nil
+	p.mutex.Unlock()
+}
@@ -0,0 +1,117 @@
+package kafkaio
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+// Outbox is a SignalProducer that remembers the last price event applied
+// for each symbol, along with the signals it failed to publish, so that when
+// the consumer retries that event, those signals are republished instead of
+// the event being applied to the detector twice. Detectors stamp signals with
+// their event's timestamp, and events are keyed by symbol, so symbol and
+// timestamp identify the event.
+type Outbox struct {
+	producer SignalProducer
+
+	mutex   sync.Mutex
+	applied map[string]*appliedEvent
+}
+
+type appliedEvent struct {
+	timestamp time.Time
+	pending   []pendingSignal
+}
+
+type pendingSignal struct {
+	topic  string
+	signal *events.TradingSignal
+}
+
+func NewOutbox(producer SignalProducer) *Outbox {
+	return &Outbox{
+		producer: producer,
+		applied:  make(map[string]*appliedEvent),
+	}
+}
+
+// PublishSignal publishes signal, holding on to it if that fails.
+func (o *Outbox) PublishSignal(ctx context.Context, topic string, signal *events.TradingSignal) error {
+	err := o.producer.PublishSignal(ctx, topic, signal)
+	if err == nil {
+		return nil
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	applied := o.applied[signal.Symbol]
+	if applied != nil && signal.Timestamp.Before(applied.timestamp) {
+		// Wrap never republishes for an event older than the last applied
+		return err
+	}
+	if applied == nil || !applied.timestamp.Equal(signal.Timestamp) {
+		applied = &appliedEvent{timestamp: signal.Timestamp}
+		o.applied[signal.Symbol] = applied
+	}
+	applied.pending = append(applied.pending, pendingSignal{topic: topic, signal: signal})
+	return err
+}
+
+func (o *Outbox) Close() error {
+	return o.producer.Close()
+}
+
+// Wrap returns a price event handler that applies each event once. The
+// event is recorded as applied before handler runs, so a retry, whatever
+// made the handler fail, only republishes the signals the event left
+// unpublished. Signals left by an earlier event were dead-lettered with it,
+// so they are dropped.
+//
+// An event older than the last one applied, such as a dead letter
+// re-injected through the retry topic, goes to handler without replacing
+// that record; detectors order their history by event time and decide
+// whether it still fits.
+func (o *Outbox) Wrap(handler func(*events.PriceEvent) error) func(*events.PriceEvent) error {
+	return func(event *events.PriceEvent) error {
+		o.mutex.Lock()
+		applied := o.applied[event.Symbol]
+		if applied != nil && applied.timestamp.Equal(event.Timestamp) {
+			pending := applied.pending
+			applied.pending = nil
+			o.mutex.Unlock()
+			return o.republish(event, pending)
+		}
+		if applied != nil && event.Timestamp.Before(applied.timestamp) {
+			o.mutex.Unlock()
+			return handler(event)
+		}
+		if applied != nil && len(applied.pending) > 0 {
+			log.Printf("Dropping %d unpublished signals for %s from an event that was not retried", len(applied.pending), event.Symbol)
+		}
+		o.applied[event.Symbol] = &appliedEvent{timestamp: event.Timestamp}
+		o.mutex.Unlock()
+
+		return handler(event)
+	}
+}
+
+func (o *Outbox) republish(event *events.PriceEvent, pending []pendingSignal) error {
+	if len(pending) == 0 {
+		log.Printf("Skipping price event for %s at %s, already applied", event.Symbol, event.Timestamp.Format(time.RFC3339))
+		return nil
+	}
+
+	var errs []error
+	for _, p := range pending {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		errs = append(errs, o.PublishSignal(ctx, p.topic, p.signal))
+		cancel()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,162 @@
+package kafkaio
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio/kafkatest"
+)
+
+func crossoverSignal(t *testing.T, event *events.PriceEvent) *events.TradingSignal {
+	t.Helper()
+	signal := &events.TradingSignal{
+		SchemaVersion:  events.SchemaVersion,
+		Timestamp:      event.Timestamp,
+		Symbol:         event.Symbol,
+		SignalStrength: events.StrengthStrong,
+		Direction:      events.DirectionBullish,
+		ServiceID:      "ma-detector-v1",
+	}
+	if err := signal.SetDetails(&events.MACrossoverDetails{
+		MAType: "sma", FastPeriod: 20, SlowPeriod: 50, FastMA: 2, SlowMA: 1, CrossoverType: "golden_cross",
+	}); err != nil {
+		t.Fatalf("failed to set details: %v", err)
+	}
+	return signal
+}
+
+func TestOutbox_RetryRepublishesWithoutReapplying(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+	outbox := NewOutbox(producer)
+
+	applied := 0
+	handler := outbox.Wrap(func(event *events.PriceEvent) error {
+		applied++
+		return outbox.PublishSignal(context.Background(), events.TopicSignals, crossoverSignal(t, event))
+	})
+
+	event := &events.PriceEvent{Timestamp: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), Symbol: "BTC", PriceUSD: 1}
+
+	producer.Err = errors.New("broker down")
+	if err := handler(event); err == nil {
+		t.Fatal("expected publish error")
+	}
+	if err := handler(event); err == nil {
+		t.Fatal("expected publish error on the first retry")
+	}
+
+	producer.Err = nil
+	if err := handler(event); err != nil {
+		t.Fatalf("expected retry to publish, got %v", err)
+	}
+	if applied != 1 {
+		t.Errorf("expected the event to be applied once, got %d", applied)
+	}
+	if len(producer.Signals()) != 1 {
+		t.Errorf("expected the signal to be published once, got %d", len(producer.Signals()))
+	}
+
+	// Nothing is pending now, so the next event is applied as usual
+	if err := handler(&events.PriceEvent{Timestamp: event.Timestamp.Add(time.Minute), Symbol: "BTC", PriceUSD: 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if applied != 2 {
+		t.Errorf("expected the next event to be applied, got %d applications", applied)
+	}
+}
+
+func TestOutbox_DropsSignalsOfDeadLetteredEvent(t *testing.T) {
+	producer := &kafkatest.SignalProducer{Err: errors.New("broker down")}
+	outbox := NewOutbox(producer)
+
+	applied := 0
+	handler := outbox.Wrap(func(event *events.PriceEvent) error {
+		applied++
+		return nil
+	})
+
+	first := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	outbox.PublishSignal(context.Background(), events.TopicSignals, &events.TradingSignal{Symbol: "BTC", Timestamp: first})
+
+	if err := handler(&events.PriceEvent{Timestamp: first.Add(time.Minute), Symbol: "BTC"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if applied != 1 {
+		t.Errorf("expected a later event to be applied, got %d applications", applied)
+	}
+
+	producer.Err = nil
+	if err := handler(&events.PriceEvent{Timestamp: first, Symbol: "BTC"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if applied != 2 || len(producer.Signals()) != 0 {
+		t.Errorf("expected the stale signal to be dropped, got %d applications and %d signals", applied, len(producer.Signals()))
+	}
+}
+
+func TestOutbox_RetryAfterHandlerErrorDoesNotReapply(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+	outbox := NewOutbox(producer)
+
+	applied := 0
+	handler := outbox.Wrap(func(event *events.PriceEvent) error {
+		applied++
+		// Fails after the price is in the history, before any signal is
+		// published
+		return errors.New("invalid details")
+	})
+
+	event := &events.PriceEvent{Timestamp: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), Symbol: "BTC", PriceUSD: 1}
+	if err := handler(event); err == nil {
+		t.Fatal("expected handler error")
+	}
+	if err := handler(event); err != nil {
+		t.Fatalf("expected the retry to be skipped, got %v", err)
+	}
+	if applied != 1 {
+		t.Errorf("expected the event to be applied once, got %d", applied)
+	}
+
+	if err := handler(&events.PriceEvent{Timestamp: event.Timestamp.Add(time.Minute), Symbol: "BTC", PriceUSD: 2}); err == nil {
+		t.Fatal("expected the next event to reach the handler")
+	}
+	if applied != 2 {
+		t.Errorf("expected the next event to be applied, got %d applications", applied)
+	}
+}
+
+func TestOutbox_OlderEventKeepsLatestRecord(t *testing.T) {
+	producer := &kafkatest.SignalProducer{Err: errors.New("broker down")}
+	outbox := NewOutbox(producer)
+
+	var handled []time.Time
+	handler := outbox.Wrap(func(event *events.PriceEvent) error {
+		handled = append(handled, event.Timestamp)
+		return outbox.PublishSignal(context.Background(), events.TopicSignals, crossoverSignal(t, event))
+	})
+
+	newest := time.Date(2024, 1, 15, 10, 5, 0, 0, time.UTC)
+	if err := handler(&events.PriceEvent{Timestamp: newest, Symbol: "BTC"}); err == nil {
+		t.Fatal("expected publish error")
+	}
+
+	// A dead letter re-injected through the retry topic after newer events
+	producer.Err = nil
+	if err := handler(&events.PriceEvent{Timestamp: newest.Add(-5 * time.Minute), Symbol: "BTC"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The newest event's retry still only republishes its signal
+	if err := handler(&events.PriceEvent{Timestamp: newest, Symbol: "BTC"}); err != nil {
+		t.Fatalf("expected retry to publish, got %v", err)
+	}
+	if len(handled) != 2 || !handled[1].Before(newest) {
+		t.Errorf("expected the older event to reach the handler once and the retry to skip it, got %v", handled)
+	}
+	if len(producer.Signals()) != 2 {
+		t.Errorf("expected the older event's signal and the republished one, got %d", len(producer.Signals()))
+	}
+}
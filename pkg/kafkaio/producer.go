@@ -0,0 +1,119 @@
+package kafkaio
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/wire"
+
+	"github.com/IBM/sarama"
+)
+
+// SignalProducer publishes trading signals. kafkatest.SignalProducer records
+// them instead, for tests.
+type SignalProducer interface {
+	PublishSignal(ctx context.Context, topic string, signal *events.TradingSignal) error
+	Close() error
+}
+
+type Producer struct {
+	producer sarama.SyncProducer
+	codec    *wire.Codec
+}
+
+func NewProducer(brokers []string, codec *wire.Codec) (*Producer, error) {
+	config := sarama.NewConfig()
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Retry.Max = 5
+	config.Producer.Return.Successes = true
+
+	producer, err := sarama.NewSyncProducer(brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka producer: %w", err)
+	}
+
+	return &Producer{producer: producer, codec: codec}, nil
+}
+
+// PublishSignal validates signal and publishes it keyed by symbol, so each
+// symbol's signals stay in order on one partition.
+func (p *Producer) PublishSignal(ctx context.Context, topic string, signal *events.TradingSignal) error {
+	if err := signal.Validate(); err != nil {
+		return fmt.Errorf("refusing to publish invalid signal: %w", err)
+	}
+	return p.Publish(ctx, topic, signal.Symbol, signal)
+}
+
+// Publish encodes value in the producer's wire format and sends it to topic
+// under key.
+func (p *Producer) Publish(ctx context.Context, topic, key string, value interface{}) error {
+	data, err := p.codec.Marshal(topic, value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %T: %w", value, err)
+	}
+
+	message := &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(key),
+		Value: sarama.ByteEncoder(data),
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	partition, offset, err := p.producer.SendMessage(message)
+	if err != nil {
+		return fmt.Errorf("failed to send message to kafka: %w", err)
+	}
+
+	log.Printf("Published %s to topic %s, partition %d, offset %d", key, topic, partition, offset)
+	return nil
+}
+
+// Forward sends message's key, value and headers unchanged to topic, with
+// headers appended.
+func (p *Producer) Forward(ctx context.Context, topic string, message *sarama.ConsumerMessage, headers ...sarama.RecordHeader) error {
+	var all []sarama.RecordHeader
+	for _, header := range message.Headers {
+		all = append(all, *header)
+	}
+	return p.send(ctx, topic, message, append(all, headers...))
+}
+
+// Reinject sends a dead-lettered message back to topic without its failure
+// headers, keeping its key, value and other headers.
+func (p *Producer) Reinject(ctx context.Context, topic string, deadLetter *DeadLetter) error {
+	return p.send(ctx, topic, deadLetter.Message, withoutDeadLetterHeaders(deadLetter.Message.Headers))
+}
+
+func (p *Producer) send(ctx context.Context, topic string, message *sarama.ConsumerMessage, headers []sarama.RecordHeader) error {
+	forwarded := &sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.ByteEncoder(message.Key),
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if _, _, err := p.producer.SendMessage(forwarded); err != nil {
+		return fmt.Errorf("failed to forward message to %s: %w", topic, err)
+	}
+	return nil
+}
+
+func (p *Producer) Close() error {
+	if p.producer != nil {
+		return p.producer.Close()
+	}
+	return nil
+}
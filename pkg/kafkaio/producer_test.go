@@ -0,0 +1,164 @@
+package kafkaio
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/wire"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+)
+
+func TestProducer_PublishSignal(t *testing.T) {
+	signal := &events.TradingSignal{
+		SchemaVersion:  events.SchemaVersion,
+		Timestamp:      time.Now(),
+		Symbol:         "BTC",
+		SignalStrength: events.StrengthStrong,
+		Direction:      events.DirectionBullish,
+		ServiceID:      "volume-spike-detector",
+	}
+	if err := signal.SetDetails(&events.VolumeSpikeDetails{
+		CurrentVolume:     3e9,
+		AvgVolume7d:       1e9,
+		SpikeMultiplier:   3,
+		ThresholdExceeded: 2,
+		WindowHours:       168,
+		SpikeMode:         "ratio",
+		SpikeScore:        3,
+		BaselineVolume:    1e9,
+	}); err != nil {
+		t.Fatalf("failed to set details: %v", err)
+	}
+
+	t.Run("keyed by symbol", func(t *testing.T) {
+		mock := mocks.NewSyncProducer(t, nil)
+		mock.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+			if message.Topic != events.TopicSignals {
+				t.Errorf("expected topic %s, got %s", events.TopicSignals, message.Topic)
+			}
+			if key, _ := message.Key.Encode(); string(key) != "BTC" {
+				t.Errorf("expected key BTC, got %s", key)
+			}
+			return nil
+		})
+		producer := &Producer{producer: mock, codec: wire.JSON()}
+		defer producer.Close()
+
+		if err := producer.PublishSignal(context.Background(), events.TopicSignals, signal); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("avro", func(t *testing.T) {
+		codec, err := wire.NewCodec(wire.FormatAvro, wire.NewLocalRegistry())
+		if err != nil {
+			t.Fatalf("failed to create codec: %v", err)
+		}
+
+		mock := mocks.NewSyncProducer(t, nil)
+		mock.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+			data, _ := message.Value.Encode()
+			if id, _, err := wire.Unframe(data); err != nil || id != wire.TradingSignalSchemaID {
+				t.Errorf("expected Avro framed with schema %d, got %d (%v)", wire.TradingSignalSchemaID, id, err)
+			}
+			return nil
+		})
+		producer := &Producer{producer: mock, codec: codec}
+		defer producer.Close()
+
+		if err := producer.PublishSignal(context.Background(), events.TopicSignals, signal); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("invalid signal", func(t *testing.T) {
+		producer := &Producer{producer: mocks.NewSyncProducer(t, nil), codec: wire.JSON()}
+		defer producer.Close()
+
+		invalid := *signal
+		invalid.Direction = "sideways"
+		if err := producer.PublishSignal(context.Background(), events.TopicSignals, &invalid); err == nil {
+			t.Error("expected invalid signal to be rejected")
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		producer := &Producer{producer: mocks.NewSyncProducer(t, nil), codec: wire.JSON()}
+		defer producer.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := producer.PublishSignal(ctx, events.TopicSignals, signal); err == nil {
+			t.Error("expected context error")
+		}
+	})
+}
+
+func TestProducer_Forward(t *testing.T) {
+	message := &sarama.ConsumerMessage{
+		Topic:   events.TopicPrices,
+		Key:     []byte("BTC"),
+		Value:   []byte(`{"symbol":"BTC"}`),
+		Headers: []*sarama.RecordHeader{{Key: []byte("trace"), Value: []byte("abc")}},
+	}
+
+	mock := mocks.NewSyncProducer(t, nil)
+	mock.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(forwarded *sarama.ProducerMessage) error {
+		if forwarded.Topic != "prices.dlq" {
+			t.Errorf("expected topic prices.dlq, got %s", forwarded.Topic)
+		}
+		key, _ := forwarded.Key.Encode()
+		value, _ := forwarded.Value.Encode()
+		if string(key) != "BTC" || string(value) != `{"symbol":"BTC"}` {
+			t.Errorf("expected key and value unchanged, got %s %s", key, value)
+		}
+		if len(forwarded.Headers) != 2 || string(forwarded.Headers[0].Key) != "trace" || string(forwarded.Headers[1].Key) != HeaderError {
+			t.Errorf("expected original headers followed by extras, got %v", forwarded.Headers)
+		}
+		return nil
+	})
+	producer := &Producer{producer: mock, codec: wire.JSON()}
+	defer producer.Close()
+
+	header := sarama.RecordHeader{Key: []byte(HeaderError), Value: []byte("boom")}
+	if err := producer.Forward(context.Background(), "prices.dlq", message, header); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestProducer_Reinject(t *testing.T) {
+	deadLetter := &DeadLetter{
+		OriginalTopic: events.TopicPrices,
+		Message: &sarama.ConsumerMessage{
+			Topic: PoisonTopic(events.TopicPrices),
+			Key:   []byte("BTC"),
+			Value: []byte(`{"symbol":"BTC"}`),
+			Headers: []*sarama.RecordHeader{
+				{Key: []byte("trace"), Value: []byte("abc")},
+				{Key: []byte(HeaderReason), Value: []byte(ReasonUndecodable)},
+				{Key: []byte(HeaderError), Value: []byte("boom")},
+			},
+		},
+	}
+
+	mock := mocks.NewSyncProducer(t, nil)
+	mock.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+		if message.Topic != events.TopicPrices {
+			t.Errorf("expected topic %s, got %s", events.TopicPrices, message.Topic)
+		}
+		if len(message.Headers) != 1 || string(message.Headers[0].Key) != "trace" {
+			t.Errorf("expected only the original headers, got %v", message.Headers)
+		}
+		return nil
+	})
+	producer := &Producer{producer: mock, codec: wire.JSON()}
+	defer producer.Close()
+
+	if err := producer.Reinject(context.Background(), deadLetter.OriginalTopic, deadLetter); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
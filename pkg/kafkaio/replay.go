@@ -0,0 +1,150 @@
+package kafkaio
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"crypto-trackers/pkg/wire"
+
+	"github.com/IBM/sarama"
+)
+
+// replayIdleTimeout ends a partition's replay when no message arrives for this
+// long. Offsets below the high-water mark can be missing, for example on
+// compacted topics or where transaction markers sit, so the last offset
+// before it may never be delivered.
+var replayIdleTimeout = 10 * time.Second
+
+type ReplayResult struct {
+	Events     int
+	EndOffsets map[string]map[int32]int64
+}
+
+// Replay reads every message on topic published since the given time up to the
+// current high-water mark and hands it to eventHandler. Partitions are read one
+// after another; messages are keyed by symbol so per-symbol order holds.
+// Messages that fail to decode, validate or handle are logged and not counted.
+// A partition that delivers nothing for 10 seconds is treated as fully read.
+func Replay[T any](ctx context.Context, brokers []string, topic string, since time.Time, codec *wire.Codec, eventHandler func(*T) error) (*ReplayResult, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Return.Errors = true
+
+	client, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+	defer client.Close()
+
+	return ReplayFromClient(ctx, client, topic, since, codec, eventHandler)
+}
+
+// ReplayFromClient is Replay over an existing client, such as one connected
+// to a sarama.MockBroker in tests.
+func ReplayFromClient[T any](ctx context.Context, client sarama.Client, topic string, since time.Time, codec *wire.Codec, eventHandler func(*T) error) (*ReplayResult, error) {
+	return ReplayMessagesFromClient(ctx, client, topic, since, func(message *sarama.ConsumerMessage) error {
+		event, err := decode[T](codec, message.Value)
+		if err != nil {
+			return err
+		}
+		return eventHandler(event)
+	})
+}
+
+// ReplayMessages is Replay without decoding, for tools that read raw
+// messages and their headers, such as dead letters.
+func ReplayMessages(ctx context.Context, brokers []string, topic string, since time.Time, messageHandler func(*sarama.ConsumerMessage) error) (*ReplayResult, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Return.Errors = true
+
+	client, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+	defer client.Close()
+
+	return ReplayMessagesFromClient(ctx, client, topic, since, messageHandler)
+}
+
+// ReplayMessagesFromClient is ReplayMessages over an existing client.
+func ReplayMessagesFromClient(ctx context.Context, client sarama.Client, topic string, since time.Time, messageHandler func(*sarama.ConsumerMessage) error) (*ReplayResult, error) {
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions for %s: %w", topic, err)
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	result := &ReplayResult{
+		EndOffsets: map[string]map[int32]int64{topic: {}},
+	}
+
+	for _, partition := range partitions {
+		end, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get newest offset for %s/%d: %w", topic, partition, err)
+		}
+		result.EndOffsets[topic][partition] = end
+
+		start, err := client.GetOffset(topic, partition, since.UnixMilli())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get offset for time on %s/%d: %w", topic, partition, err)
+		}
+		if start < 0 || start >= end {
+			continue
+		}
+
+		count, err := replayPartition(ctx, consumer, topic, partition, start, end, messageHandler)
+		result.Events += count
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func replayPartition(ctx context.Context, consumer sarama.Consumer, topic string, partition int32, start, end int64, messageHandler func(*sarama.ConsumerMessage) error) (int, error) {
+	partitionConsumer, err := consumer.ConsumePartition(topic, partition, start)
+	if err != nil {
+		return 0, fmt.Errorf("failed to consume %s/%d: %w", topic, partition, err)
+	}
+	defer partitionConsumer.Close()
+
+	idle := time.NewTimer(replayIdleTimeout)
+	defer idle.Stop()
+
+	count := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return count, ctx.Err()
+		case <-idle.C:
+			log.Printf("Replay of %s/%d stopped after %s without messages, before offset %d", topic, partition, replayIdleTimeout, end)
+			return count, nil
+		case consumerErr := <-partitionConsumer.Errors():
+			return count, fmt.Errorf("error replaying %s/%d: %w", topic, partition, consumerErr)
+		case message := <-partitionConsumer.Messages():
+			if !idle.Stop() {
+				<-idle.C
+			}
+
+			if err := messageHandler(message); err != nil {
+				log.Printf("Error handling replayed message at %s/%d offset %d: %v", topic, partition, message.Offset, err)
+			} else {
+				count++
+			}
+
+			// The next offset to read is at or past the end
+			if message.Offset+1 >= end {
+				return count, nil
+			}
+			idle.Reset(replayIdleTimeout)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package kafkaio
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/wire"
+
+	"github.com/IBM/sarama"
+)
+
+func TestReplay_ReadsFromTimestampToHighWaterMark(t *testing.T) {
+	since := time.Date(2024, 6, 16, 12, 0, 0, 0, time.UTC)
+
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	fetch := sarama.NewMockFetchResponse(t, 1)
+	for offset := int64(0); offset < 5; offset++ {
+		data, err := json.Marshal(&events.PriceEvent{
+			Timestamp: since.Add(time.Duration(offset) * time.Minute),
+			Symbol:    "BTC",
+			PriceUSD:  float64(50000 + offset),
+		})
+		if err != nil {
+			t.Fatalf("failed to marshal event: %v", err)
+		}
+		fetch.SetMessage("crypto-prices", 0, offset, sarama.ByteEncoder(data))
+	}
+	fetch.SetHighWaterMark("crypto-prices", 0, 5)
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("crypto-prices", 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("crypto-prices", 0, sarama.OffsetOldest, 0).
+			SetOffset("crypto-prices", 0, sarama.OffsetNewest, 5).
+			SetOffset("crypto-prices", 0, since.UnixMilli(), 2),
+		"FetchRequest": fetch,
+	})
+
+	client, err := sarama.NewClient([]string{broker.Addr()}, sarama.NewConfig())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	var replayed []*events.PriceEvent
+	handler := func(event *events.PriceEvent) error {
+		replayed = append(replayed, event)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := ReplayFromClient(ctx, client, "crypto-prices", since, wire.JSON(), handler)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if result.Events != 3 {
+		t.Errorf("expected 3 replayed events, got %d", result.Events)
+	}
+
+	if len(replayed) != 3 || replayed[0].PriceUSD != 50002 {
+		t.Errorf("expected replay to start at offset 2, got %d events", len(replayed))
+	}
+
+	if result.EndOffsets["crypto-prices"][0] != 5 {
+		t.Errorf("expected end offset 5, got %d", result.EndOffsets["crypto-prices"][0])
+	}
+}
+
+func TestReplay_StopsWhenTailOffsetsAreMissing(t *testing.T) {
+	defer func(timeout time.Duration) { replayIdleTimeout = timeout }(replayIdleTimeout)
+	replayIdleTimeout = 200 * time.Millisecond
+
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	// Offsets 3 and 4 were compacted away, or hold transaction markers, so
+	// the last message before the high-water mark is at offset 2
+	fetch := sarama.NewMockFetchResponse(t, 1)
+	for offset := int64(0); offset < 3; offset++ {
+		fetch.SetMessage("crypto-prices.dlq", 0, offset, sarama.StringEncoder("letter"))
+	}
+	fetch.SetHighWaterMark("crypto-prices.dlq", 0, 5)
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("crypto-prices.dlq", 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("crypto-prices.dlq", 0, sarama.OffsetOldest, 0).
+			SetOffset("crypto-prices.dlq", 0, sarama.OffsetNewest, 5).
+			SetOffset("crypto-prices.dlq", 0, 0, 0),
+		"FetchRequest": fetch,
+	})
+
+	client, err := sarama.NewClient([]string{broker.Addr()}, sarama.NewConfig())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := ReplayMessagesFromClient(ctx, client, "crypto-prices.dlq", time.UnixMilli(0), func(*sarama.ConsumerMessage) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected replay to stop on its own, got %v", err)
+	}
+	if result.Events != 3 {
+		t.Errorf("expected 3 replayed messages, got %d", result.Events)
+	}
+}
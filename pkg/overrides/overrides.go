@@ -0,0 +1,46 @@
+package overrides
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a per-symbol overrides document, read from SYMBOL_OVERRIDES_PATH.
+// T is the service's override struct, whose pointer fields stay nil when
+// unset so they fall back to the service-wide configuration.
+type File[T any] struct {
+	Symbols map[string]T `yaml:"symbols" json:"symbols"`
+}
+
+// Load reads a YAML or JSON overrides file keyed by upper-case symbol. An
+// empty path means no overrides.
+func Load[T any](path string) (map[string]T, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbol overrides: %w", err)
+	}
+
+	return Parse[T](data)
+}
+
+// Parse accepts YAML and, since JSON is valid YAML, JSON documents.
+func Parse[T any](data []byte) (map[string]T, error) {
+	var file File[T]
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse symbol overrides: %w", err)
+	}
+
+	symbols := make(map[string]T, len(file.Symbols))
+	for symbol, override := range file.Symbols {
+		symbols[strings.ToUpper(symbol)] = override
+	}
+
+	return symbols, nil
+}
@@ -0,0 +1,81 @@
+package overrides
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testOverride struct {
+	CooldownMinutes *int     `yaml:"cooldown_minutes" json:"cooldown_minutes,omitempty"`
+	SpikeThreshold  *float64 `yaml:"spike_threshold" json:"spike_threshold,omitempty"`
+}
+
+func TestParse(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		symbols, err := Parse[testOverride]([]byte(`
+symbols:
+  btc:
+    cooldown_minutes: 15
+  PEPE:
+    spike_threshold: 3
+`))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if btc := symbols["BTC"]; btc.CooldownMinutes == nil || *btc.CooldownMinutes != 15 || btc.SpikeThreshold != nil {
+			t.Errorf("expected only BTC cooldown 15, got %+v", btc)
+		}
+		if pepe := symbols["PEPE"]; pepe.CooldownMinutes != nil || pepe.SpikeThreshold == nil || *pepe.SpikeThreshold != 3 {
+			t.Errorf("expected only PEPE threshold 3, got %+v", pepe)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		symbols, err := Parse[testOverride]([]byte(`{"symbols": {"ETH": {"cooldown_minutes": 0}}}`))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if eth := symbols["ETH"]; eth.CooldownMinutes == nil || *eth.CooldownMinutes != 0 {
+			t.Errorf("expected explicit ETH cooldown 0, got %+v", eth)
+		}
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		if _, err := Parse[testOverride]([]byte("symbols: [")); err == nil {
+			t.Error("expected parse error")
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("empty path means no overrides", func(t *testing.T) {
+		symbols, err := Load[testOverride]("")
+		if err != nil || symbols != nil {
+			t.Errorf("expected nil overrides, got %v, %v", symbols, err)
+		}
+	})
+
+	t.Run("reads file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "symbols.yaml")
+		if err := os.WriteFile(path, []byte("symbols:\n  SOL:\n    cooldown_minutes: 2\n"), 0o644); err != nil {
+			t.Fatalf("failed to write overrides: %v", err)
+		}
+
+		symbols, err := Load[testOverride](path)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if sol := symbols["SOL"]; sol.CooldownMinutes == nil || *sol.CooldownMinutes != 2 {
+			t.Errorf("expected SOL cooldown 2, got %+v", sol)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := Load[testOverride](filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+			t.Error("expected error for missing file")
+		}
+	})
+}
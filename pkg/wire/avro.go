@@ -0,0 +1,255 @@
+package wire
+
+import (
+	"fmt"
+	"time"
+
+	"crypto-trackers/pkg/events"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// PriceEventSchema and TradingSignalSchema are the Avro schemas for the
+// events types. Add fields with defaults so older readers and writers stay
+// compatible.
+const (
+	PriceEventSchema = `{
+  "type": "record",
+  "name": "PriceEvent",
+  "namespace": "cryptotrackers.events",
+  "fields": [
+    {"name": "timestamp", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+    {"name": "symbol", "type": "string"},
+    {"name": "price_usd", "type": "double"},
+    {"name": "volume_24h", "type": "double"},
+    {"name": "market_cap", "type": "double"},
+    {"name": "price_change_24h", "type": "double"},
+    {"name": "source", "type": "string"}
+  ]
+}`
+
+	TradingSignalSchema = `{
+  "type": "record",
+  "name": "TradingSignal",
+  "namespace": "cryptotrackers.events",
+  "fields": [
+    {"name": "schema_version", "type": "int"},
+    {"name": "timestamp", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+    {"name": "symbol", "type": "string"},
+    {"name": "signal_type", "type": "string"},
+    {"name": "signal_strength", "type": "string"},
+    {"name": "direction", "type": "string"},
+    {"name": "details", "type": {"type": "map", "values": [
+      "null", "boolean", "double", "string",
+      {"type": "array", "items": [
+        "null", "boolean", "double", "string",
+        {"type": "map", "values": ["null", "boolean", "double", "string"]}
+      ]},
+      {"type": "map", "values": ["null", "boolean", "double", "string"]}
+    ]}},
+    {"name": "service_id", "type": "string"}
+  ]
+}`
+)
+
+// toAvro returns the schema and native Avro record for value, or ok false
+// for types without an Avro schema.
+func toAvro(value interface{}) (schema string, native map[string]interface{}, ok bool, err error) {
+	switch v := value.(type) {
+	case *events.PriceEvent:
+		return PriceEventSchema, priceEventToAvro(v), true, nil
+	case events.PriceEvent:
+		return PriceEventSchema, priceEventToAvro(&v), true, nil
+	case *events.TradingSignal:
+		native, err := tradingSignalToAvro(v)
+		return TradingSignalSchema, native, true, err
+	case events.TradingSignal:
+		native, err := tradingSignalToAvro(&v)
+		return TradingSignalSchema, native, true, err
+	default:
+		return "", nil, false, nil
+	}
+}
+
+// fromAvro fills value from a native Avro record. Fields the writer's schema
+// lacks are left at their zero value.
+func fromAvro(native interface{}, value interface{}) error {
+	record, ok := native.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected an Avro record, got %T", native)
+	}
+
+	switch v := value.(type) {
+	case *events.PriceEvent:
+		v.Timestamp = timeField(record, "timestamp")
+		v.Symbol, _ = record["symbol"].(string)
+		v.PriceUSD, _ = record["price_usd"].(float64)
+		v.Volume24h, _ = record["volume_24h"].(float64)
+		v.MarketCap, _ = record["market_cap"].(float64)
+		v.PriceChange24h, _ = record["price_change_24h"].(float64)
+		v.Source, _ = record["source"].(string)
+		return nil
+	case *events.TradingSignal:
+		version, _ := record["schema_version"].(int32)
+		v.SchemaVersion = int(version)
+		v.Timestamp = timeField(record, "timestamp")
+		v.Symbol, _ = record["symbol"].(string)
+		v.SignalType, _ = record["signal_type"].(string)
+		v.SignalStrength, _ = record["signal_strength"].(string)
+		v.Direction, _ = record["direction"].(string)
+		v.ServiceID, _ = record["service_id"].(string)
+
+		details, _ := record["details"].(map[string]interface{})
+		v.Details = make(map[string]interface{}, len(details))
+		for key, union := range details {
+			v.Details[key] = unionValue(union)
+		}
+		v.UpgradeLegacy()
+		return nil
+	default:
+		return fmt.Errorf("no Avro schema for %T", value)
+	}
+}
+
+func priceEventToAvro(event *events.PriceEvent) map[string]interface{} {
+	return map[string]interface{}{
+		"timestamp":        event.Timestamp,
+		"symbol":           event.Symbol,
+		"price_usd":        event.PriceUSD,
+		"volume_24h":       event.Volume24h,
+		"market_cap":       event.MarketCap,
+		"price_change_24h": event.PriceChange24h,
+		"source":           event.Source,
+	}
+}
+
+func tradingSignalToAvro(signal *events.TradingSignal) (map[string]interface{}, error) {
+	details := make(map[string]interface{}, len(signal.Details))
+	for key, value := range signal.Details {
+		union, err := detailUnion(value)
+		if err != nil {
+			return nil, fmt.Errorf("detail %s: %w", key, err)
+		}
+		details[key] = union
+	}
+
+	return map[string]interface{}{
+		"schema_version":  int32(signal.SchemaVersion),
+		"timestamp":       signal.Timestamp,
+		"symbol":          signal.Symbol,
+		"signal_type":     signal.SignalType,
+		"signal_strength": signal.SignalStrength,
+		"direction":       signal.Direction,
+		"details":         details,
+		"service_id":      signal.ServiceID,
+	}, nil
+}
+
+// detailUnion wraps a detail value in the details map's union type. Numbers
+// become doubles, as they do when JSON is decoded into the map. Lists may
+// hold scalars and flat objects, and objects only scalars, which is enough
+// for the signals listed in confluence details.
+func detailUnion(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			union, err := itemUnion(item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			items[i] = union
+		}
+		return goavro.Union("array", items), nil
+	case map[string]interface{}:
+		fields, err := objectFields(v)
+		if err != nil {
+			return nil, err
+		}
+		return goavro.Union("map", fields), nil
+	default:
+		return scalarUnion(value)
+	}
+}
+
+func itemUnion(value interface{}) (interface{}, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return scalarUnion(value)
+	}
+	fields, err := objectFields(object)
+	if err != nil {
+		return nil, err
+	}
+	return goavro.Union("map", fields), nil
+}
+
+func objectFields(object map[string]interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		union, err := scalarUnion(value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", key, err)
+		}
+		fields[key] = union
+	}
+	return fields, nil
+}
+
+func scalarUnion(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		return goavro.Union("boolean", v), nil
+	case string:
+		return goavro.Union("string", v), nil
+	case float64:
+		return goavro.Union("double", v), nil
+	case float32:
+		return goavro.Union("double", float64(v)), nil
+	case int:
+		return goavro.Union("double", float64(v)), nil
+	case int32:
+		return goavro.Union("double", float64(v)), nil
+	case int64:
+		return goavro.Union("double", float64(v)), nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+// unionValue unwraps a decoded detail value, and the values in its lists and
+// objects.
+func unionValue(union interface{}) interface{} {
+	wrapped, ok := union.(map[string]interface{})
+	if !ok {
+		return union
+	}
+	for branch, value := range wrapped {
+		switch branch {
+		case "array":
+			items, _ := value.([]interface{})
+			unwrapped := make([]interface{}, len(items))
+			for i, item := range items {
+				unwrapped[i] = unionValue(item)
+			}
+			return unwrapped
+		case "map":
+			fields, _ := value.(map[string]interface{})
+			unwrapped := make(map[string]interface{}, len(fields))
+			for key, field := range fields {
+				unwrapped[key] = unionValue(field)
+			}
+			return unwrapped
+		default:
+			return value
+		}
+	}
+	return nil
+}
+
+func timeField(record map[string]interface{}, name string) time.Time {
+	t, _ := record[name].(time.Time)
+	return t
+}
@@ -0,0 +1,241 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+var testTime = time.Date(2024, 1, 15, 10, 0, 0, 123456000, time.UTC)
+
+func testSignal() *events.TradingSignal {
+	return &events.TradingSignal{
+		SchemaVersion:  events.SchemaVersion,
+		Timestamp:      testTime,
+		Symbol:         "BTC",
+		SignalType:     events.SignalTypeMACrossover,
+		SignalStrength: events.StrengthStrong,
+		Direction:      events.DirectionBullish,
+		Details: map[string]interface{}{
+			"ma_type":        "sma",
+			"fast_period":    20.0,
+			"slow_period":    50.0,
+			"fast_ma":        45123.5,
+			"slow_ma":        44987.2,
+			"crossover_type": "golden_cross",
+		},
+		ServiceID: "ma-detector-v1",
+	}
+}
+
+func newCodec(t *testing.T, format string) *Codec {
+	t.Helper()
+	codec, err := NewCodec(format, NewLocalRegistry())
+	if err != nil {
+		t.Fatalf("failed to create codec: %v", err)
+	}
+	return codec
+}
+
+func TestNewCodec(t *testing.T) {
+	if _, err := NewCodec("protobuf", NewLocalRegistry()); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestFrame(t *testing.T) {
+	framed := Frame(258, []byte{0xAA})
+	if !bytes.Equal(framed, []byte{0, 0, 0, 1, 2, 0xAA}) {
+		t.Fatalf("unexpected framing %v", framed)
+	}
+
+	id, payload, err := Unframe(framed)
+	if err != nil || id != 258 || !bytes.Equal(payload, []byte{0xAA}) {
+		t.Errorf("expected schema 258 and payload AA, got %d %v %v", id, payload, err)
+	}
+	if _, _, err := Unframe([]byte(`{"symbol":"BTC"}`)); err == nil {
+		t.Error("expected error unframing JSON")
+	}
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	price := &events.PriceEvent{
+		Timestamp:      testTime,
+		Symbol:         "ETH",
+		PriceUSD:       3012.5,
+		Volume24h:      1.5e10,
+		MarketCap:      3.6e11,
+		PriceChange24h: -2.1,
+		Source:         "coingecko",
+	}
+
+	for _, format := range []string{FormatJSON, FormatAvro} {
+		t.Run(format, func(t *testing.T) {
+			writer := newCodec(t, format)
+			// A reader in the other format still decodes both
+			reader := newCodec(t, FormatJSON)
+
+			data, err := writer.Marshal(events.TopicPrices, price)
+			if err != nil {
+				t.Fatalf("failed to marshal price event: %v", err)
+			}
+			if IsFramed(data) != (format == FormatAvro) {
+				t.Errorf("expected framed=%v for %s", format == FormatAvro, format)
+			}
+			var decodedPrice events.PriceEvent
+			if err := reader.Unmarshal(data, &decodedPrice); err != nil {
+				t.Fatalf("failed to unmarshal price event: %v", err)
+			}
+			if !reflect.DeepEqual(&decodedPrice, price) {
+				t.Errorf("expected %+v, got %+v", price, decodedPrice)
+			}
+
+			signal := testSignal()
+			data, err = writer.Marshal(events.TopicSignals, signal)
+			if err != nil {
+				t.Fatalf("failed to marshal signal: %v", err)
+			}
+			var decodedSignal events.TradingSignal
+			if err := reader.Unmarshal(data, &decodedSignal); err != nil {
+				t.Fatalf("failed to unmarshal signal: %v", err)
+			}
+			if !reflect.DeepEqual(&decodedSignal, signal) {
+				t.Errorf("expected %+v, got %+v", signal, decodedSignal)
+			}
+			if err := decodedSignal.Validate(); err != nil {
+				t.Errorf("expected decoded signal to validate, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCodec_AvroDetailValues(t *testing.T) {
+	codec := newCodec(t, FormatAvro)
+	signal := testSignal()
+	signal.Details = map[string]interface{}{"count": 3, "flag": true, "missing": nil}
+
+	data, err := codec.Marshal(events.TopicSignals, signal)
+	if err != nil {
+		t.Fatalf("failed to marshal signal: %v", err)
+	}
+	var decoded events.TradingSignal
+	if err := codec.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal signal: %v", err)
+	}
+
+	expected := map[string]interface{}{"count": 3.0, "flag": true, "missing": nil}
+	if !reflect.DeepEqual(decoded.Details, expected) {
+		t.Errorf("expected %v, got %v", expected, decoded.Details)
+	}
+
+	signal.Details = map[string]interface{}{"nested": []interface{}{[]interface{}{1.0}}}
+	if _, err := codec.Marshal(events.TopicSignals, signal); err == nil {
+		t.Error("expected error for a detail the Avro schema cannot hold")
+	}
+}
+
+func TestCodec_AvroConfluenceDetails(t *testing.T) {
+	codec := newCodec(t, FormatAvro)
+	signal := testSignal()
+	details := &events.ConfluenceDetails{
+		CombinedScore: 5,
+		DetectorCount: 2,
+		Detectors:     []string{"ma-detector-v1", "volume-detector-v1"},
+		Signals: []events.ConfluenceSignal{
+			{ServiceID: "ma-detector-v1", SignalType: events.SignalTypeMACrossover, Direction: events.DirectionBullish, SignalStrength: events.StrengthStrong, Timestamp: testTime},
+			{ServiceID: "volume-detector-v1", SignalType: events.SignalTypeVolumeSpike, Direction: events.DirectionBullish, SignalStrength: events.StrengthMedium, Timestamp: testTime},
+		},
+		WindowMinutes: 15,
+	}
+	if err := signal.SetDetails(details); err != nil {
+		t.Fatalf("failed to set details: %v", err)
+	}
+
+	data, err := codec.Marshal(events.TopicSignals, signal)
+	if err != nil {
+		t.Fatalf("failed to marshal signal: %v", err)
+	}
+	var decoded events.TradingSignal
+	if err := codec.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal signal: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, signal) {
+		t.Errorf("expected %+v, got %+v", signal, decoded)
+	}
+
+	decodedDetails, err := decoded.DecodeDetails()
+	if err != nil {
+		t.Fatalf("failed to decode details: %v", err)
+	}
+	if !reflect.DeepEqual(decodedDetails, details) {
+		t.Errorf("expected %+v, got %+v", details, decodedDetails)
+	}
+}
+
+func TestCodec_AvroUpgradesLegacySignals(t *testing.T) {
+	codec := newCodec(t, FormatAvro)
+	signal := testSignal()
+	signal.SchemaVersion = 0
+	signal.Details = map[string]interface{}{"sma_20": 45123.5, "sma_50": 44987.2, "crossover_type": "golden_cross"}
+
+	data, err := codec.Marshal(events.TopicSignals, signal)
+	if err != nil {
+		t.Fatalf("failed to marshal signal: %v", err)
+	}
+	var decoded events.TradingSignal
+	if err := codec.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal signal: %v", err)
+	}
+
+	if decoded.SchemaVersion != 1 || decoded.Details["fast_ma"] != 45123.5 {
+		t.Errorf("expected the legacy signal upgraded to version 1, got version %d with %v", decoded.SchemaVersion, decoded.Details)
+	}
+	if err := decoded.Validate(); err != nil {
+		t.Errorf("expected upgraded signal to validate, got %v", err)
+	}
+}
+
+func TestCodec_AvroFallsBackToJSON(t *testing.T) {
+	codec := newCodec(t, FormatAvro)
+	value := map[string]string{"reason": "timeout"}
+
+	data, err := codec.Marshal("alert-dead-letters", value)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if IsFramed(data) {
+		t.Fatal("expected a type without an Avro schema to be written as JSON")
+	}
+	var decoded map[string]string
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded["reason"] != "timeout" {
+		t.Errorf("expected JSON round trip, got %v (%v)", decoded, err)
+	}
+}
+
+func TestCodec_UnknownSchemaID(t *testing.T) {
+	codec := newCodec(t, FormatJSON)
+	var signal events.TradingSignal
+	if err := codec.Unmarshal(Frame(99, []byte{0}), &signal); err == nil {
+		t.Error("expected error for a schema ID the registry does not know")
+	}
+}
+
+func TestCodec_UsesRegisteredID(t *testing.T) {
+	registry := NewLocalRegistry()
+	codec, err := NewCodec(FormatAvro, registry)
+	if err != nil {
+		t.Fatalf("failed to create codec: %v", err)
+	}
+
+	data, err := codec.Marshal(events.TopicSignals, testSignal())
+	if err != nil {
+		t.Fatalf("failed to marshal signal: %v", err)
+	}
+	if id, _, _ := Unframe(data); id != TradingSignalSchemaID {
+		t.Errorf("expected built-in schema ID %d, got %d", TradingSignalSchemaID, id)
+	}
+}
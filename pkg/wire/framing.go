@@ -0,0 +1,38 @@
+// Package wire encodes Kafka message values as JSON or as Avro in the
+// Confluent wire format, and decodes either. A Confluent-framed value is a
+// zero magic byte, a 4-byte big-endian schema ID and the Avro payload, so
+// the schema can be looked up in a schema registry.
+package wire
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	magicByte  = 0
+	headerSize = 5
+)
+
+// Frame prefixes payload with the Confluent wire-format header for schemaID.
+func Frame(schemaID int32, payload []byte) []byte {
+	framed := make([]byte, headerSize+len(payload))
+	framed[0] = magicByte
+	binary.BigEndian.PutUint32(framed[1:headerSize], uint32(schemaID))
+	copy(framed[headerSize:], payload)
+	return framed
+}
+
+// Unframe splits a Confluent-framed value into its schema ID and payload.
+func Unframe(data []byte) (int32, []byte, error) {
+	if !IsFramed(data) {
+		return 0, nil, fmt.Errorf("value is not in the Confluent wire format")
+	}
+	return int32(binary.BigEndian.Uint32(data[1:headerSize])), data[headerSize:], nil
+}
+
+// IsFramed reports whether data starts with the Confluent magic byte. JSON
+// values never do, since they start with '{' or whitespace.
+func IsFramed(data []byte) bool {
+	return len(data) >= headerSize && data[0] == magicByte
+}
@@ -0,0 +1,164 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRegistryUnavailable is wrapped by errors from a registry that could not
+// be reached or failed on its side, so callers can retry rather than treat
+// the message as unreadable.
+var ErrRegistryUnavailable = errors.New("schema registry unavailable")
+
+// Registry resolves Avro schemas to IDs and back, like the Confluent
+// schema registry. Subjects follow its topic naming: "<topic>-value".
+type Registry interface {
+	Register(subject, schema string) (int32, error)
+	Schema(id int32) (string, error)
+}
+
+// NewRegistry returns an HTTPRegistry for url, or a LocalRegistry when url is
+// empty.
+func NewRegistry(url string) Registry {
+	if url == "" {
+		return NewLocalRegistry()
+	}
+	return NewHTTPRegistry(url)
+}
+
+// Fixed IDs of the built-in schemas in a LocalRegistry. Every service
+// compiles in the same schemas, so they agree on these IDs without a shared
+// registry.
+const (
+	PriceEventSchemaID    int32 = 1
+	TradingSignalSchemaID int32 = 2
+)
+
+// LocalRegistry is an in-process stand-in for a schema registry. It starts
+// with the built-in schemas at their fixed IDs; schemas registered later get
+// IDs that only this process knows.
+type LocalRegistry struct {
+	mutex   sync.RWMutex
+	schemas map[int32]string
+	ids     map[string]int32
+	nextID  int32
+}
+
+func NewLocalRegistry() *LocalRegistry {
+	registry := &LocalRegistry{
+		schemas: make(map[int32]string),
+		ids:     make(map[string]int32),
+		nextID:  TradingSignalSchemaID + 1,
+	}
+	registry.add(PriceEventSchemaID, PriceEventSchema)
+	registry.add(TradingSignalSchemaID, TradingSignalSchema)
+	return registry
+}
+
+func (r *LocalRegistry) add(id int32, schema string) {
+	r.schemas[id] = schema
+	r.ids[schema] = id
+}
+
+// Register returns the ID of schema, assigning a new one if it is unknown.
+// Like the Confluent registry, the same schema has the same ID under every
+// subject.
+func (r *LocalRegistry) Register(subject, schema string) (int32, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if id, ok := r.ids[schema]; ok {
+		return id, nil
+	}
+	id := r.nextID
+	r.nextID++
+	r.add(id, schema)
+	return id, nil
+}
+
+func (r *LocalRegistry) Schema(id int32) (string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	schema, ok := r.schemas[id]
+	if !ok {
+		return "", fmt.Errorf("schema %d not found in local registry", id)
+	}
+	return schema, nil
+}
+
+// HTTPRegistry talks to a Confluent-compatible schema registry REST API.
+// Callers cache results, so every call is a request.
+type HTTPRegistry struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewHTTPRegistry(baseURL string) *HTTPRegistry {
+	return &HTTPRegistry{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (r *HTTPRegistry) Register(subject, schema string) (int32, error) {
+	body, err := json.Marshal(map[string]string{"schema": schema})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	var response struct {
+		ID int32 `json:"id"`
+	}
+	endpoint := fmt.Sprintf("%s/subjects/%s/versions", r.baseURL, url.PathEscape(subject))
+	if err := r.do(http.MethodPost, endpoint, body, &response); err != nil {
+		return 0, fmt.Errorf("failed to register schema for %s: %w", subject, err)
+	}
+	return response.ID, nil
+}
+
+func (r *HTTPRegistry) Schema(id int32) (string, error) {
+	var response struct {
+		Schema string `json:"schema"`
+	}
+	if err := r.do(http.MethodGet, fmt.Sprintf("%s/schemas/ids/%d", r.baseURL, id), nil, &response); err != nil {
+		return "", fmt.Errorf("failed to fetch schema %d: %w", id, err)
+	}
+	return response.Schema, nil
+}
+
+func (r *HTTPRegistry) do(method, endpoint string, body []byte, response interface{}) error {
+	request, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	}
+
+	resp, err := r.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRegistryUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var registryErr struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&registryErr)
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("%w: returned %s: %s", ErrRegistryUnavailable, resp.Status, registryErr.Message)
+		}
+		return fmt.Errorf("schema registry returned %s: %s", resp.Status, registryErr.Message)
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
@@ -0,0 +1,117 @@
+package wire
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"crypto-trackers/pkg/events"
+)
+
+func TestLocalRegistry(t *testing.T) {
+	registry := NewLocalRegistry()
+
+	if id, err := registry.Register("crypto-prices-value", PriceEventSchema); err != nil || id != PriceEventSchemaID {
+		t.Errorf("expected built-in ID %d, got %d (%v)", PriceEventSchemaID, id, err)
+	}
+
+	id, err := registry.Register("other-value", `{"type": "string"}`)
+	if err != nil {
+		t.Fatalf("failed to register schema: %v", err)
+	}
+	if id <= TradingSignalSchemaID {
+		t.Errorf("expected a new ID after the built-ins, got %d", id)
+	}
+	if again, _ := registry.Register("another-value", `{"type": "string"}`); again != id {
+		t.Errorf("expected the same schema to keep ID %d, got %d", id, again)
+	}
+
+	if schema, err := registry.Schema(id); err != nil || schema != `{"type": "string"}` {
+		t.Errorf("expected registered schema back, got %q (%v)", schema, err)
+	}
+	if _, err := registry.Schema(404); err == nil {
+		t.Error("expected error for unknown ID")
+	}
+}
+
+// fakeSchemaRegistry serves the two Confluent schema registry endpoints the
+// codec uses.
+func fakeSchemaRegistry(t *testing.T) *httptest.Server {
+	schemas := map[string]string{"42": TradingSignalSchema}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/subjects/trading-signals-value/versions":
+			var body struct {
+				Schema string `json:"schema"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Schema != TradingSignalSchema {
+				t.Errorf("unexpected registration body: %v", err)
+			}
+			json.NewEncoder(w).Encode(map[string]int{"id": 42})
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/schemas/ids/"):
+			schema, ok := schemas[strings.TrimPrefix(r.URL.Path, "/schemas/ids/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]interface{}{"error_code": 40403, "message": "Schema not found"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"schema": schema})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestHTTPRegistry(t *testing.T) {
+	server := fakeSchemaRegistry(t)
+	defer server.Close()
+
+	writer, err := NewCodec(FormatAvro, NewRegistry(server.URL+"/"))
+	if err != nil {
+		t.Fatalf("failed to create codec: %v", err)
+	}
+	data, err := writer.Marshal(events.TopicSignals, testSignal())
+	if err != nil {
+		t.Fatalf("failed to marshal signal: %v", err)
+	}
+	if id, _, _ := Unframe(data); id != 42 {
+		t.Errorf("expected the registry's ID 42, got %d", id)
+	}
+
+	reader, err := NewCodec(FormatJSON, NewRegistry(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create codec: %v", err)
+	}
+	var signal events.TradingSignal
+	if err := reader.Unmarshal(data, &signal); err != nil {
+		t.Fatalf("failed to unmarshal signal: %v", err)
+	}
+	if signal.Symbol != "BTC" || signal.Details["crossover_type"] != "golden_cross" {
+		t.Errorf("unexpected signal %+v", signal)
+	}
+
+	if err := reader.Unmarshal(Frame(7, nil), &signal); err == nil || !strings.Contains(err.Error(), "Schema not found") {
+		t.Errorf("expected the registry's error message, got %v", err)
+	} else if errors.Is(err, ErrRegistryUnavailable) {
+		t.Errorf("expected an unknown schema not to be retryable, got %v", err)
+	}
+}
+
+func TestHTTPRegistry_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	registry := NewHTTPRegistry(server.URL)
+	if _, err := registry.Schema(1); !errors.Is(err, ErrRegistryUnavailable) {
+		t.Errorf("expected a 503 to be retryable, got %v", err)
+	}
+
+	server.Close()
+	if _, err := registry.Schema(1); !errors.Is(err, ErrRegistryUnavailable) {
+		t.Errorf("expected a connection failure to be retryable, got %v", err)
+	}
+}
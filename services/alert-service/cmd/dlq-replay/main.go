@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"alert-service/internal/alerts"
+	"alert-service/internal/config"
+	"alert-service/internal/kafka"
+	"alert-service/internal/notify"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dlq-replay resends dead-lettered alerts using the same channel settings as
+// the service, read from the environment.
+func main() {
+	cfg := config.New()
+
+	since := flag.Duration("since", 24*time.Hour, "replay dead letters published within this long ago")
+	topic := flag.String("topic", cfg.DeadLetterTopic, "dead-letter topic to read")
+	channels := flag.String("channels", "", "comma-separated channels to resend to; empty uses each dead letter's failed channels")
+	symbol := flag.String("symbol", "", "only replay dead letters for this symbol")
+	dryRun := flag.Bool("dry-run", false, "list matching dead letters without resending them")
+	flag.Parse()
+
+	notifiers, err := notify.FromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Invalid notification settings: %v", err)
+	}
+	dispatcher := notify.NewDispatcher(
+		notifiers,
+		notify.PolicyFromConfig(cfg).Budget(),
+		*prometheus.NewCounterVec(prometheus.CounterOpts{Name: "alerts_delivered_total"}, []string{"channel"}),
+		*prometheus.NewCounterVec(prometheus.CounterOpts{Name: "alerts_delivery_failed_total"}, []string{"channel"}),
+	)
+	processor := alerts.NewAlertProcessor(
+		cfg.CooldownMinutes,
+		*prometheus.NewCounterVec(prometheus.CounterOpts{Name: "alerts_received_total"}, []string{"symbol", "signal_type"}),
+		*prometheus.NewCounterVec(prometheus.CounterOpts{Name: "alerts_sent_total"}, []string{"symbol"}),
+		*prometheus.NewCounterVec(prometheus.CounterOpts{Name: "alerts_rate_limited_total"}, []string{"symbol"}),
+	)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	matched, failed := 0, 0
+	handler := func(deadLetter *kafka.DeadLetter) error {
+		if *symbol != "" && !strings.EqualFold(deadLetter.Signal.Symbol, *symbol) {
+			return nil
+		}
+		matched++
+
+		targets := deadLetter.FailedChannels
+		if *channels != "" {
+			targets = strings.Split(*channels, ",")
+		}
+
+		fmt.Printf("%s %s %s %s -> %s (%s)\n",
+			deadLetter.FailedAt.Format(time.RFC3339), deadLetter.Signal.Symbol, deadLetter.Signal.SignalType,
+			deadLetter.Signal.Direction, strings.Join(targets, ","), deadLetter.Reason)
+		if *dryRun {
+			return nil
+		}
+
+		selected := dispatcher.Select(targets)
+		if selected.Len() == 0 {
+			failed++
+			return fmt.Errorf("none of %s are configured in NOTIFY_CHANNELS", strings.Join(targets, ","))
+		}
+		if err := processor.Redeliver(ctx, deadLetter, selected); err != nil {
+			failed++
+			return err
+		}
+		return nil
+	}
+
+	brokers := strings.Split(cfg.KafkaBootstrapServers, ",")
+	if _, err := kafka.ReplayDeadLetters(ctx, brokers, *topic, time.Now().Add(-*since), handler); err != nil {
+		log.Fatalf("Replay failed: %v", err)
+	}
+
+	log.Printf("Replayed %d dead letters from %s, %d failed", matched, *topic, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
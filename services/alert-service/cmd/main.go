@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -13,11 +14,20 @@ import (
 
 	"alert-service/internal/alerts"
 	"alert-service/internal/config"
+	"alert-service/internal/confluence"
+	"alert-service/internal/history"
 	"alert-service/internal/kafka"
+	"alert-service/internal/notify"
+	"alert-service/internal/routing"
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio"
+	"crypto-trackers/pkg/overrides"
+	"crypto-trackers/pkg/wire"
 
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 )
 
 type HealthResponse struct {
@@ -28,6 +38,10 @@ type ReadyResponse struct {
 	Status string `json:"status"`
 }
 
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
 var (
 	alertsReceived = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -50,19 +64,71 @@ var (
 		},
 		[]string{"symbol"},
 	)
+	alertsDelivered = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "alerts_delivered_total",
+			Help: "Total number of alerts delivered by channel",
+		},
+		[]string{"channel"},
+	)
+	alertsDeliveryFailed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "alerts_delivery_failed_total",
+			Help: "Total number of failed alert deliveries by channel",
+		},
+		[]string{"channel"},
+	)
+	alertsDeadLettered = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "alerts_dead_lettered_total",
+			Help: "Total number of alerts published to the dead-letter topic",
+		},
+		[]string{"symbol"},
+	)
+	rateLimitStoreErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "alerts_rate_limit_store_errors_total",
+			Help: "Total number of failed rate limit store operations, each allowing the alert",
+		},
+		[]string{"operation"},
+	)
+	alertsUnrouted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "alerts_unrouted_total",
+			Help: "Total number of signals dropped for matching no routing rule",
+		},
+		[]string{"symbol", "signal_type"},
+	)
+	poisonMessages = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_poison_messages_total",
+			Help: "Total number of consumed messages that could not be decoded",
+		},
+		[]string{"topic"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(alertsReceived)
 	prometheus.MustRegister(alertsSent)
 	prometheus.MustRegister(alertsRateLimited)
+	prometheus.MustRegister(alertsDelivered)
+	prometheus.MustRegister(alertsDeliveryFailed)
+	prometheus.MustRegister(alertsDeadLettered)
+	prometheus.MustRegister(alertsUnrouted)
+	prometheus.MustRegister(rateLimitStoreErrors)
+	prometheus.MustRegister(poisonMessages)
 }
 
 type Server struct {
 	config    *config.Config
 	ready     bool
-	consumer  *kafka.Consumer
+	consumer  *kafkaio.Consumer[events.TradingSignal]
+	producer  kafka.DeadLetterProducer
 	processor *alerts.AlertProcessor
+	router    *routing.Router
+	redis     *alerts.RedisStore
+	history   *history.Store
 }
 
 func NewServer(cfg *config.Config) *Server {
@@ -87,25 +153,209 @@ func (s *Server) readyHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(ReadyResponse{Status: status})
 }
 
+// alertsHandler serves the alert history, newest first. See
+// history.ParseFilter for the query parameters.
+func (s *Server) alertsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.history == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "alert history is disabled; set HISTORY_PATH"})
+		return
+	}
+
+	filter, err := history.ParseFilter(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	page, err := s.history.Query(filter)
+	if err != nil {
+		log.Printf("Failed to query alert history: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "failed to query alert history"})
+		return
+	}
+	json.NewEncoder(w).Encode(page)
+}
+
 func (s *Server) setReady(ready bool) {
 	s.ready = ready
 }
 
+func loadSymbolCooldowns(path string) (map[string]int, error) {
+	symbols, err := overrides.Load[config.SymbolOverride](path)
+	if err != nil {
+		return nil, err
+	}
+
+	cooldowns := make(map[string]int)
+	for symbol, override := range symbols {
+		if override.CooldownMinutes == nil {
+			continue
+		}
+		if *override.CooldownMinutes < 0 {
+			return nil, fmt.Errorf("cooldown override for %s must not be negative, got %d", symbol, *override.CooldownMinutes)
+		}
+		cooldowns[symbol] = *override.CooldownMinutes
+		log.Printf("Using %d minute cooldown for %s", *override.CooldownMinutes, symbol)
+	}
+
+	return cooldowns, nil
+}
+
+// newRateLimitStore returns nil for the default in-process store.
+func newRateLimitStore(cfg *config.Config) (*alerts.RedisStore, error) {
+	switch strings.ToLower(cfg.RateLimitStore) {
+	case "memory":
+		return nil, nil
+	case "redis":
+	default:
+		return nil, fmt.Errorf("unsupported rate limit store %q (expected memory or redis)", cfg.RateLimitStore)
+	}
+
+	if cfg.RedisAddr == "" {
+		return nil, fmt.Errorf("REDIS_ADDR is required for the redis rate limit store")
+	}
+	store := alerts.NewRedisStore(redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	}), cfg.RedisKeyPrefix)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := store.Ping(ctx); err != nil {
+		store.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// warnUnconfiguredChannels flags rules that name channels missing from
+// NOTIFY_CHANNELS; alerts routed only to those channels are dropped.
+func warnUnconfiguredChannels(router *routing.Router, dispatcher *notify.Dispatcher) {
+	for _, channel := range router.Channels() {
+		if dispatcher.Select([]string{channel}).Len() == 0 {
+			log.Printf("Routing rules reference channel %q, which is not in NOTIFY_CHANNELS", channel)
+		}
+	}
+}
+
 func (s *Server) initializeKafka() error {
 	brokers := strings.Split(s.config.KafkaBootstrapServers, ",")
 
+	rateLimit, err := alerts.NewRateLimitSettings(s.config.RateLimitMode, s.config.RateLimitKey, s.config.RateLimitMaxAlerts)
+	if err != nil {
+		return err
+	}
+
 	processor := alerts.NewAlertProcessor(s.config.CooldownMinutes, *alertsReceived, *alertsSent, *alertsRateLimited)
+	processor.ConfigureRateLimit(rateLimit)
+	if s.history != nil {
+		processor.SetHistory(s.history)
+	}
 	s.processor = processor
 
-	consumer, err := kafka.NewConsumer(
+	cooldowns, err := loadSymbolCooldowns(s.config.SymbolOverridesPath)
+	if err != nil {
+		return err
+	}
+	processor.SetSymbolCooldowns(cooldowns)
+
+	if s.config.DigestWindowSeconds > 0 {
+		processor.EnableDigest()
+		log.Printf("Sending alerts as a digest every %d seconds", s.config.DigestWindowSeconds)
+	}
+
+	store, err := newRateLimitStore(s.config)
+	if err != nil {
+		return err
+	}
+	if store != nil {
+		processor.SetRateLimitStore(store, *rateLimitStoreErrors)
+		s.redis = store
+		log.Printf("Sharing rate limit state via redis at %s", s.config.RedisAddr)
+	}
+
+	notifiers, err := notify.FromConfig(s.config)
+	if err != nil {
+		return err
+	}
+	dispatcher := notify.NewDispatcher(notifiers, notify.PolicyFromConfig(s.config).Budget(), *alertsDelivered, *alertsDeliveryFailed)
+	processor.SetNotifier(dispatcher)
+	log.Printf("Delivering alerts via %s", dispatcher.Name())
+
+	if s.config.RoutingRulesPath != "" {
+		router, err := routing.NewRouter(s.config.RoutingRulesPath)
+		if err != nil {
+			return err
+		}
+		warnUnconfiguredChannels(router, dispatcher)
+		processor.SetRouter(router, *alertsUnrouted)
+		s.router = router
+		log.Printf("Routing alerts with %d rules from %s", router.Len(), s.config.RoutingRulesPath)
+	}
+
+	if s.config.DigestWindowSeconds > 0 && s.history != nil {
+		pending, err := s.history.PendingDigest()
+		if err != nil {
+			return fmt.Errorf("failed to read buffered digest: %w", err)
+		}
+		processor.RestoreDigest(pending)
+		if len(pending) > 0 {
+			log.Printf("Restored %d signals buffered for the digest before restart", len(pending))
+		}
+	}
+
+	producer, err := kafka.NewProducer(brokers)
+	if err != nil {
+		return err
+	}
+	s.producer = producer
+	processor.SetDeadLetterQueue(producer, s.config.DeadLetterTopic, *alertsDeadLettered)
+
+	handler := processor.ProcessSignal
+	if s.config.ConfluenceWindowMins > 0 {
+		correlator, err := confluence.NewCorrelator(time.Duration(s.config.ConfluenceWindowMins)*time.Minute, s.config.ConfluenceMinDetectors)
+		if err != nil {
+			return err
+		}
+		handler = correlator.Wrap(processor.ProcessSignal)
+		log.Printf("Correlating signals from %d or more detectors within %d minutes", s.config.ConfluenceMinDetectors, s.config.ConfluenceWindowMins)
+	}
+
+	// Signals may arrive as JSON or Avro; the codec only needs the registry
+	codec, err := wire.NewCodec(wire.FormatJSON, wire.NewRegistry(s.config.SchemaRegistryURL))
+	if err != nil {
+		return err
+	}
+
+	consumer, err := kafkaio.NewConsumer(
 		brokers,
 		s.config.KafkaGroupID,
-		[]string{"trading-signals"},
-		processor.ProcessSignal,
+		[]string{events.TopicSignals, s.config.ConsumerRetryTopic},
+		codec,
+		handler,
 	)
 	if err != nil {
 		return err
 	}
+	// Signals are only retried if asked to, since a retry notifies every
+	// channel again
+	consumer.SetFailurePolicy(kafkaio.FailurePolicy{
+		Retries:         s.config.ConsumerMaxRetries,
+		Backoff:         time.Duration(s.config.ConsumerRetryBackoff) * time.Millisecond,
+		MaxBackoff:      30 * time.Second,
+		DeadLetterTopic: s.config.ConsumerDLQTopic,
+	}, producer)
+
+	var poison kafkaio.Forwarder
+	if s.config.ConsumerPoisonDLQ {
+		poison = producer
+	}
+	consumer.SetPoisonQueue(poison, *poisonMessages)
 	s.consumer = consumer
 
 	return nil
@@ -115,9 +365,19 @@ func main() {
 	cfg := config.New()
 	server := NewServer(cfg)
 
+	if cfg.HistoryPath != "" {
+		store, err := history.Open(cfg.HistoryPath)
+		if err != nil {
+			log.Fatalf("Failed to open alert history: %v", err)
+		}
+		server.history = store
+		log.Printf("Recording alert history in %s", cfg.HistoryPath)
+	}
+
 	router := mux.NewRouter()
 	router.HandleFunc("/health", server.healthHandler).Methods("GET")
 	router.HandleFunc("/ready", server.readyHandler).Methods("GET")
+	router.HandleFunc("/alerts", server.alertsHandler).Methods("GET")
 	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
 
 	httpServer := &http.Server{
@@ -141,6 +401,18 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if server.history != nil {
+		go server.history.RunRetention(ctx, time.Duration(cfg.HistoryRetentionHours)*time.Hour)
+	}
+
+	if cfg.DigestWindowSeconds > 0 {
+		go server.processor.RunDigest(ctx, time.Duration(cfg.DigestWindowSeconds)*time.Second)
+	}
+
+	if server.router != nil {
+		go server.router.Watch(ctx, time.Duration(cfg.RoutingReloadSeconds)*time.Second)
+	}
+
 	go func() {
 		if err := server.consumer.Start(ctx); err != nil {
 			log.Printf("Consumer error: %v", err)
@@ -148,7 +420,8 @@ func main() {
 	}()
 
 	server.setReady(true)
-	log.Printf("Alert Service is ready and consuming trading signals (cooldown: %d minutes)", cfg.CooldownMinutes)
+	log.Printf("Alert Service is ready and consuming trading signals (rate limit: %s by %s, cooldown: %d minutes)",
+		cfg.RateLimitMode, cfg.RateLimitKey, cfg.CooldownMinutes)
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -163,8 +436,22 @@ func main() {
 	if server.consumer != nil {
 		server.consumer.Close()
 	}
+	// Send what the digest has buffered while the dead-letter producer is
+	// still open.
+	if err := server.processor.FlushDigest(); err != nil {
+		log.Printf("Failed to send final digest: %v", err)
+	}
+	if server.producer != nil {
+		server.producer.Close()
+	}
+	if server.redis != nil {
+		server.redis.Close()
+	}
 
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
 		log.Printf("Error during shutdown: %v", err)
 	}
+	if server.history != nil {
+		server.history.Close()
+	}
 }
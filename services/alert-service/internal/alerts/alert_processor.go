@@ -1,74 +1,275 @@
 package alerts
 
 import (
+	"alert-service/internal/history"
 	"alert-service/internal/kafka"
+	"alert-service/internal/notify"
+	"alert-service/internal/routing"
+	"context"
+	"crypto-trackers/pkg/events"
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// HistoryRecorder persists what happened to each signal, e.g. a
+// history.Store. Update rewrites an entry once its digest is sent.
+type HistoryRecorder interface {
+	Record(entry *history.Entry) error
+	Update(entry *history.Entry) error
+}
+
 type AlertProcessor struct {
-	rateLimiter       *RateLimiter
-	alertsReceived    prometheus.CounterVec
-	alertsSent        prometheus.CounterVec
-	alertsRateLimited prometheus.CounterVec
+	rateLimiter        *RateLimiter
+	notifier           notify.Notifier
+	deadLetters        kafka.DeadLetterProducer
+	deadLetterTopic    string
+	alertsDeadLettered prometheus.CounterVec
+	router             *routing.Router
+	alertsUnrouted     prometheus.CounterVec
+	alertsReceived     prometheus.CounterVec
+	alertsSent         prometheus.CounterVec
+	alertsRateLimited  prometheus.CounterVec
+	digest             *digestBuffer
+	history            HistoryRecorder
 }
 
 func NewAlertProcessor(cooldownMinutes int, alertsReceived, alertsSent, alertsRateLimited prometheus.CounterVec) *AlertProcessor {
 	return &AlertProcessor{
 		rateLimiter:       NewRateLimiter(cooldownMinutes),
+		notifier:          notify.NewConsoleNotifier(os.Stdout),
 		alertsReceived:    alertsReceived,
 		alertsSent:        alertsSent,
 		alertsRateLimited: alertsRateLimited,
 	}
 }
 
-func (a *AlertProcessor) ProcessSignal(signal *kafka.TradingSignal) error {
+// ConfigureRateLimit sets the rate-limit mode and key. Cooldowns, and the
+// token-bucket window, still come from NewAlertProcessor and
+// SetSymbolCooldowns.
+func (a *AlertProcessor) ConfigureRateLimit(settings RateLimitSettings) {
+	a.rateLimiter.Configure(settings)
+}
+
+// SetRateLimitStore shares rate-limit state through store, so that the limit
+// holds across replicas. See RateLimiter.SetStore for how errors are handled.
+func (a *AlertProcessor) SetRateLimitStore(store RateLimitStore, storeErrors prometheus.CounterVec) {
+	a.rateLimiter.SetStore(store, storeErrors)
+}
+
+// SetSymbolCooldowns applies per-symbol cooldowns, in minutes, on top of the
+// default passed to NewAlertProcessor.
+func (a *AlertProcessor) SetSymbolCooldowns(cooldowns map[string]int) {
+	for symbol, minutes := range cooldowns {
+		a.rateLimiter.SetSymbolCooldown(symbol, minutes)
+	}
+}
+
+// SetNotifier replaces the default console output, typically with a
+// notify.Dispatcher fanning out to several channels.
+func (a *AlertProcessor) SetNotifier(notifier notify.Notifier) {
+	a.notifier = notifier
+}
+
+// SetDeadLetterQueue publishes alerts that could not be delivered to every
+// channel to topic instead of failing ProcessSignal.
+func (a *AlertProcessor) SetDeadLetterQueue(producer kafka.DeadLetterProducer, topic string, alertsDeadLettered prometheus.CounterVec) {
+	a.deadLetters = producer
+	a.deadLetterTopic = topic
+	a.alertsDeadLettered = alertsDeadLettered
+}
+
+// SetRouter restricts each alert to the channels of the routing rules it
+// matches. Signals matching no rule are dropped before rate limiting.
+func (a *AlertProcessor) SetRouter(router *routing.Router, alertsUnrouted prometheus.CounterVec) {
+	a.router = router
+	a.alertsUnrouted = alertsUnrouted
+}
+
+// SetHistory records every received signal with its outcome.
+func (a *AlertProcessor) SetHistory(recorder HistoryRecorder) {
+	a.history = recorder
+}
+
+func (a *AlertProcessor) ProcessSignal(signal *events.TradingSignal) error {
+	receivedAt := time.Now()
 	a.alertsReceived.WithLabelValues(signal.Symbol, signal.SignalType).Inc()
 
-	if !a.rateLimiter.CanSendAlert(signal.Symbol) {
+	notifier := a.notifier
+	if a.router != nil {
+		channels := a.router.Route(signal)
+		if len(channels) == 0 {
+			a.alertsUnrouted.WithLabelValues(signal.Symbol, signal.SignalType).Inc()
+			log.Printf("DROPPED: No routing rule matches %s %s signal for %s", signal.SignalType, signal.Direction, signal.Symbol)
+			a.recordHistory(signal, receivedAt, history.OutcomeUnrouted, nil, nil)
+			return nil
+		}
+		if notifier = a.selectChannels(channels); notifier == nil {
+			a.alertsUnrouted.WithLabelValues(signal.Symbol, signal.SignalType).Inc()
+			log.Printf("DROPPED: %s signal for %s routed to unconfigured channels %s", signal.SignalType, signal.Symbol, strings.Join(channels, ","))
+			a.recordHistory(signal, receivedAt, history.OutcomeUnrouted, channels, nil)
+			return nil
+		}
+	}
+	channels := channelsOf(notifier)
+
+	key := a.rateLimiter.Key(signal)
+	if !a.rateLimiter.TryAcquire(key) {
 		a.alertsRateLimited.WithLabelValues(signal.Symbol).Inc()
-		log.Printf("SKIPPED: Alert for %s within cooldown period (last sent < 5 min ago)", signal.Symbol)
+		log.Printf("SKIPPED: Alert for %s rate limited (%s)", key, a.rateLimiter.Describe(key))
+		entry := a.recordHistory(signal, receivedAt, history.OutcomeRateLimited, channels, nil)
+		if a.digest != nil {
+			a.digest.add(notifier, signal, "", entry)
+		}
+		return nil
+	}
+
+	if a.digest != nil {
+		entry := a.recordHistory(signal, receivedAt, history.OutcomeDigested, channels, nil)
+		a.digest.add(notifier, signal, key, entry)
+		log.Printf("BUFFERED: %s %s signal for %s added to digest", signal.SignalType, signal.Direction, signal.Symbol)
+		return nil
+	}
+
+	err := a.sendAlert(signal, notifier)
+
+	// A partial failure still reached someone, so it keeps the rate-limit
+	// slot; only the failed channels are dead-lettered.
+	outcome := history.OutcomeSent
+	var deliveryErr *notify.DeliveryError
+	if err == nil || (errors.As(err, &deliveryErr) && deliveryErr.Delivered > 0) {
+		a.alertsSent.WithLabelValues(signal.Symbol).Inc()
+		log.Printf("SENT: Alert recorded for %s", signal.Symbol)
+		if err != nil {
+			outcome = history.OutcomePartial
+		}
+	} else {
+		a.rateLimiter.Release(key)
+		outcome = history.OutcomeFailed
+	}
+	a.recordHistory(signal, receivedAt, outcome, channels, err)
+
+	if err != nil {
+		return a.deadLetter(signal, notifier, err)
+	}
+	return nil
+}
+
+// recordHistory returns the recorded entry, or nil if there is no history
+// or recording failed.
+func (a *AlertProcessor) recordHistory(signal *events.TradingSignal, receivedAt time.Time, outcome string, channels []string, deliveryErr error) *history.Entry {
+	if a.history == nil {
 		return nil
 	}
 
-	a.sendAlert(signal)
-	a.rateLimiter.RecordAlert(signal.Symbol)
-	a.alertsSent.WithLabelValues(signal.Symbol).Inc()
-	log.Printf("SENT: Alert recorded for %s", signal.Symbol)
+	// Signals that will appear in a digest, suppressed or not, stay pending
+	// until it is sent
+	entry := &history.Entry{
+		ReceivedAt:    receivedAt.UTC(),
+		Outcome:       outcome,
+		Channels:      channels,
+		PendingDigest: a.digest != nil && (outcome == history.OutcomeDigested || outcome == history.OutcomeRateLimited),
+		Signal:        signal,
+	}
+	if deliveryErr != nil {
+		entry.Error = deliveryErr.Error()
+	}
+
+	if err := a.history.Record(entry); err != nil {
+		log.Printf("Failed to record alert history for %s: %v", signal.Symbol, err)
+		return nil
+	}
+	return entry
+}
+
+// Redeliver sends a dead-lettered alert through notifier. Rate limiting is
+// skipped since the signal already passed it when first received.
+func (a *AlertProcessor) Redeliver(ctx context.Context, deadLetter *kafka.DeadLetter, notifier notify.Notifier) error {
+	return notifier.Notify(ctx, a.newAlert(deadLetter.Signal))
+}
+
+// selectChannels narrows a dispatcher to the routed channels, returning nil
+// if none of them are configured. Other notifiers have no channels to choose
+// between and are used as they are.
+func (a *AlertProcessor) selectChannels(channels []string) notify.Notifier {
+	dispatcher, ok := a.notifier.(*notify.Dispatcher)
+	if !ok {
+		return a.notifier
+	}
+
+	selected := dispatcher.Select(channels)
+	if selected.Len() == 0 {
+		return nil
+	}
+	return selected
+}
 
+// channelsOf lists the channels notifier delivers to.
+func channelsOf(notifier notify.Notifier) []string {
+	if dispatcher, ok := notifier.(*notify.Dispatcher); ok {
+		return dispatcher.Channels()
+	}
+	return []string{notifier.Name()}
+}
+
+func (a *AlertProcessor) deadLetter(signal *events.TradingSignal, notifier notify.Notifier, deliveryErr error) error {
+	if a.deadLetters == nil {
+		return fmt.Errorf("failed to deliver alert for %s: %w", signal.Symbol, deliveryErr)
+	}
+
+	// Only a dispatcher knows which of its channels failed; any other
+	// notifier is a single channel that failed as a whole.
+	failedChannels := channelsOf(notifier)
+	var channelErrs *notify.DeliveryError
+	if errors.As(deliveryErr, &channelErrs) {
+		failedChannels = channelErrs.Channels()
+	}
+
+	deadLetter := &kafka.DeadLetter{
+		Signal:         signal,
+		Reason:         deliveryErr.Error(),
+		FailedChannels: failedChannels,
+		FailedAt:       time.Now().UTC(),
+	}
+
+	if err := a.deadLetters.PublishDeadLetter(context.Background(), a.deadLetterTopic, deadLetter); err != nil {
+		return fmt.Errorf("failed to dead-letter alert for %s: %w", signal.Symbol, errors.Join(deliveryErr, err))
+	}
+
+	a.alertsDeadLettered.WithLabelValues(signal.Symbol).Inc()
+	log.Printf("DEAD-LETTERED: Alert for %s via %s sent to %s", signal.Symbol, strings.Join(failedChannels, ","), a.deadLetterTopic)
 	return nil
 }
 
-func (a *AlertProcessor) sendAlert(signal *kafka.TradingSignal) {
-	alert := a.formatAlert(signal)
-	fmt.Println("🚨 TRADING SIGNAL ALERT 🚨")
-	fmt.Println(alert)
-	fmt.Println("=" + strings.Repeat("=", 50))
+func (a *AlertProcessor) sendAlert(signal *events.TradingSignal, notifier notify.Notifier) error {
+	alert := a.newAlert(signal)
 
 	log.Printf("ALERT: %s %s signal for %s (strength: %s)",
 		signal.SignalType, signal.Direction, signal.Symbol, signal.SignalStrength)
-}
 
-func (a *AlertProcessor) formatAlert(signal *kafka.TradingSignal) string {
-	var builder strings.Builder
+	return notifier.Notify(context.Background(), alert)
+}
 
-	builder.WriteString(fmt.Sprintf("Symbol: %s\n", signal.Symbol))
-	builder.WriteString(fmt.Sprintf("Signal Type: %s\n", signal.SignalType))
-	builder.WriteString(fmt.Sprintf("Direction: %s\n", signal.Direction))
-	builder.WriteString(fmt.Sprintf("Strength: %s\n", signal.SignalStrength))
-	builder.WriteString(fmt.Sprintf("Time: %s\n", signal.Timestamp.Format(time.RFC3339)))
-	builder.WriteString(fmt.Sprintf("Service: %s\n", signal.ServiceID))
+// newAlert renders signal in plain text. Channels configured with another
+// format render it again from the signal.
+func (a *AlertProcessor) newAlert(signal *events.TradingSignal) notify.Alert {
+	return a.format(notify.Alert{Signal: signal})
+}
 
-	if len(signal.Details) > 0 {
-		builder.WriteString("Details:\n")
-		for key, value := range signal.Details {
-			builder.WriteString(fmt.Sprintf("  %s: %v\n", key, value))
-		}
+func (a *AlertProcessor) format(alert notify.Alert) notify.Alert {
+	formatted, err := notify.DefaultFormatter().Format(alert)
+	if err != nil {
+		log.Printf("Failed to format alert: %v", err)
+		return alert
 	}
+	return formatted
+}
 
-	return builder.String()
+func (a *AlertProcessor) formatAlert(signal *events.TradingSignal) string {
+	return a.newAlert(signal).Body
 }
@@ -1,11 +1,21 @@
 package alerts
 
 import (
+	"alert-service/internal/history"
 	"alert-service/internal/kafka"
+	"alert-service/internal/notify"
+	"alert-service/internal/routing"
+	"context"
+	"crypto-trackers/pkg/events"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestAlertProcessor_ProcessSignal(t *testing.T) {
@@ -24,7 +34,7 @@ func TestAlertProcessor_ProcessSignal(t *testing.T) {
 
 	processor := NewAlertProcessor(5, *alertsReceived, *alertsSent, *alertsRateLimited)
 
-	signal := &kafka.TradingSignal{
+	signal := &events.TradingSignal{
 		Timestamp:      time.Now(),
 		Symbol:         "BTC",
 		SignalType:     "moving_average_crossover",
@@ -49,7 +59,7 @@ func TestAlertProcessor_ProcessSignal(t *testing.T) {
 	})
 
 	t.Run("different symbol processed", func(t *testing.T) {
-		ethSignal := &kafka.TradingSignal{
+		ethSignal := &events.TradingSignal{
 			Timestamp:      time.Now(),
 			Symbol:         "ETH",
 			SignalType:     "volume_spike",
@@ -82,7 +92,7 @@ func TestAlertProcessor_FormatAlert(t *testing.T) {
 
 	processor := NewAlertProcessor(5, *alertsReceived, *alertsSent, *alertsRateLimited)
 
-	signal := &kafka.TradingSignal{
+	signal := &events.TradingSignal{
 		Timestamp:      time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
 		Symbol:         "BTC",
 		SignalType:     "test_signal",
@@ -111,6 +121,386 @@ func TestAlertProcessor_FormatAlert(t *testing.T) {
 	}
 }
 
+type failingNotifier struct {
+	calls int
+}
+
+func (f *failingNotifier) Name() string { return "failing" }
+
+func (f *failingNotifier) Notify(ctx context.Context, alert notify.Alert) error {
+	f.calls++
+	return &notify.DeliveryError{Failed: map[string]error{"failing": errors.New("unavailable")}}
+}
+
+func TestAlertProcessor_DeliveryFailure(t *testing.T) {
+	alertsReceived := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_received", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	alertsSent := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_sent", Help: "test"},
+		[]string{"symbol"},
+	)
+	alertsRateLimited := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_rate_limited", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	processor := NewAlertProcessor(5, *alertsReceived, *alertsSent, *alertsRateLimited)
+	notifier := &failingNotifier{}
+	processor.SetNotifier(notifier)
+
+	signal := &events.TradingSignal{
+		Timestamp:      time.Now(),
+		Symbol:         "BTC",
+		SignalType:     "moving_average_crossover",
+		SignalStrength: "strong",
+		Direction:      "bullish",
+		ServiceID:      "ma-signal-detector",
+	}
+
+	if err := processor.ProcessSignal(signal); err == nil {
+		t.Error("expected error when no channel delivered the alert")
+	}
+
+	// An undelivered alert must not start the cooldown
+	if err := processor.ProcessSignal(signal); err == nil {
+		t.Error("expected error on second attempt")
+	}
+	if notifier.calls != 2 {
+		t.Errorf("expected 2 delivery attempts, got %d", notifier.calls)
+	}
+}
+
+type mockDeadLetterProducer struct {
+	deadLetters []*kafka.DeadLetter
+	topics      []string
+}
+
+func (m *mockDeadLetterProducer) PublishDeadLetter(ctx context.Context, topic string, deadLetter *kafka.DeadLetter) error {
+	m.topics = append(m.topics, topic)
+	m.deadLetters = append(m.deadLetters, deadLetter)
+	return nil
+}
+
+func (m *mockDeadLetterProducer) Close() error {
+	return nil
+}
+
+type partialNotifier struct{}
+
+func (partialNotifier) Name() string { return "webhook,slack" }
+
+func (partialNotifier) Notify(ctx context.Context, alert notify.Alert) error {
+	return &notify.DeliveryError{Failed: map[string]error{"slack": errors.New("unexpected status 503")}, Delivered: 1}
+}
+
+func TestAlertProcessor_DeadLetter(t *testing.T) {
+	alertsReceived := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_received", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	alertsSent := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_sent", Help: "test"},
+		[]string{"symbol"},
+	)
+	alertsRateLimited := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_rate_limited", Help: "test"},
+		[]string{"symbol"},
+	)
+	alertsDeadLettered := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_dead_lettered", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	signal := &events.TradingSignal{
+		Timestamp:      time.Now(),
+		Symbol:         "BTC",
+		SignalType:     "moving_average_crossover",
+		SignalStrength: "strong",
+		Direction:      "bullish",
+		ServiceID:      "ma-signal-detector",
+	}
+
+	t.Run("failed channels of a partial delivery", func(t *testing.T) {
+		producer := &mockDeadLetterProducer{}
+		processor := NewAlertProcessor(5, *alertsReceived, *alertsSent, *alertsRateLimited)
+		processor.SetNotifier(partialNotifier{})
+		processor.SetDeadLetterQueue(producer, "alerts-dlq", *alertsDeadLettered)
+
+		if err := processor.ProcessSignal(signal); err != nil {
+			t.Fatalf("expected no error once dead-lettered, got %v", err)
+		}
+
+		if len(producer.deadLetters) != 1 || producer.topics[0] != "alerts-dlq" {
+			t.Fatalf("expected 1 dead letter on alerts-dlq, got %d", len(producer.deadLetters))
+		}
+		deadLetter := producer.deadLetters[0]
+		if deadLetter.Signal != signal {
+			t.Error("expected the original signal in the dead letter")
+		}
+		if len(deadLetter.FailedChannels) != 1 || deadLetter.FailedChannels[0] != "slack" {
+			t.Errorf("expected only slack to be dead-lettered, got %v", deadLetter.FailedChannels)
+		}
+		if !contains(deadLetter.Reason, "unexpected status 503") {
+			t.Errorf("expected failure reason, got %q", deadLetter.Reason)
+		}
+
+		if processor.rateLimiter.CanSendAlert("BTC") {
+			t.Error("expected the partial delivery to start the cooldown")
+		}
+	})
+
+	t.Run("undelivered alert", func(t *testing.T) {
+		producer := &mockDeadLetterProducer{}
+		processor := NewAlertProcessor(5, *alertsReceived, *alertsSent, *alertsRateLimited)
+		processor.SetNotifier(&failingNotifier{})
+		processor.SetDeadLetterQueue(producer, "alerts-dlq", *alertsDeadLettered)
+
+		if err := processor.ProcessSignal(signal); err != nil {
+			t.Fatalf("expected no error once dead-lettered, got %v", err)
+		}
+		if len(producer.deadLetters) != 1 || producer.deadLetters[0].FailedChannels[0] != "failing" {
+			t.Errorf("expected the failing channel to be dead-lettered, got %d dead letters", len(producer.deadLetters))
+		}
+		if !processor.rateLimiter.CanSendAlert("BTC") {
+			t.Error("expected an undelivered alert not to start the cooldown")
+		}
+	})
+}
+
+type recordingNotifier struct {
+	name    string
+	mutex   sync.Mutex
+	symbols []string
+}
+
+func (r *recordingNotifier) Name() string { return r.name }
+
+func (r *recordingNotifier) Notify(ctx context.Context, alert notify.Alert) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.symbols = append(r.symbols, alert.Signal.Symbol)
+	return nil
+}
+
+func TestAlertProcessor_Routing(t *testing.T) {
+	alertsReceived := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_received", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	alertsSent := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_sent", Help: "test"},
+		[]string{"symbol"},
+	)
+	alertsRateLimited := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_rate_limited", Help: "test"},
+		[]string{"symbol"},
+	)
+	alertsUnrouted := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_unrouted", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	delivered := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_delivered", Help: "test"},
+		[]string{"channel"},
+	)
+	failed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_delivery_failed", Help: "test"},
+		[]string{"channel"},
+	)
+
+	path := filepath.Join(t.TempDir(), "routing.yaml")
+	rules := "rules:\n  - match: {symbol: BTC}\n    channels: [slack]\n  - match: {symbol: ETH}\n    channels: [telegram]\n"
+	if err := os.WriteFile(path, []byte(rules), 0o644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	router, err := routing.NewRouter(path)
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	slack := &recordingNotifier{name: "slack"}
+	smtp := &recordingNotifier{name: "smtp"}
+
+	processor := NewAlertProcessor(5, *alertsReceived, *alertsSent, *alertsRateLimited)
+	processor.SetNotifier(notify.NewDispatcher([]notify.Notifier{slack, smtp}, time.Second, *delivered, *failed))
+	processor.SetRouter(router, *alertsUnrouted)
+
+	for _, symbol := range []string{"BTC", "ETH", "DOGE"} {
+		signal := &events.TradingSignal{
+			Timestamp:  time.Now(),
+			Symbol:     symbol,
+			SignalType: "volume_spike",
+			Direction:  "bullish",
+		}
+		if err := processor.ProcessSignal(signal); err != nil {
+			t.Errorf("expected no error for %s, got %v", symbol, err)
+		}
+	}
+
+	if len(slack.symbols) != 1 || slack.symbols[0] != "BTC" {
+		t.Errorf("expected only BTC on slack, got %v", slack.symbols)
+	}
+	if len(smtp.symbols) != 0 {
+		t.Errorf("expected nothing routed to smtp, got %v", smtp.symbols)
+	}
+
+	// ETH is routed to telegram, which is not configured, and DOGE matches no rule
+	if got := testutil.ToFloat64(alertsUnrouted.WithLabelValues("ETH", "volume_spike")); got != 1 {
+		t.Errorf("expected ETH to be counted as unrouted, got %.0f", got)
+	}
+	if got := testutil.ToFloat64(alertsUnrouted.WithLabelValues("DOGE", "volume_spike")); got != 1 {
+		t.Errorf("expected DOGE to be counted as unrouted, got %.0f", got)
+	}
+	if !processor.rateLimiter.CanSendAlert("DOGE") {
+		t.Error("expected a dropped signal not to start the cooldown")
+	}
+}
+
+func TestAlertProcessor_RateLimitKey(t *testing.T) {
+	alertsReceived := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_received", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	alertsSent := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_sent", Help: "test"},
+		[]string{"symbol"},
+	)
+	alertsRateLimited := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_rate_limited", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	settings, err := NewRateLimitSettings(RateLimitModeCooldown, RateLimitKeySymbolSignalType, 1)
+	if err != nil {
+		t.Fatalf("failed to create settings: %v", err)
+	}
+
+	processor := NewAlertProcessor(5, *alertsReceived, *alertsSent, *alertsRateLimited)
+	processor.ConfigureRateLimit(settings)
+	notifier := &recordingNotifier{name: "console"}
+	processor.SetNotifier(notifier)
+
+	for _, signalType := range []string{"volume_spike", "moving_average_crossover", "volume_spike"} {
+		signal := &events.TradingSignal{Timestamp: time.Now(), Symbol: "BTC", SignalType: signalType, Direction: "bullish"}
+		if err := processor.ProcessSignal(signal); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	}
+
+	if len(notifier.symbols) != 2 {
+		t.Errorf("expected the crossover to pass and the repeated spike to be limited, got %d alerts", len(notifier.symbols))
+	}
+	if got := testutil.ToFloat64(alertsRateLimited.WithLabelValues("BTC")); got != 1 {
+		t.Errorf("expected 1 rate-limited alert, got %.0f", got)
+	}
+}
+
+func TestAlertProcessor_ConcurrentSignals(t *testing.T) {
+	alertsReceived := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_received", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	alertsSent := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_sent", Help: "test"},
+		[]string{"symbol"},
+	)
+	alertsRateLimited := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_rate_limited", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	processor := NewAlertProcessor(5, *alertsReceived, *alertsSent, *alertsRateLimited)
+	notifier := &recordingNotifier{name: "console"}
+	processor.SetNotifier(notifier)
+
+	const goroutines = 50
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			processor.ProcessSignal(&events.TradingSignal{Timestamp: time.Now(), Symbol: "BTC", SignalType: "volume_spike"})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if len(notifier.symbols) != 1 {
+		t.Errorf("expected exactly 1 alert to be sent, got %d", len(notifier.symbols))
+	}
+	if got := testutil.ToFloat64(alertsRateLimited.WithLabelValues("BTC")); got != goroutines-1 {
+		t.Errorf("expected %d rate-limited alerts, got %.0f", goroutines-1, got)
+	}
+}
+
+type memoryHistory struct {
+	entries []*history.Entry
+}
+
+func (m *memoryHistory) Record(entry *history.Entry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *memoryHistory) Update(entry *history.Entry) error {
+	return nil
+}
+
+func TestAlertProcessor_History(t *testing.T) {
+	alertsReceived := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_received", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	alertsSent := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_sent", Help: "test"},
+		[]string{"symbol"},
+	)
+	alertsRateLimited := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_rate_limited", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	processor := NewAlertProcessor(5, *alertsReceived, *alertsSent, *alertsRateLimited)
+	processor.SetNotifier(&recordingNotifier{name: "console"})
+	recorder := &memoryHistory{}
+	processor.SetHistory(recorder)
+
+	btc := &events.TradingSignal{Timestamp: time.Now(), Symbol: "BTC", SignalType: "volume_spike"}
+	processor.ProcessSignal(btc)
+	processor.ProcessSignal(btc)
+
+	processor.SetNotifier(&failingNotifier{})
+	processor.ProcessSignal(&events.TradingSignal{Timestamp: time.Now(), Symbol: "ETH", SignalType: "volume_spike"})
+
+	expected := []struct{ symbol, outcome string }{
+		{"BTC", history.OutcomeSent},
+		{"BTC", history.OutcomeRateLimited},
+		{"ETH", history.OutcomeFailed},
+	}
+	if len(recorder.entries) != len(expected) {
+		t.Fatalf("expected %d history entries, got %d", len(expected), len(recorder.entries))
+	}
+	for i, want := range expected {
+		entry := recorder.entries[i]
+		if entry.Signal.Symbol != want.symbol || entry.Outcome != want.outcome {
+			t.Errorf("entry %d: expected %s %s, got %s %s", i, want.symbol, want.outcome, entry.Signal.Symbol, entry.Outcome)
+		}
+		if entry.ReceivedAt.IsZero() {
+			t.Errorf("entry %d: expected a receive time", i)
+		}
+	}
+	if recorder.entries[0].Channels[0] != "console" {
+		t.Errorf("expected the delivery channel to be recorded, got %v", recorder.entries[0].Channels)
+	}
+	if recorder.entries[2].Error == "" {
+		t.Error("expected the delivery error to be recorded")
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && (s[:len(substr)] == substr || s[len(s)-len(substr):] == substr || containsInMiddle(s, substr)))
 }
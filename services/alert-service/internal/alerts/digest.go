@@ -0,0 +1,234 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"alert-service/internal/history"
+	"alert-service/internal/notify"
+	"crypto-trackers/pkg/events"
+)
+
+// digestBuffer collects signals between flushes, separately for each
+// notifier since routing can send signals to different channels.
+type digestBuffer struct {
+	mutex   sync.Mutex
+	start   time.Time
+	pending map[string]*pendingDigest
+}
+
+type pendingDigest struct {
+	notifier notify.Notifier
+	groups   map[string]*notify.DigestGroup
+	keys     []string
+	entries  []*history.Entry
+}
+
+func newDigestBuffer(start time.Time) *digestBuffer {
+	return &digestBuffer{start: start, pending: make(map[string]*pendingDigest)}
+}
+
+// add buffers signal for notifier. key is the rate-limit key it acquired,
+// or empty if it was suppressed. entry is its history entry, if recorded.
+func (b *digestBuffer) add(notifier notify.Notifier, signal *events.TradingSignal, key string, entry *history.Entry) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	pending, ok := b.pending[notifier.Name()]
+	if !ok {
+		pending = &pendingDigest{notifier: notifier, groups: make(map[string]*notify.DigestGroup)}
+		b.pending[notifier.Name()] = pending
+	}
+
+	groupKey := signal.Symbol + keySeparator + signal.SignalType
+	group, ok := pending.groups[groupKey]
+	if !ok {
+		group = &notify.DigestGroup{Symbol: signal.Symbol, SignalType: signal.SignalType}
+		pending.groups[groupKey] = group
+	}
+
+	if entry != nil {
+		pending.entries = append(pending.entries, entry)
+		if entry.ReceivedAt.Before(b.start) {
+			b.start = entry.ReceivedAt
+		}
+	}
+
+	if key == "" {
+		group.Suppressed++
+		return
+	}
+	group.Signals = append(group.Signals, signal)
+	pending.keys = append(pending.keys, key)
+}
+
+// take empties the buffer, returning what it held and the window it covered.
+func (b *digestBuffer) take(now time.Time) (map[string]*pendingDigest, time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	pending, start := b.pending, b.start
+	b.pending = make(map[string]*pendingDigest)
+	b.start = now
+	return pending, start
+}
+
+// EnableDigest buffers alerts instead of sending them one by one. Buffered
+// signals, including those suppressed by rate limiting, go out as a single
+// summary on each FlushDigest.
+func (a *AlertProcessor) EnableDigest() {
+	a.digest = newDigestBuffer(time.Now())
+}
+
+// RestoreDigest buffers again the signals a previous run recorded in the
+// history but did not send, as returned by history.Store.PendingDigest.
+// Call it after EnableDigest and once the notifier and router are set.
+func (a *AlertProcessor) RestoreDigest(entries []*history.Entry) {
+	if a.digest == nil {
+		return
+	}
+
+	for _, entry := range entries {
+		notifier := a.notifier
+		if len(entry.Channels) > 0 {
+			notifier = a.selectChannels(entry.Channels)
+		}
+		if notifier == nil {
+			log.Printf("DROPPED: Buffered %s signal for %s routed to unconfigured channels %s",
+				entry.Signal.SignalType, entry.Signal.Symbol, strings.Join(entry.Channels, ","))
+			continue
+		}
+
+		key := ""
+		if entry.Outcome == history.OutcomeDigested {
+			// Hold the slot again so that repeats are still suppressed
+			key = a.rateLimiter.Key(entry.Signal)
+			a.rateLimiter.TryAcquire(key)
+		}
+		a.digest.add(notifier, entry.Signal, key, entry)
+	}
+}
+
+// RunDigest flushes the digest every window until ctx is done. Call
+// FlushDigest once more on shutdown to send what is left.
+func (a *AlertProcessor) RunDigest(ctx context.Context, window time.Duration) {
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.FlushDigest(); err != nil {
+				log.Printf("Digest delivery failed: %v", err)
+			}
+		}
+	}
+}
+
+// FlushDigest sends one digest per notifier with buffered signals. Failures
+// are handled as for single alerts: the signals are dead-lettered and, if
+// no channel received the digest, their rate-limit slots are released.
+func (a *AlertProcessor) FlushDigest() error {
+	if a.digest == nil {
+		return nil
+	}
+
+	now := time.Now()
+	pending, start := a.digest.take(now)
+
+	names := make([]string, 0, len(pending))
+	for name := range pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if err := a.sendDigest(pending[name], start, now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *AlertProcessor) sendDigest(pending *pendingDigest, start, end time.Time) error {
+	digest := &notify.Digest{Start: start.UTC(), End: end.UTC()}
+	for _, group := range pending.groups {
+		digest.Groups = append(digest.Groups, *group)
+	}
+	sort.Slice(digest.Groups, func(i, j int) bool {
+		if digest.Groups[i].Symbol != digest.Groups[j].Symbol {
+			return digest.Groups[i].Symbol < digest.Groups[j].Symbol
+		}
+		return digest.Groups[i].SignalType < digest.Groups[j].SignalType
+	})
+
+	alert := a.format(notify.Alert{Digest: digest})
+	log.Printf("DIGEST: %s via %s", alert.Subject, pending.notifier.Name())
+
+	err := pending.notifier.Notify(context.Background(), alert)
+
+	outcome := history.OutcomeSent
+	var deliveryErr *notify.DeliveryError
+	if err == nil || (errors.As(err, &deliveryErr) && deliveryErr.Delivered > 0) {
+		if err != nil {
+			outcome = history.OutcomePartial
+		}
+		for _, group := range digest.Groups {
+			if len(group.Signals) > 0 {
+				a.alertsSent.WithLabelValues(group.Symbol).Add(float64(len(group.Signals)))
+			}
+		}
+	} else {
+		outcome = history.OutcomeFailed
+		for _, key := range pending.keys {
+			a.rateLimiter.Release(key)
+		}
+	}
+
+	a.settleHistory(pending.entries, outcome, err)
+
+	if err == nil {
+		return nil
+	}
+	if len(pending.keys) == 0 {
+		return fmt.Errorf("failed to deliver digest of suppressed signals: %w", err)
+	}
+
+	var errs []error
+	for _, group := range digest.Groups {
+		for _, signal := range group.Signals {
+			if dlqErr := a.deadLetter(signal, pending.notifier, err); dlqErr != nil {
+				errs = append(errs, dlqErr)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// settleHistory records the digest's outcome on the history entries of the
+// signals it carried, and clears the pending flag on all of its entries so
+// they are not buffered again after a restart. Suppressed signals keep their
+// rate_limited outcome.
+func (a *AlertProcessor) settleHistory(entries []*history.Entry, outcome string, deliveryErr error) {
+	for _, entry := range entries {
+		entry.PendingDigest = false
+		if entry.Outcome == history.OutcomeDigested {
+			entry.Outcome = outcome
+			if deliveryErr != nil {
+				entry.Error = deliveryErr.Error()
+			}
+		}
+		if err := a.history.Update(entry); err != nil {
+			log.Printf("Failed to update alert history for %s: %v", entry.Signal.Symbol, err)
+		}
+	}
+}
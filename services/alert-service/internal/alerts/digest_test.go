@@ -0,0 +1,237 @@
+package alerts
+
+import (
+	"alert-service/internal/history"
+	"alert-service/internal/notify"
+	"context"
+	"crypto-trackers/pkg/events"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type capturingNotifier struct {
+	alerts []notify.Alert
+}
+
+func (c *capturingNotifier) Name() string { return "capturing" }
+
+func (c *capturingNotifier) Notify(ctx context.Context, alert notify.Alert) error {
+	c.alerts = append(c.alerts, alert)
+	return nil
+}
+
+func newDigestTestProcessor() (*AlertProcessor, *prometheus.CounterVec) {
+	alertsReceived := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_received", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	alertsSent := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_sent", Help: "test"},
+		[]string{"symbol"},
+	)
+	alertsRateLimited := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_rate_limited", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	processor := NewAlertProcessor(5, *alertsReceived, *alertsSent, *alertsRateLimited)
+	processor.EnableDigest()
+	return processor, alertsSent
+}
+
+func TestAlertProcessor_Digest(t *testing.T) {
+	processor, alertsSent := newDigestTestProcessor()
+	notifier := &capturingNotifier{}
+	processor.SetNotifier(notifier)
+
+	signals := []*events.TradingSignal{
+		{Timestamp: time.Now(), Symbol: "ETH", SignalType: "golden_cross", SignalStrength: "strong", Direction: "bullish"},
+		{Timestamp: time.Now(), Symbol: "BTC", SignalType: "volume_spike", SignalStrength: "medium", Direction: "bullish"},
+		{Timestamp: time.Now(), Symbol: "BTC", SignalType: "volume_spike", SignalStrength: "strong", Direction: "bullish"},
+		{Timestamp: time.Now(), Symbol: "BTC", SignalType: "golden_cross", SignalStrength: "strong", Direction: "bullish"},
+	}
+	for _, signal := range signals {
+		if err := processor.ProcessSignal(signal); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if len(notifier.alerts) != 0 {
+		t.Fatalf("expected signals to be buffered, got %d alerts", len(notifier.alerts))
+	}
+
+	if err := processor.FlushDigest(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("expected a single digest, got %d alerts", len(notifier.alerts))
+	}
+
+	digest := notifier.alerts[0].Digest
+	if digest == nil {
+		t.Fatal("expected the alert to carry the digest")
+	}
+
+	// Groups are ordered by symbol, then signal type; BTC's later signals
+	// are rate limited by the first
+	expected := []struct {
+		symbol, signalType string
+		alerts, suppressed int
+	}{
+		{"BTC", "golden_cross", 0, 1},
+		{"BTC", "volume_spike", 1, 1},
+		{"ETH", "golden_cross", 1, 0},
+	}
+	if len(digest.Groups) != len(expected) {
+		t.Fatalf("expected %d groups, got %d", len(expected), len(digest.Groups))
+	}
+	for i, want := range expected {
+		group := digest.Groups[i]
+		if group.Symbol != want.symbol || group.SignalType != want.signalType ||
+			len(group.Signals) != want.alerts || group.Suppressed != want.suppressed {
+			t.Errorf("group %d: expected %s %s with %d alerts and %d suppressed, got %s %s with %d and %d",
+				i, want.symbol, want.signalType, want.alerts, want.suppressed,
+				group.Symbol, group.SignalType, len(group.Signals), group.Suppressed)
+		}
+	}
+
+	alert := notifier.alerts[0]
+	if alert.Subject != "Alert digest: 4 signals for 2 symbols, 2 suppressed" {
+		t.Errorf("unexpected subject %q", alert.Subject)
+	}
+	if !strings.Contains(alert.Body, "  volume_spike: 1 alerts, 1 suppressed (latest: bullish medium") {
+		t.Errorf("expected suppressed counts in the body, got:\n%s", alert.Body)
+	}
+	if strings.Index(alert.Body, "BTC:") > strings.Index(alert.Body, "ETH:") {
+		t.Errorf("expected symbols in order, got:\n%s", alert.Body)
+	}
+
+	if got := testutil.ToFloat64(alertsSent.WithLabelValues("BTC")); got != 1 {
+		t.Errorf("expected 1 BTC alert counted as sent, got %v", got)
+	}
+
+	if err := processor.FlushDigest(); err != nil || len(notifier.alerts) != 1 {
+		t.Errorf("expected an empty buffer to send nothing, got %d alerts (%v)", len(notifier.alerts), err)
+	}
+}
+
+func TestAlertProcessor_DigestFailure(t *testing.T) {
+	processor, _ := newDigestTestProcessor()
+	processor.SetNotifier(&failingNotifier{})
+
+	alertsDeadLettered := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_dead_lettered", Help: "test"},
+		[]string{"symbol"},
+	)
+	producer := &mockDeadLetterProducer{}
+	processor.SetDeadLetterQueue(producer, "alerts-dlq", *alertsDeadLettered)
+
+	signal := &events.TradingSignal{Timestamp: time.Now(), Symbol: "BTC", SignalType: "volume_spike", Direction: "bullish"}
+	processor.ProcessSignal(signal)
+	processor.ProcessSignal(signal)
+
+	if err := processor.FlushDigest(); err != nil {
+		t.Fatalf("expected no error once dead-lettered, got %v", err)
+	}
+	if len(producer.deadLetters) != 1 || producer.deadLetters[0].Signal != signal {
+		t.Errorf("expected the allowed signal to be dead-lettered, got %d dead letters", len(producer.deadLetters))
+	}
+	if !processor.rateLimiter.CanSendAlert("BTC") {
+		t.Error("expected an undelivered digest to release its rate-limit slots")
+	}
+}
+
+func TestAlertProcessor_DigestSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.db")
+	store, err := history.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open history: %v", err)
+	}
+
+	processor, _ := newDigestTestProcessor()
+	processor.SetNotifier(&capturingNotifier{})
+	processor.SetHistory(store)
+
+	signal := &events.TradingSignal{Timestamp: time.Now(), Symbol: "BTC", SignalType: "volume_spike", Direction: "bullish"}
+	processor.ProcessSignal(signal)
+	processor.ProcessSignal(signal)
+
+	// The process dies before the digest is flushed
+	store.Close()
+	if store, err = history.Open(path); err != nil {
+		t.Fatalf("failed to reopen history: %v", err)
+	}
+	defer store.Close()
+
+	restarted, _ := newDigestTestProcessor()
+	notifier := &capturingNotifier{}
+	restarted.SetNotifier(notifier)
+	restarted.SetHistory(store)
+
+	pending, err := store.PendingDigest()
+	if err != nil || len(pending) != 2 {
+		t.Fatalf("expected both signals pending, got %d (%v)", len(pending), err)
+	}
+	restarted.RestoreDigest(pending)
+
+	if restarted.rateLimiter.CanSendAlert("BTC") {
+		t.Error("expected the restored signal to hold its rate-limit slot")
+	}
+
+	if err := restarted.FlushDigest(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("expected the restored signals in one digest, got %d alerts", len(notifier.alerts))
+	}
+	groups := notifier.alerts[0].Digest.Groups
+	if len(groups) != 1 || len(groups[0].Signals) != 1 || groups[0].Suppressed != 1 {
+		t.Errorf("expected 1 alert and 1 suppressed, got %+v", groups)
+	}
+
+	if pending, _ := store.PendingDigest(); len(pending) != 0 {
+		t.Errorf("expected nothing pending once the digest is sent, got %d", len(pending))
+	}
+}
+
+func TestAlertProcessor_DigestHistory(t *testing.T) {
+	processor, _ := newDigestTestProcessor()
+	recorder := &memoryHistory{}
+	processor.SetHistory(recorder)
+
+	btc := &events.TradingSignal{Timestamp: time.Now(), Symbol: "BTC", SignalType: "volume_spike", Direction: "bullish"}
+	processor.SetNotifier(&capturingNotifier{})
+	processor.ProcessSignal(btc)
+	processor.ProcessSignal(btc)
+	if err := processor.FlushDigest(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	processor.SetNotifier(&failingNotifier{})
+	processor.ProcessSignal(&events.TradingSignal{Timestamp: time.Now(), Symbol: "ETH", SignalType: "volume_spike", Direction: "bullish"})
+	processor.FlushDigest()
+
+	expected := []struct{ symbol, outcome string }{
+		{"BTC", history.OutcomeSent},
+		{"BTC", history.OutcomeRateLimited},
+		{"ETH", history.OutcomeFailed},
+	}
+	if len(recorder.entries) != len(expected) {
+		t.Fatalf("expected %d history entries, got %d", len(expected), len(recorder.entries))
+	}
+	for i, want := range expected {
+		entry := recorder.entries[i]
+		if entry.Signal.Symbol != want.symbol || entry.Outcome != want.outcome || entry.PendingDigest {
+			t.Errorf("entry %d: expected %s %s and settled, got %s %s (pending: %v)",
+				i, want.symbol, want.outcome, entry.Signal.Symbol, entry.Outcome, entry.PendingDigest)
+		}
+	}
+	if recorder.entries[2].Error == "" {
+		t.Error("expected the digest's delivery error to be recorded")
+	}
+}
@@ -1,7 +1,7 @@
 package alerts
 
 import (
-	"alert-service/internal/kafka"
+	"crypto-trackers/pkg/events"
 	"testing"
 	"time"
 
@@ -25,7 +25,7 @@ func TestAlertProcessor_EndToEndIntegration(t *testing.T) {
 	processor := NewAlertProcessor(5, *alertsReceived, *alertsSent, *alertsRateLimited)
 
 	t.Run("rate limiting integration", func(t *testing.T) {
-		signal := &kafka.TradingSignal{
+		signal := &events.TradingSignal{
 			Timestamp:      time.Now(),
 			Symbol:         "BTC",
 			SignalType:     "moving_average_crossover",
@@ -50,7 +50,7 @@ func TestAlertProcessor_EndToEndIntegration(t *testing.T) {
 		symbols := []string{"BTC", "ETH", "ADA", "SOL"}
 
 		for _, symbol := range symbols {
-			signal := &kafka.TradingSignal{
+			signal := &events.TradingSignal{
 				Timestamp:      time.Now(),
 				Symbol:         symbol,
 				SignalType:     "volume_spike",
@@ -79,7 +79,7 @@ func TestAlertProcessor_EndToEndIntegration(t *testing.T) {
 		}
 
 		for i, st := range signalTypes {
-			signal := &kafka.TradingSignal{
+			signal := &events.TradingSignal{
 				Timestamp:      time.Now(),
 				Symbol:         "TEST",
 				SignalType:     st.signalType,
@@ -99,7 +99,7 @@ func TestAlertProcessor_EndToEndIntegration(t *testing.T) {
 	t.Run("cooldown period verification", func(t *testing.T) {
 		shortProcessor := NewAlertProcessor(0, *alertsReceived, *alertsSent, *alertsRateLimited)
 
-		signal := &kafka.TradingSignal{
+		signal := &events.TradingSignal{
 			Timestamp:      time.Now(),
 			Symbol:         "COOLDOWN",
 			SignalType:     "test",
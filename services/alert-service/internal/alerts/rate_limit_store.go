@@ -0,0 +1,131 @@
+package alerts
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is the rate limit that applies to one key at the time of a call.
+type Limit struct {
+	Mode     string
+	Window   time.Duration
+	Capacity int
+}
+
+// RateLimitStore holds the per-key state behind a RateLimiter. Acquire must
+// check and record in one atomic step so that concurrent callers, including
+// other replicas sharing the store, cannot both pass the check.
+type RateLimitStore interface {
+	Acquire(ctx context.Context, key string, limit Limit, now time.Time) (bool, error)
+	Release(ctx context.Context, key string, limit Limit, now time.Time) error
+	Allowed(ctx context.Context, key string, limit Limit, now time.Time) (bool, error)
+	Record(ctx context.Context, key string, limit Limit, now time.Time) error
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// memoryStore keeps state in the process. It is the default and only limits
+// alerts within a single replica.
+type memoryStore struct {
+	lastAlertTime map[string]time.Time
+	buckets       map[string]bucket
+	mutex         sync.Mutex
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		lastAlertTime: make(map[string]time.Time),
+		buckets:       make(map[string]bucket),
+	}
+}
+
+func (m *memoryStore) Acquire(_ context.Context, key string, limit Limit, now time.Time) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.allowed(key, limit, now) {
+		return false, nil
+	}
+	m.record(key, limit, now)
+	return true, nil
+}
+
+// Release in cooldown mode forgets the key: the previous alert had already
+// expired, since the acquire succeeded, so this restores the earlier state.
+func (m *memoryStore) Release(_ context.Context, key string, limit Limit, now time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if limit.Mode == RateLimitModeTokenBucket {
+		m.buckets[key] = bucket{
+			tokens:  math.Min(m.tokens(key, limit, now)+1, float64(limit.Capacity)),
+			updated: now,
+		}
+		return nil
+	}
+
+	delete(m.lastAlertTime, key)
+	return nil
+}
+
+func (m *memoryStore) Allowed(_ context.Context, key string, limit Limit, now time.Time) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.allowed(key, limit, now), nil
+}
+
+func (m *memoryStore) Record(_ context.Context, key string, limit Limit, now time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.record(key, limit, now)
+	return nil
+}
+
+func (m *memoryStore) allowed(key string, limit Limit, now time.Time) bool {
+	if limit.Mode == RateLimitModeTokenBucket {
+		return m.tokens(key, limit, now) >= 1
+	}
+
+	lastTime, exists := m.lastAlertTime[key]
+	if !exists {
+		return true
+	}
+
+	return now.Sub(lastTime) >= limit.Window
+}
+
+func (m *memoryStore) record(key string, limit Limit, now time.Time) {
+	if limit.Mode == RateLimitModeTokenBucket {
+		m.buckets[key] = bucket{
+			tokens:  math.Max(m.tokens(key, limit, now)-1, 0),
+			updated: now,
+		}
+		return
+	}
+
+	m.lastAlertTime[key] = now
+}
+
+// tokens returns the bucket for key refilled up to now. Buckets start full
+// and refill at Capacity per Window; a zero window never limits.
+func (m *memoryStore) tokens(key string, limit Limit, now time.Time) float64 {
+	capacity := float64(limit.Capacity)
+	if limit.Window <= 0 {
+		return capacity
+	}
+
+	b, exists := m.buckets[key]
+	if !exists {
+		return capacity
+	}
+
+	refill := now.Sub(b.updated).Seconds() / limit.Window.Seconds() * capacity
+	return math.Min(b.tokens+refill, capacity)
+}
@@ -1,38 +1,244 @@
 package alerts
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
 	"sync"
 	"time"
+
+	"crypto-trackers/pkg/events"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	RateLimitModeCooldown    = "cooldown"
+	RateLimitModeTokenBucket = "token_bucket"
+
+	RateLimitKeySymbol           = "symbol"
+	RateLimitKeySymbolSignalType = "symbol_signal_type"
+	RateLimitKeySymbolDirection  = "symbol_direction"
+
+	DefaultMaxAlertsPerWindow = 3
+
+	// keySeparator splits the symbol from the rest of a rate-limit key.
+	// Symbols are tickers, so it never appears inside one.
+	keySeparator = "/"
+
+	storeTimeout = 2 * time.Second
 )
 
+// RateLimitSettings picks how alerts are limited. In cooldown mode one alert
+// per key is allowed per cooldown; in token-bucket mode MaxAlerts are allowed
+// per cooldown, refilling gradually.
+type RateLimitSettings struct {
+	Mode      string
+	Key       string
+	MaxAlerts int
+}
+
+func NewRateLimitSettings(mode, key string, maxAlerts int) (RateLimitSettings, error) {
+	mode = strings.ToLower(mode)
+	key = strings.ToLower(key)
+
+	switch mode {
+	case RateLimitModeCooldown, RateLimitModeTokenBucket:
+	default:
+		return RateLimitSettings{}, fmt.Errorf("unsupported rate limit mode %q (expected %s or %s)",
+			mode, RateLimitModeCooldown, RateLimitModeTokenBucket)
+	}
+
+	switch key {
+	case RateLimitKeySymbol, RateLimitKeySymbolSignalType, RateLimitKeySymbolDirection:
+	default:
+		return RateLimitSettings{}, fmt.Errorf("unsupported rate limit key %q (expected %s, %s or %s)",
+			key, RateLimitKeySymbol, RateLimitKeySymbolSignalType, RateLimitKeySymbolDirection)
+	}
+
+	if mode == RateLimitModeTokenBucket && maxAlerts < 1 {
+		return RateLimitSettings{}, fmt.Errorf("token bucket must allow at least 1 alert per window, got %d", maxAlerts)
+	}
+
+	return RateLimitSettings{Mode: mode, Key: key, MaxAlerts: maxAlerts}, nil
+}
+
+func DefaultRateLimitSettings() RateLimitSettings {
+	return RateLimitSettings{
+		Mode:      RateLimitModeCooldown,
+		Key:       RateLimitKeySymbol,
+		MaxAlerts: DefaultMaxAlertsPerWindow,
+	}
+}
+
 type RateLimiter struct {
-	lastAlertTime map[string]time.Time
-	cooldown      time.Duration
-	mutex         sync.RWMutex
+	settings        RateLimitSettings
+	store           RateLimitStore
+	storeErrors     prometheus.CounterVec
+	cooldown        time.Duration
+	symbolCooldowns map[string]time.Duration
+	mutex           sync.RWMutex
 }
 
 func NewRateLimiter(cooldownMinutes int) *RateLimiter {
 	return &RateLimiter{
-		lastAlertTime: make(map[string]time.Time),
-		cooldown:      time.Duration(cooldownMinutes) * time.Minute,
-		mutex:         sync.RWMutex{},
+		settings:        DefaultRateLimitSettings(),
+		store:           newMemoryStore(),
+		cooldown:        time.Duration(cooldownMinutes) * time.Minute,
+		symbolCooldowns: make(map[string]time.Duration),
+		mutex:           sync.RWMutex{},
 	}
 }
 
-func (r *RateLimiter) CanSendAlert(symbol string) bool {
+// Configure switches the mode and key. It is meant to be called before any
+// alerts are recorded.
+func (r *RateLimiter) Configure(settings RateLimitSettings) {
+	r.mutex.Lock()
+	r.settings = settings
+	r.mutex.Unlock()
+}
+
+// SetStore moves rate-limit state out of the process, typically into a
+// RedisStore shared by every replica. Store errors fail open: the alert is
+// allowed and storeErrors is incremented, since a duplicate alert is cheaper
+// than a missed one.
+func (r *RateLimiter) SetStore(store RateLimitStore, storeErrors prometheus.CounterVec) {
+	r.mutex.Lock()
+	r.store = store
+	r.storeErrors = storeErrors
+	r.mutex.Unlock()
+}
+
+// Key returns the rate-limit key for signal under the configured key mode.
+// Confluence signals always get their own key, so the detector alerts that
+// triggered them cannot suppress them.
+func (r *RateLimiter) Key(signal *events.TradingSignal) string {
 	r.mutex.RLock()
-	lastTime, exists := r.lastAlertTime[symbol]
+	keyMode := r.settings.Key
 	r.mutex.RUnlock()
 
-	if !exists {
-		return true
+	if signal.SignalType == events.SignalTypeConfluence {
+		return signal.Symbol + keySeparator + signal.SignalType
 	}
 
-	return time.Since(lastTime) >= r.cooldown
+	switch keyMode {
+	case RateLimitKeySymbolSignalType:
+		return signal.Symbol + keySeparator + signal.SignalType
+	case RateLimitKeySymbolDirection:
+		return signal.Symbol + keySeparator + signal.Direction
+	default:
+		return signal.Symbol
+	}
 }
 
-func (r *RateLimiter) RecordAlert(symbol string) {
+// SetSymbolCooldown overrides the default cooldown for one symbol.
+func (r *RateLimiter) SetSymbolCooldown(symbol string, cooldownMinutes int) {
 	r.mutex.Lock()
-	r.lastAlertTime[symbol] = time.Now()
+	r.symbolCooldowns[symbol] = time.Duration(cooldownMinutes) * time.Minute
 	r.mutex.Unlock()
 }
+
+func (r *RateLimiter) CooldownFor(symbol string) time.Duration {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.cooldownFor(symbol)
+}
+
+func (r *RateLimiter) cooldownFor(symbol string) time.Duration {
+	if cooldown, ok := r.symbolCooldowns[symbol]; ok {
+		return cooldown
+	}
+	return r.cooldown
+}
+
+// Describe summarizes the limit that applies to key, for logging.
+func (r *RateLimiter) Describe(key string) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	cooldown := r.cooldownFor(symbolOf(key))
+	if r.settings.Mode == RateLimitModeTokenBucket {
+		return fmt.Sprintf("%d alerts per %s", r.settings.MaxAlerts, cooldown)
+	}
+	return fmt.Sprintf("%s cooldown", cooldown)
+}
+
+// TryAcquire checks and records an alert for key in one atomic step, so
+// concurrent callers cannot both pass the check. It reports whether the
+// alert may be sent.
+func (r *RateLimiter) TryAcquire(key string) bool {
+	store, limit := r.limitFor(key)
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	allowed, err := store.Acquire(ctx, key, limit, time.Now())
+	if err != nil {
+		r.storeFailed("acquire", key, err)
+		return true
+	}
+	return allowed
+}
+
+// Release gives back an acquisition whose alert was never delivered.
+func (r *RateLimiter) Release(key string) {
+	store, limit := r.limitFor(key)
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	if err := store.Release(ctx, key, limit, time.Now()); err != nil {
+		r.storeFailed("release", key, err)
+	}
+}
+
+// CanSendAlert reports whether an alert for key is allowed now. Keys come
+// from Key; a bare symbol is a valid key. Use TryAcquire when the alert is
+// going to be sent, as checking and recording separately races.
+func (r *RateLimiter) CanSendAlert(key string) bool {
+	store, limit := r.limitFor(key)
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	allowed, err := store.Allowed(ctx, key, limit, time.Now())
+	if err != nil {
+		r.storeFailed("check", key, err)
+		return true
+	}
+	return allowed
+}
+
+func (r *RateLimiter) RecordAlert(key string) {
+	store, limit := r.limitFor(key)
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	if err := store.Record(ctx, key, limit, time.Now()); err != nil {
+		r.storeFailed("record", key, err)
+	}
+}
+
+func (r *RateLimiter) limitFor(key string) (RateLimitStore, Limit) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.store, Limit{
+		Mode:     r.settings.Mode,
+		Window:   r.cooldownFor(symbolOf(key)),
+		Capacity: r.settings.MaxAlerts,
+	}
+}
+
+func (r *RateLimiter) storeFailed(operation, key string, err error) {
+	r.mutex.RLock()
+	storeErrors := r.storeErrors
+	r.mutex.RUnlock()
+
+	storeErrors.WithLabelValues(operation).Inc()
+	log.Printf("Rate limit store %s failed for %s, allowing alert: %v", operation, key, err)
+}
+
+func symbolOf(key string) string {
+	symbol, _, _ := strings.Cut(key, keySeparator)
+	return symbol
+}
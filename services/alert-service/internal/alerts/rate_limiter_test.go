@@ -1,8 +1,12 @@
 package alerts
 
 import (
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"crypto-trackers/pkg/events"
 )
 
 func TestRateLimiter_CanSendAlert(t *testing.T) {
@@ -59,3 +63,228 @@ func TestRateLimiter_ConcurrentAccess(t *testing.T) {
 		t.Error("concurrent access should not affect different symbols")
 	}
 }
+
+func TestRateLimiter_SymbolCooldown(t *testing.T) {
+	limiter := NewRateLimiter(5)
+	limiter.SetSymbolCooldown("PEPE", 0)
+
+	if cooldown := limiter.CooldownFor("BTC"); cooldown != 5*time.Minute {
+		t.Errorf("expected default cooldown 5m for BTC, got %s", cooldown)
+	}
+	if cooldown := limiter.CooldownFor("PEPE"); cooldown != 0 {
+		t.Errorf("expected override cooldown 0 for PEPE, got %s", cooldown)
+	}
+
+	limiter.RecordAlert("BTC")
+	limiter.RecordAlert("PEPE")
+	time.Sleep(1 * time.Millisecond)
+
+	if limiter.CanSendAlert("BTC") {
+		t.Error("expected BTC to stay in its default cooldown")
+	}
+	if !limiter.CanSendAlert("PEPE") {
+		t.Error("expected PEPE to be allowed after its shorter cooldown")
+	}
+}
+
+func TestNewRateLimitSettings(t *testing.T) {
+	if _, err := NewRateLimitSettings("Token_Bucket", "SYMBOL_DIRECTION", 3); err != nil {
+		t.Errorf("expected mode and key to be case-insensitive, got %v", err)
+	}
+
+	invalid := []struct {
+		name      string
+		mode      string
+		key       string
+		maxAlerts int
+	}{
+		{"unknown mode", "sliding_window", RateLimitKeySymbol, 3},
+		{"unknown key", RateLimitModeCooldown, "signal_type", 3},
+		{"empty bucket", RateLimitModeTokenBucket, RateLimitKeySymbol, 0},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRateLimitSettings(tt.mode, tt.key, tt.maxAlerts); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Key(t *testing.T) {
+	spike := &events.TradingSignal{Symbol: "BTC", SignalType: "volume_spike", Direction: "bullish"}
+	cross := &events.TradingSignal{Symbol: "BTC", SignalType: "moving_average_crossover", Direction: "bullish"}
+
+	tests := []struct {
+		key         string
+		expected    string
+		independent bool
+	}{
+		{RateLimitKeySymbol, "BTC", false},
+		{RateLimitKeySymbolSignalType, "BTC/volume_spike", true},
+		{RateLimitKeySymbolDirection, "BTC/bullish", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			settings, err := NewRateLimitSettings(RateLimitModeCooldown, tt.key, 1)
+			if err != nil {
+				t.Fatalf("failed to create settings: %v", err)
+			}
+			limiter := NewRateLimiter(5)
+			limiter.Configure(settings)
+
+			if key := limiter.Key(spike); key != tt.expected {
+				t.Errorf("expected key %s, got %s", tt.expected, key)
+			}
+
+			limiter.RecordAlert(limiter.Key(spike))
+			if allowed := limiter.CanSendAlert(limiter.Key(cross)); allowed != tt.independent {
+				t.Errorf("expected golden cross after volume spike allowed=%v, got %v", tt.independent, allowed)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_ConfluenceKey(t *testing.T) {
+	limiter := NewRateLimiter(5)
+	spike := &events.TradingSignal{Symbol: "BTC", SignalType: "volume_spike", Direction: "bullish"}
+	composite := &events.TradingSignal{Symbol: "BTC", SignalType: events.SignalTypeConfluence, Direction: "bullish"}
+
+	if key := limiter.Key(composite); key != "BTC/confluence" {
+		t.Errorf("expected confluence key BTC/confluence, got %s", key)
+	}
+
+	limiter.RecordAlert(limiter.Key(spike))
+	if !limiter.CanSendAlert(limiter.Key(composite)) {
+		t.Error("expected confluence signal to be allowed after the volume spike that triggered it")
+	}
+}
+
+func TestRateLimiter_KeyUsesSymbolCooldown(t *testing.T) {
+	settings, _ := NewRateLimitSettings(RateLimitModeCooldown, RateLimitKeySymbolSignalType, 1)
+	limiter := NewRateLimiter(5)
+	limiter.Configure(settings)
+	limiter.SetSymbolCooldown("PEPE", 0)
+
+	limiter.RecordAlert("PEPE/volume_spike")
+	time.Sleep(1 * time.Millisecond)
+
+	if !limiter.CanSendAlert("PEPE/volume_spike") {
+		t.Error("expected PEPE's cooldown override to apply to its composite keys")
+	}
+	if description := limiter.Describe("BTC/volume_spike"); description != "5m0s cooldown" {
+		t.Errorf("expected the default cooldown to be described, got %q", description)
+	}
+}
+
+func TestRateLimiter_TokenBucket(t *testing.T) {
+	settings, _ := NewRateLimitSettings(RateLimitModeTokenBucket, RateLimitKeySymbol, 3)
+	limiter := NewRateLimiter(60)
+	limiter.Configure(settings)
+
+	for i := 0; i < 3; i++ {
+		if !limiter.CanSendAlert("BTC") {
+			t.Fatalf("expected alert %d of 3 to be allowed", i+1)
+		}
+		limiter.RecordAlert("BTC")
+	}
+
+	if limiter.CanSendAlert("BTC") {
+		t.Error("expected the fourth alert within the window to be blocked")
+	}
+	if !limiter.CanSendAlert("ETH") {
+		t.Error("expected a separate bucket for ETH")
+	}
+
+	// A third of the window refills one token
+	store := limiter.store.(*memoryStore)
+	store.mutex.Lock()
+	b := store.buckets["BTC"]
+	b.updated = b.updated.Add(-20 * time.Minute)
+	store.buckets["BTC"] = b
+	store.mutex.Unlock()
+
+	if !limiter.CanSendAlert("BTC") {
+		t.Error("expected a token to refill after a third of the window")
+	}
+	limiter.RecordAlert("BTC")
+	if limiter.CanSendAlert("BTC") {
+		t.Error("expected the refilled token to be used up")
+	}
+
+	if description := limiter.Describe("BTC"); description != "3 alerts per 1h0m0s" {
+		t.Errorf("expected bucket description, got %q", description)
+	}
+}
+
+func TestRateLimiter_TryAcquireUnderContention(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings RateLimitSettings
+		winners  int64
+	}{
+		{"cooldown", RateLimitSettings{Mode: RateLimitModeCooldown, Key: RateLimitKeySymbol}, 1},
+		{"token bucket", RateLimitSettings{Mode: RateLimitModeTokenBucket, Key: RateLimitKeySymbol, MaxAlerts: 3}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(5)
+			limiter.Configure(tt.settings)
+
+			const goroutines = 100
+			var winners atomic.Int64
+			var ready, done sync.WaitGroup
+			start := make(chan struct{})
+
+			ready.Add(goroutines)
+			done.Add(goroutines)
+			for i := 0; i < goroutines; i++ {
+				go func() {
+					defer done.Done()
+					ready.Done()
+					<-start
+					if limiter.TryAcquire("BTC") {
+						winners.Add(1)
+					}
+				}()
+			}
+
+			ready.Wait()
+			close(start)
+			done.Wait()
+
+			if got := winners.Load(); got != tt.winners {
+				t.Errorf("expected exactly %d winners, got %d", tt.winners, got)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Release(t *testing.T) {
+	limiter := NewRateLimiter(5)
+
+	if !limiter.TryAcquire("BTC") {
+		t.Fatal("expected first acquire to succeed")
+	}
+	if limiter.TryAcquire("BTC") {
+		t.Fatal("expected second acquire to be blocked")
+	}
+
+	limiter.Release("BTC")
+	if !limiter.TryAcquire("BTC") {
+		t.Error("expected a released acquisition to free the key")
+	}
+
+	settings, _ := NewRateLimitSettings(RateLimitModeTokenBucket, RateLimitKeySymbol, 1)
+	bucketLimiter := NewRateLimiter(60)
+	bucketLimiter.Configure(settings)
+
+	bucketLimiter.TryAcquire("ETH")
+	bucketLimiter.Release("ETH")
+	bucketLimiter.Release("ETH")
+	if !bucketLimiter.TryAcquire("ETH") || bucketLimiter.TryAcquire("ETH") {
+		t.Error("expected releases to return the token without exceeding capacity")
+	}
+}
@@ -0,0 +1,135 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const DefaultRedisKeyPrefix = "alert-service:ratelimit:"
+
+// bucketScript applies one operation to a token bucket stored as a hash of
+// tokens and updated (milliseconds). Buckets start full and refill at
+// capacity per window, matching memoryStore. It returns 1 when the
+// operation was allowed. The hash expires once it would have refilled.
+//
+// ARGV: now_ms, window_ms, capacity, op (acquire, release, check, record)
+var bucketScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local capacity = tonumber(ARGV[3])
+local op = ARGV[4]
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = capacity
+if state[1] then
+  local elapsed = math.max(now - tonumber(state[2]), 0)
+  tokens = math.min(tonumber(state[1]) + elapsed / window * capacity, capacity)
+end
+
+if op == 'check' then
+  if tokens >= 1 then return 1 end
+  return 0
+end
+
+if op == 'acquire' then
+  if tokens < 1 then return 0 end
+  tokens = tokens - 1
+elseif op == 'record' then
+  tokens = math.max(tokens - 1, 0)
+elseif op == 'release' then
+  tokens = math.min(tokens + 1, capacity)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], window)
+return 1
+`)
+
+// RedisStore shares rate-limit state between replicas through any server
+// speaking the Redis protocol. Cooldowns are keys set with NX and a TTL of
+// the cooldown, so a later change to a symbol's cooldown only applies to
+// alerts recorded after it; token buckets are updated by a Lua script.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = DefaultRedisKeyPrefix
+	}
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Ping checks the server is reachable, for use at startup.
+func (s *RedisStore) Ping(ctx context.Context) error {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to reach rate limit store: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *RedisStore) Acquire(ctx context.Context, key string, limit Limit, now time.Time) (bool, error) {
+	if limit.Window <= 0 {
+		return true, nil
+	}
+	if limit.Mode == RateLimitModeTokenBucket {
+		return s.bucket(ctx, key, limit, now, "acquire")
+	}
+	return s.client.SetNX(ctx, s.redisKey(key, limit), now.UnixMilli(), limit.Window).Result()
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string, limit Limit, now time.Time) error {
+	if limit.Window <= 0 {
+		return nil
+	}
+	if limit.Mode == RateLimitModeTokenBucket {
+		_, err := s.bucket(ctx, key, limit, now, "release")
+		return err
+	}
+	return s.client.Del(ctx, s.redisKey(key, limit)).Err()
+}
+
+func (s *RedisStore) Allowed(ctx context.Context, key string, limit Limit, now time.Time) (bool, error) {
+	if limit.Window <= 0 {
+		return true, nil
+	}
+	if limit.Mode == RateLimitModeTokenBucket {
+		return s.bucket(ctx, key, limit, now, "check")
+	}
+	exists, err := s.client.Exists(ctx, s.redisKey(key, limit)).Result()
+	return exists == 0, err
+}
+
+func (s *RedisStore) Record(ctx context.Context, key string, limit Limit, now time.Time) error {
+	if limit.Window <= 0 {
+		return nil
+	}
+	if limit.Mode == RateLimitModeTokenBucket {
+		_, err := s.bucket(ctx, key, limit, now, "record")
+		return err
+	}
+	return s.client.Set(ctx, s.redisKey(key, limit), now.UnixMilli(), limit.Window).Err()
+}
+
+func (s *RedisStore) bucket(ctx context.Context, key string, limit Limit, now time.Time, op string) (bool, error) {
+	allowed, err := bucketScript.Run(ctx, s.client, []string{s.redisKey(key, limit)},
+		now.UnixMilli(), limit.Window.Milliseconds(), limit.Capacity, op).Int()
+	if err != nil {
+		return false, err
+	}
+	return allowed == 1, nil
+}
+
+// redisKey includes the mode so that switching modes never reads a cooldown
+// key as a bucket hash or the other way round.
+func (s *RedisStore) redisKey(key string, limit Limit) string {
+	return s.prefix + limit.Mode + ":" + key
+}
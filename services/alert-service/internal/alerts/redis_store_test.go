@@ -0,0 +1,140 @@
+package alerts
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisStore(t *testing.T) (*miniredis.Miniredis, *RedisStore) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	store := NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1}), "")
+	t.Cleanup(func() { store.Close() })
+	return server, store
+}
+
+func newTestStoreErrors() prometheus.CounterVec {
+	return *prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_rate_limit_store_errors"}, []string{"operation"})
+}
+
+// Two limiters sharing a store stand in for two replicas of the service.
+func TestRedisStore_CooldownAcrossReplicas(t *testing.T) {
+	server, store := newTestRedisStore(t)
+
+	replicaA, replicaB := NewRateLimiter(5), NewRateLimiter(5)
+	replicaA.SetStore(store, newTestStoreErrors())
+	replicaB.SetStore(store, newTestStoreErrors())
+
+	if !replicaA.TryAcquire("BTC") {
+		t.Fatal("expected the first alert to be allowed")
+	}
+	if replicaB.TryAcquire("BTC") {
+		t.Error("expected the other replica to see the cooldown")
+	}
+	if !replicaB.CanSendAlert("ETH") {
+		t.Error("expected ETH to be independent of BTC")
+	}
+
+	server.FastForward(5 * time.Minute)
+	if !replicaB.TryAcquire("BTC") {
+		t.Error("expected the cooldown to expire")
+	}
+
+	replicaB.Release("BTC")
+	if !replicaA.CanSendAlert("BTC") {
+		t.Error("expected a release to clear the cooldown for every replica")
+	}
+}
+
+func TestRedisStore_TokenBucket(t *testing.T) {
+	_, store := newTestRedisStore(t)
+	ctx := context.Background()
+	limit := Limit{Mode: RateLimitModeTokenBucket, Window: time.Hour, Capacity: 3}
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		allowed, err := store.Acquire(ctx, "BTC", limit, now)
+		if err != nil || !allowed {
+			t.Fatalf("expected alert %d of 3 to be allowed, got %v (%v)", i+1, allowed, err)
+		}
+	}
+	if allowed, _ := store.Acquire(ctx, "BTC", limit, now); allowed {
+		t.Error("expected the fourth alert within the window to be blocked")
+	}
+
+	// A third of the window refills one token
+	later := now.Add(20 * time.Minute)
+	if allowed, _ := store.Allowed(ctx, "BTC", limit, later); !allowed {
+		t.Error("expected a token to refill after a third of the window")
+	}
+	if err := store.Record(ctx, "BTC", limit, later); err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+	if allowed, _ := store.Allowed(ctx, "BTC", limit, later); allowed {
+		t.Error("expected the refilled token to be used up")
+	}
+
+	store.Release(ctx, "BTC", limit, later)
+	store.Release(ctx, "BTC", limit, later)
+	store.Release(ctx, "BTC", limit, later)
+	store.Release(ctx, "BTC", limit, later)
+	for i := 0; i < 3; i++ {
+		store.Acquire(ctx, "BTC", limit, later)
+	}
+	if allowed, _ := store.Acquire(ctx, "BTC", limit, later); allowed {
+		t.Error("expected releases not to exceed capacity")
+	}
+}
+
+func TestRedisStore_TryAcquireUnderContention(t *testing.T) {
+	_, store := newTestRedisStore(t)
+
+	replicas := make([]*RateLimiter, 4)
+	for i := range replicas {
+		replicas[i] = NewRateLimiter(5)
+		replicas[i].SetStore(store, newTestStoreErrors())
+	}
+
+	const goroutines = 100
+	var winners atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func(limiter *RateLimiter) {
+			defer wg.Done()
+			if limiter.TryAcquire("BTC") {
+				winners.Add(1)
+			}
+		}(replicas[i%len(replicas)])
+	}
+	wg.Wait()
+
+	if got := winners.Load(); got != 1 {
+		t.Errorf("expected exactly 1 winner across replicas, got %d", got)
+	}
+}
+
+func TestRedisStore_FailsOpen(t *testing.T) {
+	server, store := newTestRedisStore(t)
+	storeErrors := newTestStoreErrors()
+
+	limiter := NewRateLimiter(5)
+	limiter.SetStore(store, storeErrors)
+	server.Close()
+
+	if !limiter.TryAcquire("BTC") || !limiter.TryAcquire("BTC") {
+		t.Error("expected alerts to be allowed while the store is unreachable")
+	}
+	if got := testutil.ToFloat64(storeErrors.WithLabelValues("acquire")); got != 2 {
+		t.Errorf("expected 2 acquire errors, got %v", got)
+	}
+}
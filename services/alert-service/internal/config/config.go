@@ -6,20 +6,107 @@ import (
 )
 
 type Config struct {
-	KafkaBootstrapServers string
-	KafkaGroupID          string
-	Port                  string
-	LogLevel              string
-	CooldownMinutes       int
+	KafkaBootstrapServers  string
+	KafkaGroupID           string
+	SchemaRegistryURL      string
+	ConsumerMaxRetries     int
+	ConsumerRetryBackoff   int
+	ConsumerDLQTopic       string
+	ConsumerRetryTopic     string
+	ConsumerPoisonDLQ      bool
+	Port                   string
+	LogLevel               string
+	CooldownMinutes        int
+	RateLimitMode          string
+	RateLimitKey           string
+	RateLimitMaxAlerts     int
+	RateLimitStore         string
+	RedisAddr              string
+	RedisPassword          string
+	RedisDB                int
+	RedisKeyPrefix         string
+	DigestWindowSeconds    int
+	ConfluenceWindowMins   int
+	ConfluenceMinDetectors int
+	SymbolOverridesPath    string
+	NotifyChannels         string
+	NotifyTimeoutSeconds   int
+	AlertFormat            string
+	AlertFormats           string
+	AlertTemplateDir       string
+	NotifyMaxAttempts      int
+	NotifyInitialBackoffMs int
+	DeadLetterTopic        string
+	RoutingRulesPath       string
+	HistoryPath            string
+	HistoryRetentionHours  int
+	RoutingReloadSeconds   int
+	WebhookURL             string
+	SlackWebhookURL        string
+	SMTPHost               string
+	SMTPPort               int
+	SMTPUsername           string
+	SMTPPassword           string
+	SMTPFrom               string
+	SMTPTo                 string
+	TelegramAPIURL         string
+	TelegramBotToken       string
+	TelegramChatID         string
+}
+
+// SymbolOverride sets a symbol's alert cooldown.
+type SymbolOverride struct {
+	CooldownMinutes *int `yaml:"cooldown_minutes" json:"cooldown_minutes,omitempty"`
 }
 
 func New() *Config {
 	return &Config{
-		KafkaBootstrapServers: getEnv("KAFKA_BOOTSTRAP_SERVERS", "kafka-service:9092"),
-		KafkaGroupID:          getEnv("KAFKA_GROUP_ID", "alert-service"),
-		Port:                  getEnv("PORT", "8080"),
-		LogLevel:              getEnv("LOG_LEVEL", "INFO"),
-		CooldownMinutes:       getEnvInt("COOLDOWN_MINUTES", 5),
+		KafkaBootstrapServers:  getEnv("KAFKA_BOOTSTRAP_SERVERS", "kafka-service:9092"),
+		KafkaGroupID:           getEnv("KAFKA_GROUP_ID", "alert-service"),
+		SchemaRegistryURL:      getEnv("SCHEMA_REGISTRY_URL", ""),
+		ConsumerMaxRetries:     getEnvInt("CONSUMER_MAX_RETRIES", 0),
+		ConsumerRetryBackoff:   getEnvInt("CONSUMER_RETRY_BACKOFF_MS", 500),
+		ConsumerDLQTopic:       getEnv("CONSUMER_DLQ_TOPIC", "alert-service.dlq"),
+		ConsumerRetryTopic:     getEnv("CONSUMER_RETRY_TOPIC", "alert-service.retry"),
+		ConsumerPoisonDLQ:      getEnvBool("CONSUMER_POISON_DLQ_ENABLED", true),
+		Port:                   getEnv("PORT", "8080"),
+		LogLevel:               getEnv("LOG_LEVEL", "INFO"),
+		CooldownMinutes:        getEnvInt("COOLDOWN_MINUTES", 5),
+		RateLimitMode:          getEnv("RATE_LIMIT_MODE", "cooldown"),
+		RateLimitKey:           getEnv("RATE_LIMIT_KEY", "symbol"),
+		RateLimitMaxAlerts:     getEnvInt("RATE_LIMIT_MAX_ALERTS", 3),
+		RateLimitStore:         getEnv("RATE_LIMIT_STORE", "memory"),
+		RedisAddr:              getEnv("REDIS_ADDR", ""),
+		RedisPassword:          getEnv("REDIS_PASSWORD", ""),
+		RedisDB:                getEnvInt("REDIS_DB", 0),
+		RedisKeyPrefix:         getEnv("REDIS_KEY_PREFIX", "alert-service:ratelimit:"),
+		DigestWindowSeconds:    getEnvInt("DIGEST_WINDOW_SECONDS", 0),
+		ConfluenceWindowMins:   getEnvInt("CONFLUENCE_WINDOW_MINUTES", 0),
+		ConfluenceMinDetectors: getEnvInt("CONFLUENCE_MIN_DETECTORS", 2),
+		SymbolOverridesPath:    getEnv("SYMBOL_OVERRIDES_PATH", ""),
+		NotifyChannels:         getEnv("NOTIFY_CHANNELS", "console"),
+		NotifyTimeoutSeconds:   getEnvInt("NOTIFY_TIMEOUT_SECONDS", 10),
+		AlertFormat:            getEnv("ALERT_FORMAT", "plain"),
+		AlertFormats:           getEnv("ALERT_FORMATS", ""),
+		AlertTemplateDir:       getEnv("ALERT_TEMPLATE_DIR", ""),
+		NotifyMaxAttempts:      getEnvInt("NOTIFY_MAX_ATTEMPTS", 3),
+		NotifyInitialBackoffMs: getEnvInt("NOTIFY_INITIAL_BACKOFF_MS", 500),
+		DeadLetterTopic:        getEnv("DLQ_TOPIC", "alerts-dlq"),
+		RoutingRulesPath:       getEnv("ROUTING_RULES_PATH", ""),
+		HistoryPath:            getEnv("HISTORY_PATH", ""),
+		HistoryRetentionHours:  getEnvInt("HISTORY_RETENTION_HOURS", 168),
+		RoutingReloadSeconds:   getEnvInt("ROUTING_RELOAD_SECONDS", 30),
+		WebhookURL:             getEnv("WEBHOOK_URL", ""),
+		SlackWebhookURL:        getEnv("SLACK_WEBHOOK_URL", ""),
+		SMTPHost:               getEnv("SMTP_HOST", ""),
+		SMTPPort:               getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:           getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:           getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:               getEnv("SMTP_FROM", ""),
+		SMTPTo:                 getEnv("SMTP_TO", ""),
+		TelegramAPIURL:         getEnv("TELEGRAM_API_URL", "https://api.telegram.org"),
+		TelegramBotToken:       getEnv("TELEGRAM_BOT_TOKEN", ""),
+		TelegramChatID:         getEnv("TELEGRAM_CHAT_ID", ""),
 	}
 }
 
@@ -38,3 +125,12 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
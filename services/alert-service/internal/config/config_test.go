@@ -0,0 +1,21 @@
+package config
+
+import (
+	"testing"
+
+	"crypto-trackers/pkg/overrides"
+)
+
+func TestSymbolOverride(t *testing.T) {
+	symbols, err := overrides.Parse[SymbolOverride]([]byte("symbols:\n  btc:\n    cooldown_minutes: 15\n  ETH: {}\n"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if btc := symbols["BTC"]; btc.CooldownMinutes == nil || *btc.CooldownMinutes != 15 {
+		t.Errorf("expected BTC cooldown 15, got %+v", btc)
+	}
+	if eth := symbols["ETH"]; eth.CooldownMinutes != nil {
+		t.Errorf("expected unset ETH cooldown, got %d", *eth.CooldownMinutes)
+	}
+}
@@ -0,0 +1,191 @@
+package confluence
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+const (
+	// ServiceID marks composite signals, along with their signal type
+	// events.SignalTypeConfluence. The correlator never correlates its own
+	// output.
+	ServiceID = "confluence"
+
+	DefaultMinDetectors = 2
+)
+
+var strengthWeights = map[string]float64{
+	"weak":   1,
+	"medium": 2,
+	"strong": 3,
+}
+
+// Correlator watches signals per symbol and emits a confluence signal when
+// enough distinct detectors (by ServiceID) signal the same symbol within the
+// window. Only the latest signal from each detector counts.
+type Correlator struct {
+	window       time.Duration
+	minDetectors int
+
+	mutex   sync.Mutex
+	symbols map[string]map[string]*events.TradingSignal
+}
+
+func NewCorrelator(window time.Duration, minDetectors int) (*Correlator, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("confluence window must be positive, got %s", window)
+	}
+	if minDetectors < 2 {
+		return nil, fmt.Errorf("confluence needs at least 2 detectors, got %d", minDetectors)
+	}
+
+	return &Correlator{
+		window:       window,
+		minDetectors: minDetectors,
+		symbols:      make(map[string]map[string]*events.TradingSignal),
+	}, nil
+}
+
+// Observe adds signal to its symbol's window and returns a confluence signal
+// when signal brings a new detector into a window that now has at least the
+// minimum number of detectors. Repeats from a detector already in the window
+// refresh it without emitting again.
+func (c *Correlator) Observe(signal *events.TradingSignal) *events.TradingSignal {
+	if signal.SignalType == events.SignalTypeConfluence || signal.ServiceID == "" {
+		return nil
+	}
+
+	at := signal.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	detectors := c.symbols[signal.Symbol]
+	if detectors == nil {
+		detectors = make(map[string]*events.TradingSignal)
+		c.symbols[signal.Symbol] = detectors
+	}
+	c.expire(detectors, at)
+
+	_, seen := detectors[signal.ServiceID]
+	detectors[signal.ServiceID] = signal
+	if seen || len(detectors) < c.minDetectors {
+		return nil
+	}
+
+	composite, err := c.composite(signal.Symbol, at, detectors)
+	if err != nil {
+		log.Printf("Failed to build confluence signal for %s: %v", signal.Symbol, err)
+		return nil
+	}
+	return composite
+}
+
+// Wrap returns a signal handler that passes each signal to next, followed by
+// any confluence signal it completes. Only the original signal's error is
+// returned: a failed composite is logged rather than having the original
+// retried, which would observe it again.
+func (c *Correlator) Wrap(next func(*events.TradingSignal) error) func(*events.TradingSignal) error {
+	return func(signal *events.TradingSignal) error {
+		err := next(signal)
+
+		if composite := c.Observe(signal); composite != nil {
+			log.Printf("CONFLUENCE: %v detectors agree on %s %s (score %.0f)",
+				composite.Details["detector_count"], composite.Symbol, composite.Direction, composite.Details["combined_score"])
+			if compositeErr := next(composite); compositeErr != nil {
+				log.Printf("Failed to process confluence signal for %s: %v", composite.Symbol, compositeErr)
+			}
+		}
+		return err
+	}
+}
+
+func (c *Correlator) expire(detectors map[string]*events.TradingSignal, now time.Time) {
+	cutoff := now.Add(-c.window)
+	for serviceID, signal := range detectors {
+		if signal.Timestamp.Before(cutoff) {
+			delete(detectors, serviceID)
+		}
+	}
+}
+
+// composite scores the window by summing strength weights signed by
+// direction, so agreeing detectors reinforce each other and opposing ones
+// cancel out.
+func (c *Correlator) composite(symbol string, at time.Time, detectors map[string]*events.TradingSignal) (*events.TradingSignal, error) {
+	serviceIDs := make([]string, 0, len(detectors))
+	for serviceID := range detectors {
+		serviceIDs = append(serviceIDs, serviceID)
+	}
+	sort.Strings(serviceIDs)
+
+	score := 0.0
+	signals := make([]events.ConfluenceSignal, 0, len(serviceIDs))
+	for _, serviceID := range serviceIDs {
+		signal := detectors[serviceID]
+		weight := strengthWeights[signal.SignalStrength]
+		switch signal.Direction {
+		case "bullish":
+			score += weight
+		case "bearish":
+			score -= weight
+		}
+
+		signals = append(signals, events.ConfluenceSignal{
+			ServiceID:      signal.ServiceID,
+			SignalType:     signal.SignalType,
+			Direction:      signal.Direction,
+			SignalStrength: signal.SignalStrength,
+			Timestamp:      signal.Timestamp.UTC(),
+		})
+	}
+
+	direction := "neutral"
+	if score > 0 {
+		direction = "bullish"
+	} else if score < 0 {
+		direction = "bearish"
+		score = -score
+	}
+
+	composite := &events.TradingSignal{
+		SchemaVersion:  events.SchemaVersion,
+		Timestamp:      at,
+		Symbol:         symbol,
+		SignalStrength: strengthFor(score),
+		Direction:      direction,
+		ServiceID:      ServiceID,
+	}
+	err := composite.SetDetails(&events.ConfluenceDetails{
+		CombinedScore: score,
+		DetectorCount: len(serviceIDs),
+		Detectors:     serviceIDs,
+		Signals:       signals,
+		WindowMinutes: c.window.Minutes(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return composite, nil
+}
+
+// strengthFor maps a combined score onto the detectors' strength scale: two
+// strong signals in agreement make a strong confluence.
+func strengthFor(score float64) string {
+	switch {
+	case score >= 6:
+		return "strong"
+	case score >= 4:
+		return "medium"
+	default:
+		return "weak"
+	}
+}
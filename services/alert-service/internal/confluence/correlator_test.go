@@ -0,0 +1,211 @@
+package confluence
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+var start = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+func signalFrom(serviceID, signalType, direction, strength string, offset time.Duration) *events.TradingSignal {
+	return &events.TradingSignal{
+		Timestamp:      start.Add(offset),
+		Symbol:         "BTC",
+		SignalType:     signalType,
+		SignalStrength: strength,
+		Direction:      direction,
+		ServiceID:      serviceID,
+	}
+}
+
+func newTestCorrelator(t *testing.T, minDetectors int) *Correlator {
+	t.Helper()
+	correlator, err := NewCorrelator(time.Hour, minDetectors)
+	if err != nil {
+		t.Fatalf("failed to create correlator: %v", err)
+	}
+	return correlator
+}
+
+func decodeConfluence(t *testing.T, composite *events.TradingSignal) *events.ConfluenceDetails {
+	t.Helper()
+	details, err := composite.DecodeDetails()
+	if err != nil {
+		t.Fatalf("failed to decode confluence details: %v", err)
+	}
+	return details.(*events.ConfluenceDetails)
+}
+
+func TestNewCorrelator(t *testing.T) {
+	if _, err := NewCorrelator(0, 2); err == nil {
+		t.Error("expected error for zero window")
+	}
+	if _, err := NewCorrelator(time.Hour, 1); err == nil {
+		t.Error("expected error for fewer than 2 detectors")
+	}
+}
+
+func TestCorrelator_Observe(t *testing.T) {
+	correlator := newTestCorrelator(t, 2)
+
+	if composite := correlator.Observe(signalFrom("ma-signal-detector", "moving_average_crossover", "bullish", "strong", 0)); composite != nil {
+		t.Fatalf("expected no confluence from one detector, got %+v", composite)
+	}
+
+	composite := correlator.Observe(signalFrom("volume-spike-detector", "volume_spike", "bullish", "strong", 30*time.Minute))
+	if composite == nil {
+		t.Fatal("expected confluence from two detectors within the window")
+	}
+
+	if composite.SignalType != events.SignalTypeConfluence || composite.ServiceID != ServiceID {
+		t.Errorf("expected confluence signal type and service, got %s from %s", composite.SignalType, composite.ServiceID)
+	}
+	if composite.Symbol != "BTC" || composite.Direction != "bullish" || composite.SignalStrength != "strong" {
+		t.Errorf("expected strong bullish BTC confluence, got %s %s %s", composite.SignalStrength, composite.Direction, composite.Symbol)
+	}
+	if !composite.Timestamp.Equal(start.Add(30 * time.Minute)) {
+		t.Errorf("expected the completing signal's timestamp, got %s", composite.Timestamp)
+	}
+	if err := composite.Validate(); err != nil {
+		t.Errorf("expected a valid signal, got %v", err)
+	}
+	details := decodeConfluence(t, composite)
+	if details.CombinedScore != 6 {
+		t.Errorf("expected combined score 6, got %v", details.CombinedScore)
+	}
+	if len(details.Detectors) != 2 || details.Detectors[0] != "ma-signal-detector" || details.Detectors[1] != "volume-spike-detector" {
+		t.Errorf("expected both detectors in sorted order, got %v", details.Detectors)
+	}
+	if len(details.Signals) != 2 || details.Signals[1].SignalType != "volume_spike" {
+		t.Errorf("expected contributing signals in details, got %+v", details.Signals)
+	}
+}
+
+func TestCorrelator_ObserveOnlyEmitsForNewDetectors(t *testing.T) {
+	correlator := newTestCorrelator(t, 2)
+	correlator.Observe(signalFrom("ma-signal-detector", "moving_average_crossover", "bullish", "medium", 0))
+	correlator.Observe(signalFrom("volume-spike-detector", "volume_spike", "bullish", "medium", time.Minute))
+
+	if composite := correlator.Observe(signalFrom("volume-spike-detector", "volume_spike", "bullish", "strong", 2*time.Minute)); composite != nil {
+		t.Errorf("expected a repeat from the same detector not to emit, got %+v", composite)
+	}
+
+	composite := correlator.Observe(signalFrom("momentum-detector-v1", "rsi_threshold", "bullish", "weak", 3*time.Minute))
+	if composite == nil {
+		t.Fatal("expected a third detector to emit again")
+	}
+	details := decodeConfluence(t, composite)
+	if details.DetectorCount != 3 {
+		t.Errorf("expected 3 detectors, got %d", details.DetectorCount)
+	}
+	// The repeat replaced the medium volume spike: 2 + 3 + 1
+	if details.CombinedScore != 6 {
+		t.Errorf("expected combined score 6 from the latest signals, got %v", details.CombinedScore)
+	}
+}
+
+func TestCorrelator_ObserveWindow(t *testing.T) {
+	correlator := newTestCorrelator(t, 2)
+	correlator.Observe(signalFrom("ma-signal-detector", "moving_average_crossover", "bullish", "strong", 0))
+
+	if composite := correlator.Observe(signalFrom("volume-spike-detector", "volume_spike", "bullish", "strong", 61*time.Minute)); composite != nil {
+		t.Errorf("expected signals more than the window apart not to correlate, got %+v", composite)
+	}
+
+	other := signalFrom("ma-signal-detector", "moving_average_crossover", "bullish", "strong", 62*time.Minute)
+	other.Symbol = "ETH"
+	if composite := correlator.Observe(other); composite != nil {
+		t.Errorf("expected signals for different symbols not to correlate, got %+v", composite)
+	}
+}
+
+func TestCorrelator_ObserveScoring(t *testing.T) {
+	tests := []struct {
+		name      string
+		first     [2]string
+		second    [2]string
+		direction string
+		strength  string
+		score     float64
+	}{
+		{"agreeing medium", [2]string{"bullish", "medium"}, [2]string{"bullish", "medium"}, "bullish", "medium", 4},
+		{"agreeing bearish", [2]string{"bearish", "strong"}, [2]string{"bearish", "weak"}, "bearish", "medium", 4},
+		{"opposing", [2]string{"bullish", "strong"}, [2]string{"bearish", "medium"}, "bullish", "weak", 1},
+		{"cancelling", [2]string{"bullish", "strong"}, [2]string{"bearish", "strong"}, "neutral", "weak", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			correlator := newTestCorrelator(t, 2)
+			correlator.Observe(signalFrom("ma-signal-detector", "moving_average_crossover", tt.first[0], tt.first[1], 0))
+			composite := correlator.Observe(signalFrom("volume-spike-detector", "volume_spike", tt.second[0], tt.second[1], time.Minute))
+			if composite == nil {
+				t.Fatal("expected confluence")
+			}
+
+			if composite.Direction != tt.direction || composite.SignalStrength != tt.strength {
+				t.Errorf("expected %s %s, got %s %s", tt.strength, tt.direction, composite.SignalStrength, composite.Direction)
+			}
+			if score := decodeConfluence(t, composite).CombinedScore; score != tt.score {
+				t.Errorf("expected combined score %v, got %v", tt.score, score)
+			}
+		})
+	}
+}
+
+func TestCorrelator_ObserveIgnoresConfluence(t *testing.T) {
+	correlator := newTestCorrelator(t, 2)
+	correlator.Observe(signalFrom("ma-signal-detector", "moving_average_crossover", "bullish", "strong", 0))
+
+	if composite := correlator.Observe(signalFrom(ServiceID, events.SignalTypeConfluence, "bullish", "strong", time.Minute)); composite != nil {
+		t.Errorf("expected confluence signals not to correlate with themselves, got %+v", composite)
+	}
+}
+
+func TestCorrelator_Wrap(t *testing.T) {
+	correlator := newTestCorrelator(t, 2)
+
+	var handled []*events.TradingSignal
+	handler := correlator.Wrap(func(signal *events.TradingSignal) error {
+		handled = append(handled, signal)
+		if signal.SignalType == "volume_spike" {
+			return errors.New("delivery failed")
+		}
+		return nil
+	})
+
+	if err := handler(signalFrom("ma-signal-detector", "moving_average_crossover", "bullish", "strong", 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := handler(signalFrom("volume-spike-detector", "volume_spike", "bullish", "strong", time.Minute)); err == nil {
+		t.Error("expected the detector signal's error to be returned")
+	}
+
+	if len(handled) != 3 {
+		t.Fatalf("expected both signals and the confluence to be handled, got %d", len(handled))
+	}
+	if handled[2].SignalType != events.SignalTypeConfluence {
+		t.Errorf("expected confluence to follow the signal that completed it, got %s", handled[2].SignalType)
+	}
+}
+
+func TestCorrelator_WrapKeepsCompositeErrorsApart(t *testing.T) {
+	correlator := newTestCorrelator(t, 2)
+
+	handler := correlator.Wrap(func(signal *events.TradingSignal) error {
+		if signal.SignalType == events.SignalTypeConfluence {
+			return errors.New("delivery failed")
+		}
+		return nil
+	})
+
+	if err := handler(signalFrom("ma-signal-detector", "moving_average_crossover", "bullish", "strong", 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := handler(signalFrom("volume-spike-detector", "volume_spike", "bullish", "strong", time.Minute)); err != nil {
+		t.Errorf("expected the confluence's failure not to fail the signal that completed it, got %v", err)
+	}
+}
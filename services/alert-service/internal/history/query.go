@@ -0,0 +1,56 @@
+package history
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ParseFilter reads a Filter from GET /alerts query parameters: symbol,
+// signal_type, direction, outcome, since and until (RFC 3339), limit and
+// cursor.
+func ParseFilter(values url.Values) (Filter, error) {
+	filter := Filter{
+		Symbol:     values.Get("symbol"),
+		SignalType: values.Get("signal_type"),
+		Direction:  values.Get("direction"),
+		Outcome:    values.Get("outcome"),
+		Cursor:     values.Get("cursor"),
+		Limit:      DefaultLimit,
+	}
+
+	var err error
+	if filter.Since, err = parseTime(values, "since"); err != nil {
+		return Filter{}, err
+	}
+	if filter.Until, err = parseTime(values, "until"); err != nil {
+		return Filter{}, err
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		return Filter{}, fmt.Errorf("until must not be before since")
+	}
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return Filter{}, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+func parseTime(values url.Values, name string) (time.Time, error) {
+	value := values.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time such as 2024-01-15T10:00:00Z", name)
+	}
+	return t, nil
+}
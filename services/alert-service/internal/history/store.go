@@ -0,0 +1,304 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"crypto-trackers/pkg/events"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	OutcomeSent        = "sent"
+	OutcomePartial     = "partially_sent"
+	OutcomeFailed      = "failed"
+	OutcomeRateLimited = "rate_limited"
+	OutcomeUnrouted    = "unrouted"
+	OutcomeDigested    = "digested"
+
+	DefaultLimit = 100
+	MaxLimit     = 1000
+
+	pruneInterval = time.Hour
+)
+
+var alertsBucket = []byte("alerts")
+
+// Entry is one received signal and what the service did with it.
+// PendingDigest marks a signal buffered for a digest that has not been sent
+// yet, so the buffer can be rebuilt after a restart.
+type Entry struct {
+	ID            string                `json:"id"`
+	ReceivedAt    time.Time             `json:"received_at"`
+	Outcome       string                `json:"outcome"`
+	Channels      []string              `json:"channels,omitempty"`
+	Error         string                `json:"error,omitempty"`
+	PendingDigest bool                  `json:"pending_digest,omitempty"`
+	Signal        *events.TradingSignal `json:"signal"`
+}
+
+// Store keeps entries in a BoltDB file keyed by receive time, so queries
+// and pruning walk the keys in time order.
+type Store struct {
+	db *bolt.DB
+}
+
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open alert history: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(alertsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize alert history: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record stores entry and sets its ID.
+func (s *Store) Record(entry *Entry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(alertsBucket)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		key := entryKey(entry.ReceivedAt, seq)
+		entry.ID = hex.EncodeToString(key)
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal history entry: %w", err)
+		}
+		return bucket.Put(key, data)
+	})
+}
+
+// Update replaces the entry with entry's ID, as set by Record.
+func (s *Store) Update(entry *Entry) error {
+	key, err := hex.DecodeString(entry.ID)
+	if err != nil || len(key) != 16 {
+		return fmt.Errorf("invalid history entry ID %q", entry.ID)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(alertsBucket)
+		if bucket.Get(key) == nil {
+			return fmt.Errorf("history entry %s not found", entry.ID)
+		}
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal history entry: %w", err)
+		}
+		return bucket.Put(key, data)
+	})
+}
+
+// PendingDigest returns the entries still waiting for a digest, oldest
+// first.
+func (s *Store) PendingDigest() ([]*Entry, error) {
+	var pending []*Entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(alertsBucket).ForEach(func(k, v []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return fmt.Errorf("failed to unmarshal history entry %x: %w", k, err)
+			}
+			if entry.PendingDigest {
+				pending = append(pending, &entry)
+			}
+			return nil
+		})
+	})
+	return pending, err
+}
+
+// Page is one page of query results, newest first. NextCursor is set when
+// the page is full and more entries may follow.
+type Page struct {
+	Alerts     []Entry `json:"alerts"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// Query returns the newest entries matching filter, continuing after
+// filter.Cursor if set.
+func (s *Store) Query(filter Filter) (Page, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	var upper []byte
+	if !filter.Until.IsZero() {
+		upper = entryKey(filter.Until.Add(time.Nanosecond), 0)
+	}
+	if filter.Cursor != "" {
+		cursor, err := hex.DecodeString(filter.Cursor)
+		if err != nil || len(cursor) != 16 {
+			return Page{}, fmt.Errorf("invalid cursor %q", filter.Cursor)
+		}
+		if upper == nil || bytes.Compare(cursor, upper) < 0 {
+			upper = cursor
+		}
+	}
+
+	var lower []byte
+	if !filter.Since.IsZero() {
+		lower = entryKey(filter.Since, 0)
+	}
+
+	page := Page{Alerts: []Entry{}}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(alertsBucket).Cursor()
+
+		// Keys are exclusive upper bounds: start just below them
+		var k, v []byte
+		if upper == nil {
+			k, v = c.Last()
+		} else if k, v = c.Seek(upper); k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+
+		for ; k != nil; k, v = c.Prev() {
+			if lower != nil && bytes.Compare(k, lower) < 0 {
+				break
+			}
+
+			var entry Entry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return fmt.Errorf("failed to unmarshal history entry %x: %w", k, err)
+			}
+			if !filter.Matches(&entry) {
+				continue
+			}
+
+			page.Alerts = append(page.Alerts, entry)
+			if len(page.Alerts) == limit {
+				page.NextCursor = hex.EncodeToString(k)
+				break
+			}
+		}
+		return nil
+	})
+
+	return page, err
+}
+
+// Prune deletes entries received before cutoff and returns how many.
+func (s *Store) Prune(cutoff time.Time) (int, error) {
+	upper := entryKey(cutoff, 0)
+	deleted := 0
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(alertsBucket)
+
+		// Deleting while iterating makes the cursor skip keys, so collect first
+		var keys [][]byte
+		c := bucket.Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k, upper) < 0; k, _ = c.Next() {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+
+		for _, key := range keys {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		deleted = len(keys)
+		return nil
+	})
+
+	return deleted, err
+}
+
+// RunRetention prunes entries older than retention every hour until ctx is
+// done. A zero retention keeps everything.
+func (s *Store) RunRetention(ctx context.Context, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.Prune(time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("Failed to prune alert history: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Pruned %d alert history entries older than %s", deleted, retention)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// entryKey orders entries by receive time, with the store sequence breaking
+// ties between entries received in the same nanosecond.
+func entryKey(receivedAt time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key[:8], uint64(receivedAt.UnixNano()))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+// Filter selects entries. Empty fields match anything; string fields
+// compare case-insensitively.
+type Filter struct {
+	Symbol     string
+	SignalType string
+	Direction  string
+	Outcome    string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+	Cursor     string
+}
+
+func (f Filter) Matches(entry *Entry) bool {
+	signal := entry.Signal
+	if signal == nil {
+		signal = &events.TradingSignal{}
+	}
+
+	return matches(f.Symbol, signal.Symbol) &&
+		matches(f.SignalType, signal.SignalType) &&
+		matches(f.Direction, signal.Direction) &&
+		matches(f.Outcome, entry.Outcome)
+}
+
+func matches(want, value string) bool {
+	return want == "" || strings.EqualFold(want, value)
+}
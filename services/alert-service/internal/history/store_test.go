@@ -0,0 +1,221 @@
+package history
+
+import (
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	store, err := Open(filepath.Join(t.TempDir(), "history", "alerts.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func record(t *testing.T, store *Store, receivedAt time.Time, symbol, signalType, direction, outcome string) {
+	t.Helper()
+
+	entry := &Entry{
+		ReceivedAt: receivedAt,
+		Outcome:    outcome,
+		Signal:     &events.TradingSignal{Symbol: symbol, SignalType: signalType, Direction: direction},
+	}
+	if err := store.Record(entry); err != nil {
+		t.Fatalf("failed to record entry: %v", err)
+	}
+	if entry.ID == "" {
+		t.Fatal("expected Record to set an ID")
+	}
+}
+
+func symbols(page Page) []string {
+	var result []string
+	for _, entry := range page.Alerts {
+		result = append(result, entry.Signal.Symbol)
+	}
+	return result
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStore_Query(t *testing.T) {
+	store := openTestStore(t)
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	record(t, store, base, "BTC", "volume_spike", "bullish", OutcomeSent)
+	record(t, store, base.Add(time.Minute), "ETH", "golden_cross", "bullish", OutcomeSent)
+	record(t, store, base.Add(2*time.Minute), "BTC", "volume_spike", "bearish", OutcomeRateLimited)
+	record(t, store, base.Add(3*time.Minute), "SOL", "death_cross", "bearish", OutcomeFailed)
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{"newest first", Filter{}, []string{"SOL", "BTC", "ETH", "BTC"}},
+		{"symbol", Filter{Symbol: "btc"}, []string{"BTC", "BTC"}},
+		{"signal type", Filter{SignalType: "golden_cross"}, []string{"ETH"}},
+		{"direction", Filter{Direction: "bearish"}, []string{"SOL", "BTC"}},
+		{"outcome", Filter{Outcome: OutcomeRateLimited}, []string{"BTC"}},
+		{"since", Filter{Since: base.Add(2 * time.Minute)}, []string{"SOL", "BTC"}},
+		{"until", Filter{Until: base.Add(time.Minute)}, []string{"ETH", "BTC"}},
+		{"range", Filter{Since: base.Add(time.Minute), Until: base.Add(2 * time.Minute)}, []string{"BTC", "ETH"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := store.Query(tt.filter)
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			if got := symbols(page); !equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStore_Pagination(t *testing.T) {
+	store := openTestStore(t)
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	for i, symbol := range []string{"A", "B", "C", "D", "E"} {
+		record(t, store, base.Add(time.Duration(i)*time.Second), symbol, "volume_spike", "bullish", OutcomeSent)
+	}
+
+	var pages [][]string
+	filter := Filter{Limit: 2}
+	for {
+		page, err := store.Query(filter)
+		if err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		pages = append(pages, symbols(page))
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	expected := [][]string{{"E", "D"}, {"C", "B"}, {"A"}}
+	if len(pages) != len(expected) {
+		t.Fatalf("expected %d pages, got %v", len(expected), pages)
+	}
+	for i := range expected {
+		if !equal(pages[i], expected[i]) {
+			t.Errorf("page %d: expected %v, got %v", i, expected[i], pages[i])
+		}
+	}
+
+	if _, err := store.Query(Filter{Cursor: "not-a-cursor"}); err == nil {
+		t.Error("expected an invalid cursor to be rejected")
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	store := openTestStore(t)
+	now := time.Now()
+
+	record(t, store, now.Add(-48*time.Hour), "OLD", "volume_spike", "bullish", OutcomeSent)
+	record(t, store, now.Add(-time.Hour), "NEW", "volume_spike", "bullish", OutcomeSent)
+
+	deleted, err := store.Prune(now.Add(-24 * time.Hour))
+	if err != nil || deleted != 1 {
+		t.Fatalf("expected 1 entry pruned, got %d (%v)", deleted, err)
+	}
+
+	page, _ := store.Query(Filter{})
+	if got := symbols(page); !equal(got, []string{"NEW"}) {
+		t.Errorf("expected only the recent entry to remain, got %v", got)
+	}
+}
+
+func TestStore_PendingDigest(t *testing.T) {
+	store := openTestStore(t)
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	record(t, store, base, "BTC", "volume_spike", "bullish", OutcomeSent)
+	for i, symbol := range []string{"ETH", "SOL"} {
+		entry := &Entry{
+			ReceivedAt:    base.Add(time.Duration(i+1) * time.Minute),
+			Outcome:       OutcomeDigested,
+			PendingDigest: true,
+			Signal:        &events.TradingSignal{Symbol: symbol},
+		}
+		if err := store.Record(entry); err != nil {
+			t.Fatalf("failed to record entry: %v", err)
+		}
+	}
+
+	pending, err := store.PendingDigest()
+	if err != nil || len(pending) != 2 || pending[0].Signal.Symbol != "ETH" {
+		t.Fatalf("expected ETH and SOL pending, got %d entries (%v)", len(pending), err)
+	}
+
+	pending[0].PendingDigest = false
+	pending[0].Outcome = OutcomeSent
+	if err := store.Update(pending[0]); err != nil {
+		t.Fatalf("failed to update entry: %v", err)
+	}
+
+	if pending, _ = store.PendingDigest(); len(pending) != 1 || pending[0].Signal.Symbol != "SOL" {
+		t.Errorf("expected only SOL left pending, got %d entries", len(pending))
+	}
+	page, _ := store.Query(Filter{Outcome: OutcomeSent})
+	if got := symbols(page); !equal(got, []string{"ETH", "BTC"}) {
+		t.Errorf("expected the update to replace the entry, got %v", got)
+	}
+
+	if err := store.Update(&Entry{ID: "00"}); err == nil {
+		t.Error("expected an invalid ID to be rejected")
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter(url.Values{
+		"symbol":    {"BTC"},
+		"direction": {"bullish"},
+		"since":     {"2024-01-15T10:00:00Z"},
+		"limit":     {"50"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if filter.Symbol != "BTC" || filter.Direction != "bullish" || filter.Limit != 50 ||
+		!filter.Since.Equal(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+
+	if filter, _ := ParseFilter(url.Values{}); filter.Limit != DefaultLimit {
+		t.Errorf("expected default limit %d, got %d", DefaultLimit, filter.Limit)
+	}
+
+	for _, values := range []url.Values{
+		{"since": {"yesterday"}},
+		{"limit": {"0"}},
+		{"limit": {"5000"}},
+		{"since": {"2024-01-15T10:00:00Z"}, "until": {"2024-01-14T10:00:00Z"}},
+	} {
+		if _, err := ParseFilter(values); err == nil {
+			t.Errorf("expected %v to be rejected", values)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package kafka
+
+import (
+	"context"
+
+	"crypto-trackers/pkg/kafkaio"
+	"crypto-trackers/pkg/wire"
+)
+
+type DeadLetterProducer interface {
+	PublishDeadLetter(ctx context.Context, topic string, deadLetter *DeadLetter) error
+	Close() error
+}
+
+type Producer struct {
+	*kafkaio.Producer
+}
+
+// NewProducer returns a dead-letter producer. Dead letters have no Avro
+// schema, so they are always JSON. It also forwards signals the consumer
+// could not handle.
+func NewProducer(brokers []string) (*Producer, error) {
+	producer, err := kafkaio.NewProducer(brokers, wire.JSON())
+	if err != nil {
+		return nil, err
+	}
+	return &Producer{Producer: producer}, nil
+}
+
+// PublishDeadLetter keys dead letters by symbol, like the signals they hold.
+func (p *Producer) PublishDeadLetter(ctx context.Context, topic string, deadLetter *DeadLetter) error {
+	return p.Publish(ctx, topic, deadLetter.Signal.Symbol, deadLetter)
+}
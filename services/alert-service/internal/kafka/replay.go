@@ -0,0 +1,16 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"crypto-trackers/pkg/kafkaio"
+	"crypto-trackers/pkg/wire"
+)
+
+// ReplayDeadLetters reads every dead letter on topic published since the given
+// time up to the current high-water mark and hands it to eventHandler. See
+// kafkaio.Replay.
+func ReplayDeadLetters(ctx context.Context, brokers []string, topic string, since time.Time, eventHandler func(*DeadLetter) error) (*kafkaio.ReplayResult, error) {
+	return kafkaio.Replay(ctx, brokers, topic, since, wire.JSON(), eventHandler)
+}
@@ -0,0 +1,86 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio"
+	"crypto-trackers/pkg/wire"
+
+	"github.com/IBM/sarama"
+)
+
+func TestReplayDeadLetters_ReadsFromTimestampToHighWaterMark(t *testing.T) {
+	since := time.Date(2024, 6, 16, 12, 0, 0, 0, time.UTC)
+
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	fetch := sarama.NewMockFetchResponse(t, 1)
+	for offset := int64(0); offset < 5; offset++ {
+		data, err := json.Marshal(&DeadLetter{
+			Signal: &events.TradingSignal{
+				Timestamp: since.Add(time.Duration(offset) * time.Minute),
+				Symbol:    "BTC",
+				Details:   map[string]interface{}{"offset": offset},
+			},
+			Reason:         "slack: unexpected status 503",
+			FailedChannels: []string{"slack"},
+			FailedAt:       since.Add(time.Duration(offset) * time.Minute),
+		})
+		if err != nil {
+			t.Fatalf("failed to marshal dead letter: %v", err)
+		}
+		fetch.SetMessage("alerts-dlq", 0, offset, sarama.ByteEncoder(data))
+	}
+	fetch.SetHighWaterMark("alerts-dlq", 0, 5)
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("alerts-dlq", 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("alerts-dlq", 0, sarama.OffsetOldest, 0).
+			SetOffset("alerts-dlq", 0, sarama.OffsetNewest, 5).
+			SetOffset("alerts-dlq", 0, since.UnixMilli(), 2),
+		"FetchRequest": fetch,
+	})
+
+	client, err := sarama.NewClient([]string{broker.Addr()}, sarama.NewConfig())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	var replayed []*DeadLetter
+	handler := func(deadLetter *DeadLetter) error {
+		replayed = append(replayed, deadLetter)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := kafkaio.ReplayFromClient(ctx, client, "alerts-dlq", since, wire.JSON(), handler)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if result.Events != 3 {
+		t.Errorf("expected 3 replayed dead letters, got %d", result.Events)
+	}
+
+	if len(replayed) != 3 || replayed[0].Signal.Details["offset"] != 2.0 {
+		t.Errorf("expected replay to start at offset 2, got %d dead letters", len(replayed))
+	}
+	if replayed[0].FailedChannels[0] != "slack" {
+		t.Errorf("expected failed channel slack, got %v", replayed[0].FailedChannels)
+	}
+
+	if result.EndOffsets["alerts-dlq"][0] != 5 {
+		t.Errorf("expected end offset 5, got %d", result.EndOffsets["alerts-dlq"][0])
+	}
+}
@@ -1,13 +1,26 @@
 package kafka
 
-import "time"
-
-type TradingSignal struct {
-	Timestamp      time.Time              `json:"timestamp"`
-	Symbol         string                 `json:"symbol"`
-	SignalType     string                 `json:"signal_type"`
-	SignalStrength string                 `json:"signal_strength"`
-	Direction      string                 `json:"direction"`
-	Details        map[string]interface{} `json:"details"`
-	ServiceID      string                 `json:"service_id"`
+import (
+	"errors"
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+// DeadLetter is an alert that exhausted its delivery retries, as published
+// to the dead-letter topic. FailedChannels lists only the channels that did
+// not receive it, so a replay does not repeat successful deliveries.
+type DeadLetter struct {
+	Signal         *events.TradingSignal `json:"signal"`
+	Reason         string                `json:"reason"`
+	FailedChannels []string              `json:"failed_channels"`
+	FailedAt       time.Time             `json:"failed_at"`
+}
+
+// Validate rejects dead letters without a signal to resend.
+func (d *DeadLetter) Validate() error {
+	if d.Signal == nil {
+		return errors.New("dead letter has no signal")
+	}
+	return nil
 }
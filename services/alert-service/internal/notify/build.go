@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"alert-service/internal/config"
+)
+
+// FromConfig builds the channels named in NOTIFY_CHANNELS, each wrapped in
+// the configured retry policy and rendering alerts in its format.
+func FromConfig(cfg *config.Config) ([]Notifier, error) {
+	client := &http.Client{Timeout: time.Duration(cfg.NotifyTimeoutSeconds) * time.Second}
+
+	var notifiers []Notifier
+	for _, channel := range strings.Split(cfg.NotifyChannels, ",") {
+		switch strings.ToLower(strings.TrimSpace(channel)) {
+		case "":
+			continue
+		case ChannelConsole:
+			notifiers = append(notifiers, NewConsoleNotifier(os.Stdout))
+		case ChannelWebhook:
+			if cfg.WebhookURL == "" {
+				return nil, fmt.Errorf("webhook channel requires WEBHOOK_URL")
+			}
+			notifiers = append(notifiers, NewWebhookNotifier(cfg.WebhookURL, client))
+		case ChannelSlack:
+			if cfg.SlackWebhookURL == "" {
+				return nil, fmt.Errorf("slack channel requires SLACK_WEBHOOK_URL")
+			}
+			notifiers = append(notifiers, NewSlackNotifier(cfg.SlackWebhookURL, client))
+		case ChannelSMTP:
+			if cfg.SMTPHost == "" || cfg.SMTPFrom == "" || cfg.SMTPTo == "" {
+				return nil, fmt.Errorf("smtp channel requires SMTP_HOST, SMTP_FROM and SMTP_TO")
+			}
+			recipients := strings.Split(cfg.SMTPTo, ",")
+			for i := range recipients {
+				recipients[i] = strings.TrimSpace(recipients[i])
+			}
+			notifiers = append(notifiers, NewSMTPNotifier(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom, recipients))
+		case ChannelTelegram:
+			if cfg.TelegramBotToken == "" || cfg.TelegramChatID == "" {
+				return nil, fmt.Errorf("telegram channel requires TELEGRAM_BOT_TOKEN and TELEGRAM_CHAT_ID")
+			}
+			notifiers = append(notifiers, NewTelegramNotifier(cfg.TelegramAPIURL, cfg.TelegramBotToken, cfg.TelegramChatID, client))
+		default:
+			return nil, fmt.Errorf("unsupported notification channel %q", channel)
+		}
+	}
+
+	if len(notifiers) == 0 {
+		return nil, fmt.Errorf("NOTIFY_CHANNELS must name at least one channel")
+	}
+
+	formats, err := parseFormats(cfg.AlertFormats)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := PolicyFromConfig(cfg)
+	for i, notifier := range notifiers {
+		format, ok := formats[notifier.Name()]
+		if !ok {
+			format = cfg.AlertFormat
+		}
+		formatter, err := NewFormatter(format, cfg.AlertTemplateDir)
+		if err != nil {
+			return nil, fmt.Errorf("%s channel: %w", notifier.Name(), err)
+		}
+		notifiers[i] = NewFormattingNotifier(NewRetryNotifier(notifier, policy), formatter)
+	}
+	return notifiers, nil
+}
+
+// parseFormats reads ALERT_FORMATS, a comma-separated list of
+// channel=format pairs such as "smtp=html,slack=markdown".
+func parseFormats(value string) (map[string]string, error) {
+	formats := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		channel, format, found := strings.Cut(pair, "=")
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		format = strings.TrimSpace(format)
+		if !found || channel == "" || format == "" {
+			return nil, fmt.Errorf("invalid ALERT_FORMATS entry %q (expected channel=format)", pair)
+		}
+		formats[channel] = format
+	}
+	return formats, nil
+}
+
+func PolicyFromConfig(cfg *config.Config) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    cfg.NotifyMaxAttempts,
+		InitialBackoff: time.Duration(cfg.NotifyInitialBackoffMs) * time.Millisecond,
+		MaxBackoff:     DefaultMaxBackoff,
+		AttemptTimeout: time.Duration(cfg.NotifyTimeoutSeconds) * time.Second,
+	}
+}
@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ConsoleNotifier writes alerts to a terminal or log stream.
+type ConsoleNotifier struct {
+	out io.Writer
+}
+
+func NewConsoleNotifier(out io.Writer) *ConsoleNotifier {
+	return &ConsoleNotifier{out: out}
+}
+
+func (c *ConsoleNotifier) Name() string {
+	return ChannelConsole
+}
+
+func (c *ConsoleNotifier) Notify(ctx context.Context, alert Alert) error {
+	title := "🚨 TRADING SIGNAL ALERT 🚨"
+	if alert.Digest != nil {
+		title = "📋 " + alert.Subject + " 📋"
+	}
+	_, err := fmt.Fprintf(c.out, "%s\n%s\n=%s\n", title, alert.Body, strings.Repeat("=", 50))
+	return err
+}
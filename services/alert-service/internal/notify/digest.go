@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+// Digest summarizes the signals buffered over one window. Alerts carrying a
+// digest have a nil Signal.
+type Digest struct {
+	Start  time.Time     `json:"start"`
+	End    time.Time     `json:"end"`
+	Groups []DigestGroup `json:"groups"`
+}
+
+// DigestGroup holds the signals of one symbol and signal type. Signals were
+// allowed by rate limiting; Suppressed counts the ones that were not.
+type DigestGroup struct {
+	Symbol     string                  `json:"symbol"`
+	SignalType string                  `json:"signal_type"`
+	Signals    []*events.TradingSignal `json:"signals,omitempty"`
+	Suppressed int                     `json:"suppressed"`
+}
+
+// Alerts returns the number of signals allowed by rate limiting.
+func (d *Digest) Alerts() int {
+	alerts := 0
+	for _, group := range d.Groups {
+		alerts += len(group.Signals)
+	}
+	return alerts
+}
+
+// Suppressed returns the number of signals rate limiting held back.
+func (d *Digest) Suppressed() int {
+	suppressed := 0
+	for _, group := range d.Groups {
+		suppressed += group.Suppressed
+	}
+	return suppressed
+}
+
+// Total returns the number of signals the digest covers.
+func (d *Digest) Total() int {
+	return d.Alerts() + d.Suppressed()
+}
+
+// Symbols returns the number of distinct symbols in the digest.
+func (d *Digest) Symbols() int {
+	seen := make(map[string]bool)
+	for _, group := range d.Groups {
+		seen[group.Symbol] = true
+	}
+	return len(seen)
+}
+
+// Latest returns the most recent allowed signal, or nil if every signal in
+// the group was suppressed.
+func (g DigestGroup) Latest() *events.TradingSignal {
+	if len(g.Signals) == 0 {
+		return nil
+	}
+	return g.Signals[len(g.Signals)-1]
+}
@@ -0,0 +1,229 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+
+	ContentTypePlain    = "text/plain; charset=UTF-8"
+	ContentTypeMarkdown = "text/markdown; charset=UTF-8"
+	ContentTypeHTML     = "text/html; charset=UTF-8"
+	ContentTypeJSON     = "application/json"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// Template files are looked up by format name with one of these suffixes,
+// which decide the content type. HTML templates escape their values.
+var templateSuffixes = []struct {
+	suffix      string
+	contentType string
+}{
+	{".tmpl", ContentTypePlain},
+	{".md.tmpl", ContentTypeMarkdown},
+	{".html.tmpl", ContentTypeHTML},
+	{".json.tmpl", ContentTypeJSON},
+}
+
+// Detail is one Details entry; templates get them sorted by key.
+type Detail struct {
+	Key   string
+	Value interface{}
+}
+
+// TemplateData is what templates render: exactly one of Signal and Digest
+// is set.
+type TemplateData struct {
+	Signal *events.TradingSignal
+	Digest *Digest
+}
+
+var templateFuncs = map[string]interface{}{
+	"details": sortedDetails,
+	"json":    toJSON,
+	"rfc3339": func(t time.Time) string { return t.Format(time.RFC3339) },
+}
+
+type executor interface {
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+// Formatter renders alerts from a template defining "body" and, optionally,
+// "subject". The subject is always rendered as plain text since it ends up
+// in headers and titles.
+type Formatter struct {
+	name        string
+	contentType string
+	subject     executor
+	body        executor
+}
+
+var defaultFormatter = mustFormatter(NewFormatter(FormatPlain, ""))
+
+// DefaultFormatter returns the built-in plain text formatter.
+func DefaultFormatter() *Formatter {
+	return defaultFormatter
+}
+
+// NewFormatter loads the named format, preferring a template in dir over
+// the built-in ones so that built-ins can be customized.
+func NewFormatter(name, dir string) (*Formatter, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid alert format %q", name)
+	}
+
+	subjectSource, err := fs.ReadFile(builtinTemplates, "templates/subject.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, suffix := range templateSuffixes {
+		source, err := readTemplate(dir, name+suffix.suffix)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return parseFormatter(name, suffix.contentType, string(subjectSource), string(source))
+	}
+
+	return nil, fmt.Errorf("unknown alert format %q (built-in: %s, %s, %s, %s)",
+		name, FormatPlain, FormatMarkdown, FormatHTML, FormatJSON)
+}
+
+func readTemplate(dir, file string) ([]byte, error) {
+	if dir != "" {
+		source, err := os.ReadFile(filepath.Join(dir, file))
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return source, err
+		}
+	}
+	return fs.ReadFile(builtinTemplates, "templates/"+file)
+}
+
+func parseFormatter(name, contentType, subjectSource, source string) (*Formatter, error) {
+	subject, err := texttemplate.New(name).Funcs(templateFuncs).Parse(subjectSource)
+	if err == nil {
+		subject, err = subject.Parse(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+
+	formatter := &Formatter{name: name, contentType: contentType, subject: subject, body: subject}
+	if contentType == ContentTypeHTML {
+		body, err := htmltemplate.New(name).Funcs(templateFuncs).Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %w", name, err)
+		}
+		if body.Lookup("body") == nil {
+			return nil, fmt.Errorf("%s template does not define \"body\"", name)
+		}
+		formatter.body = body
+	} else if subject.Lookup("body") == nil {
+		return nil, fmt.Errorf("%s template does not define \"body\"", name)
+	}
+
+	return formatter, nil
+}
+
+func mustFormatter(formatter *Formatter, err error) *Formatter {
+	if err != nil {
+		panic(err)
+	}
+	return formatter
+}
+
+func (f *Formatter) Name() string {
+	return f.name
+}
+
+// Format returns alert with Subject, Body and ContentType rendered from its
+// Signal or Digest.
+func (f *Formatter) Format(alert Alert) (Alert, error) {
+	data := TemplateData{Signal: alert.Signal, Digest: alert.Digest}
+
+	var subject, body bytes.Buffer
+	if err := f.subject.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return alert, fmt.Errorf("failed to render %s subject: %w", f.name, err)
+	}
+	if err := f.body.ExecuteTemplate(&body, "body", data); err != nil {
+		return alert, fmt.Errorf("failed to render %s body: %w", f.name, err)
+	}
+
+	alert.Subject = strings.TrimSpace(subject.String())
+	alert.Body = body.String()
+	alert.ContentType = f.contentType
+	return alert, nil
+}
+
+// FormattingNotifier renders each alert in its channel's format before
+// passing it on.
+type FormattingNotifier struct {
+	notifier  Notifier
+	formatter *Formatter
+}
+
+func NewFormattingNotifier(notifier Notifier, formatter *Formatter) *FormattingNotifier {
+	return &FormattingNotifier{notifier: notifier, formatter: formatter}
+}
+
+func (f *FormattingNotifier) Name() string {
+	return f.notifier.Name()
+}
+
+// Notify fails permanently on a template error, since retrying renders the
+// same template again.
+func (f *FormattingNotifier) Notify(ctx context.Context, alert Alert) error {
+	formatted, err := f.formatter.Format(alert)
+	if err != nil {
+		return Permanent(err)
+	}
+	return f.notifier.Notify(ctx, formatted)
+}
+
+func sortedDetails(details map[string]interface{}) []Detail {
+	keys := make([]string, 0, len(details))
+	for key := range details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sorted := make([]Detail, len(keys))
+	for i, key := range keys {
+		sorted[i] = Detail{Key: key, Value: details[key]}
+	}
+	return sorted
+}
+
+func toJSON(value interface{}) (string, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
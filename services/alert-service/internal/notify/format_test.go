@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"alert-service/internal/config"
+	"crypto-trackers/pkg/events"
+)
+
+func formatSignal() *events.TradingSignal {
+	return &events.TradingSignal{
+		Timestamp:      time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+		Symbol:         "BTC",
+		SignalType:     "volume_spike",
+		SignalStrength: "strong",
+		Direction:      "bullish",
+		Details: map[string]interface{}{
+			"volume":           1.5e9,
+			"spike_multiplier": 2.4,
+			"note":             "<script>",
+			"average_volume":   6.2e8,
+		},
+		ServiceID: "volume-spike-detector",
+	}
+}
+
+func TestFormatter_Plain(t *testing.T) {
+	alert, err := DefaultFormatter().Format(Alert{Signal: formatSignal()})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := "Symbol: BTC\n" +
+		"Signal Type: volume_spike\n" +
+		"Direction: bullish\n" +
+		"Strength: strong\n" +
+		"Time: 2023-01-01T12:00:00Z\n" +
+		"Service: volume-spike-detector\n" +
+		"Details:\n" +
+		"  average_volume: 6.2e+08\n" +
+		"  note: <script>\n" +
+		"  spike_multiplier: 2.4\n" +
+		"  volume: 1.5e+09\n"
+	if alert.Body != expected {
+		t.Errorf("expected details in key order:\n%s\ngot:\n%s", expected, alert.Body)
+	}
+	if alert.Subject != "BTC bullish volume_spike signal (strong)" {
+		t.Errorf("unexpected subject %q", alert.Subject)
+	}
+	if alert.ContentType != ContentTypePlain {
+		t.Errorf("expected plain content type, got %q", alert.ContentType)
+	}
+}
+
+func TestFormatter_BuiltIns(t *testing.T) {
+	digest := &Digest{Groups: []DigestGroup{{Symbol: "BTC", SignalType: "volume_spike", Signals: []*events.TradingSignal{formatSignal()}, Suppressed: 2}}}
+
+	tests := []struct {
+		format      string
+		contentType string
+		signal      []string
+		digest      []string
+	}{
+		{FormatPlain, ContentTypePlain, []string{"Symbol: BTC"}, []string{"BTC:\n  volume_spike: 1 alerts, 2 suppressed"}},
+		{FormatMarkdown, ContentTypeMarkdown, []string{"### BTC bullish volume_spike", "- `spike_multiplier`: 2.4"}, []string{"**BTC**", "- `volume_spike`: 1 alerts, 2 suppressed"}},
+		{FormatHTML, ContentTypeHTML, []string{"<h2>BTC bullish volume_spike signal</h2>", "&lt;script&gt;"}, []string{"<td>volume_spike</td>"}},
+		{FormatJSON, ContentTypeJSON, []string{`"symbol": "BTC"`}, []string{`"suppressed": 2`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			formatter, err := NewFormatter(tt.format, "")
+			if err != nil {
+				t.Fatalf("failed to load format: %v", err)
+			}
+
+			for _, c := range []struct {
+				alert    Alert
+				expected []string
+			}{{Alert{Signal: formatSignal()}, tt.signal}, {Alert{Digest: digest}, tt.digest}} {
+				alert, err := formatter.Format(c.alert)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if alert.ContentType != tt.contentType {
+					t.Errorf("expected content type %q, got %q", tt.contentType, alert.ContentType)
+				}
+				for _, want := range c.expected {
+					if !strings.Contains(alert.Body, want) {
+						t.Errorf("expected %q in:\n%s", want, alert.Body)
+					}
+				}
+				if strings.Contains(alert.Body, "<script>") && tt.format == FormatHTML {
+					t.Errorf("expected HTML to escape details, got:\n%s", alert.Body)
+				}
+			}
+		})
+	}
+
+	formatter, _ := NewFormatter(FormatJSON, "")
+	alert, _ := formatter.Format(Alert{Signal: formatSignal()})
+	var decoded events.TradingSignal
+	if err := json.Unmarshal([]byte(alert.Body), &decoded); err != nil || decoded.Symbol != "BTC" {
+		t.Errorf("expected the JSON format to decode as a signal, got %v", err)
+	}
+}
+
+func TestFormatter_TemplateDir(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "plain.tmpl"), []byte(`{{define "body"}}custom {{.Signal.Symbol}}{{end}}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "pager.tmpl"), []byte(`{{define "subject"}}PAGE {{.Signal.Symbol}}{{end}}{{define "body"}}{{.Signal.Direction}}{{end}}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "broken.tmpl"), []byte(`{{define "subject"}}no body{{end}}`), 0o644)
+
+	formatter, err := NewFormatter("plain", dir)
+	if err != nil {
+		t.Fatalf("failed to load override: %v", err)
+	}
+	alert, _ := formatter.Format(Alert{Signal: formatSignal()})
+	if alert.Body != "custom BTC" || alert.Subject != "BTC bullish volume_spike signal (strong)" {
+		t.Errorf("expected the override body with the default subject, got %q / %q", alert.Subject, alert.Body)
+	}
+
+	formatter, err = NewFormatter("pager", dir)
+	if err != nil {
+		t.Fatalf("failed to load custom format: %v", err)
+	}
+	alert, _ = formatter.Format(Alert{Signal: formatSignal()})
+	if alert.Subject != "PAGE BTC" || alert.Body != "bullish" {
+		t.Errorf("expected the custom subject and body, got %q / %q", alert.Subject, alert.Body)
+	}
+
+	if _, err := NewFormatter("broken", dir); err == nil {
+		t.Error("expected a template without a body to be rejected")
+	}
+	if _, err := NewFormatter("missing", dir); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+	if _, err := NewFormatter("../plain", dir); err == nil {
+		t.Error("expected a path to be rejected as a format name")
+	}
+}
+
+func TestFromConfig_Formats(t *testing.T) {
+	server, _, bodies := recordingServer(t, http.StatusOK)
+
+	cfg := &config.Config{
+		NotifyChannels:       "slack,webhook",
+		SlackWebhookURL:      server.URL,
+		WebhookURL:           server.URL,
+		NotifyTimeoutSeconds: 5,
+		NotifyMaxAttempts:    1,
+		AlertFormat:          FormatPlain,
+		AlertFormats:         "slack=markdown",
+	}
+	notifiers, err := FromConfig(cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, notifier := range notifiers {
+		if err := notifier.Notify(context.Background(), Alert{Signal: formatSignal()}); err != nil {
+			t.Fatalf("%s failed: %v", notifier.Name(), err)
+		}
+	}
+
+	if text, _ := (*bodies)[0]["text"].(string); !strings.HasPrefix(text, "### BTC") {
+		t.Errorf("expected slack to get markdown, got %q", text)
+	}
+	if message, _ := (*bodies)[1]["message"].(string); !strings.HasPrefix(message, "Symbol: BTC") {
+		t.Errorf("expected webhook to get plain text, got %q", message)
+	}
+
+	cfg.AlertFormats = "slack"
+	if _, err := FromConfig(cfg); err == nil {
+		t.Error("expected a malformed ALERT_FORMATS entry to be rejected")
+	}
+	cfg.AlertFormats = "slack=fancy"
+	if _, err := FromConfig(cfg); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
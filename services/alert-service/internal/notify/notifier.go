@@ -0,0 +1,167 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-trackers/pkg/events"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+// Alert is a formatted trading signal, or a digest of several, ready for
+// delivery. Channels that carry structured data use Signal or Digest; the
+// rest send Subject and Body. An empty ContentType means plain text.
+type Alert struct {
+	Signal      *events.TradingSignal
+	Digest      *Digest
+	Subject     string
+	Body        string
+	ContentType string
+}
+
+// describe names the alert in logs.
+func (a Alert) describe() string {
+	if a.Signal == nil {
+		return a.Subject
+	}
+	return a.Signal.Symbol
+}
+
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// DeliveryError reports the channels an alert could not be delivered to.
+type DeliveryError struct {
+	Failed    map[string]error
+	Delivered int
+}
+
+// Channels returns the names of the channels that failed, sorted.
+func (e *DeliveryError) Channels() []string {
+	channels := make([]string, 0, len(e.Failed))
+	for channel := range e.Failed {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+func (e *DeliveryError) Error() string {
+	channels := e.Channels()
+	messages := make([]string, len(channels))
+	for i, channel := range channels {
+		messages[i] = fmt.Sprintf("%s: %v", channel, e.Failed[channel])
+	}
+	return fmt.Sprintf("delivery failed on %d of %d channels: %s",
+		len(e.Failed), len(e.Failed)+e.Delivered, strings.Join(messages, "; "))
+}
+
+func (e *DeliveryError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, err := range e.Failed {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Dispatcher fans an alert out to every configured channel concurrently and
+// records per-channel delivery metrics.
+type Dispatcher struct {
+	notifiers []Notifier
+	timeout   time.Duration
+	delivered prometheus.CounterVec
+	failed    prometheus.CounterVec
+}
+
+func NewDispatcher(notifiers []Notifier, timeout time.Duration, delivered, failed prometheus.CounterVec) *Dispatcher {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Dispatcher{
+		notifiers: notifiers,
+		timeout:   timeout,
+		delivered: delivered,
+		failed:    failed,
+	}
+}
+
+func (d *Dispatcher) Name() string {
+	return strings.Join(d.Channels(), ",")
+}
+
+// Channels returns the names of the dispatcher's channels in the order they
+// were configured.
+func (d *Dispatcher) Channels() []string {
+	names := make([]string, len(d.notifiers))
+	for i, notifier := range d.notifiers {
+		names[i] = notifier.Name()
+	}
+	return names
+}
+
+// Notify returns a *DeliveryError if any channel failed. Delivered on the
+// error tells callers whether the alert reached anyone at all.
+func (d *Dispatcher) Notify(ctx context.Context, alert Alert) error {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	errs := make([]error, len(d.notifiers))
+	var wg sync.WaitGroup
+	for i, notifier := range d.notifiers {
+		wg.Add(1)
+		go func(i int, notifier Notifier) {
+			defer wg.Done()
+			errs[i] = notifier.Notify(ctx, alert)
+		}(i, notifier)
+	}
+	wg.Wait()
+
+	failed := make(map[string]error)
+	for i, notifier := range d.notifiers {
+		if errs[i] != nil {
+			d.failed.WithLabelValues(notifier.Name()).Inc()
+			log.Printf("Failed to deliver alert for %s via %s: %v", alert.describe(), notifier.Name(), errs[i])
+			failed[notifier.Name()] = errs[i]
+			continue
+		}
+		d.delivered.WithLabelValues(notifier.Name()).Inc()
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	return &DeliveryError{Failed: failed, Delivered: len(d.notifiers) - len(failed)}
+}
+
+// Select returns a dispatcher for just the named channels, in the order
+// they were configured. Unknown names are ignored.
+func (d *Dispatcher) Select(channels []string) *Dispatcher {
+	wanted := make(map[string]bool, len(channels))
+	for _, channel := range channels {
+		wanted[strings.ToLower(strings.TrimSpace(channel))] = true
+	}
+
+	selected := *d
+	selected.notifiers = nil
+	for _, notifier := range d.notifiers {
+		if wanted[notifier.Name()] {
+			selected.notifiers = append(selected.notifiers, notifier)
+		}
+	}
+	return &selected
+}
+
+func (d *Dispatcher) Len() int {
+	return len(d.notifiers)
+}
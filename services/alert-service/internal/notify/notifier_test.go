@@ -0,0 +1,231 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"crypto-trackers/pkg/events"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func testAlert() Alert {
+	return Alert{
+		Signal: &events.TradingSignal{
+			Timestamp:      time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+			Symbol:         "BTC",
+			SignalType:     "volume_spike",
+			SignalStrength: "strong",
+			Direction:      "bullish",
+			Details:        map[string]interface{}{"spike_multiplier": 2.4},
+			ServiceID:      "volume-spike-detector",
+		},
+		Subject: "BTC bullish volume_spike signal (strong)",
+		Body:    "Symbol: BTC\n",
+	}
+}
+
+// recordingServer captures the path and JSON body of every request.
+func recordingServer(t *testing.T, status int) (*httptest.Server, *[]string, *[]map[string]interface{}) {
+	t.Helper()
+	var paths []string
+	var bodies []map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected JSON content type, got %q", r.Header.Get("Content-Type"))
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &paths, &bodies
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	server, _, bodies := recordingServer(t, http.StatusOK)
+
+	err := NewWebhookNotifier(server.URL, server.Client()).Notify(context.Background(), testAlert())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	body := (*bodies)[0]
+	if body["symbol"] != "BTC" || body["signal_type"] != "volume_spike" {
+		t.Errorf("expected signal fields in payload, got %v", body)
+	}
+	if body["message"] != "Symbol: BTC\n" {
+		t.Errorf("expected formatted alert in message, got %v", body["message"])
+	}
+	if details, ok := body["details"].(map[string]interface{}); !ok || details["spike_multiplier"] != 2.4 {
+		t.Errorf("expected details to be passed through, got %v", body["details"])
+	}
+}
+
+func TestWebhookNotifier_Digest(t *testing.T) {
+	server, _, bodies := recordingServer(t, http.StatusOK)
+
+	alert := Alert{
+		Digest: &Digest{Groups: []DigestGroup{
+			{Symbol: "BTC", SignalType: "volume_spike", Signals: []*events.TradingSignal{testAlert().Signal}, Suppressed: 2},
+		}},
+		Subject: "Alert digest",
+		Body:    "BTC:\n",
+	}
+	if err := NewWebhookNotifier(server.URL, server.Client()).Notify(context.Background(), alert); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	body := (*bodies)[0]
+	digest, ok := body["digest"].(map[string]interface{})
+	if !ok || body["message"] != "BTC:\n" {
+		t.Fatalf("expected digest and message in payload, got %v", body)
+	}
+	groups, _ := digest["groups"].([]interface{})
+	if len(groups) != 1 || groups[0].(map[string]interface{})["suppressed"] != 2.0 {
+		t.Errorf("expected the group with its suppressed count, got %v", digest["groups"])
+	}
+}
+
+func TestSlackNotifier(t *testing.T) {
+	server, _, bodies := recordingServer(t, http.StatusOK)
+
+	err := NewSlackNotifier(server.URL, server.Client()).Notify(context.Background(), testAlert())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	text, _ := (*bodies)[0]["text"].(string)
+	if !strings.Contains(text, "*BTC bullish volume_spike signal (strong)*") || !strings.Contains(text, "Symbol: BTC") {
+		t.Errorf("expected subject and body in text, got %q", text)
+	}
+}
+
+func TestTelegramNotifier(t *testing.T) {
+	server, paths, bodies := recordingServer(t, http.StatusOK)
+
+	err := NewTelegramNotifier(server.URL+"/", "123:abc", "-1001", server.Client()).Notify(context.Background(), testAlert())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if (*paths)[0] != "/bot123:abc/sendMessage" {
+		t.Errorf("expected sendMessage path, got %s", (*paths)[0])
+	}
+	if (*bodies)[0]["chat_id"] != "-1001" {
+		t.Errorf("expected chat_id -1001, got %v", (*bodies)[0]["chat_id"])
+	}
+}
+
+func TestHTTPNotifiers_ErrorStatus(t *testing.T) {
+	server, _, _ := recordingServer(t, http.StatusTooManyRequests)
+
+	err := NewSlackNotifier(server.URL, server.Client()).Notify(context.Background(), testAlert())
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 429") {
+		t.Errorf("expected status error, got %v", err)
+	}
+}
+
+func TestHTTPNotifiers_ErrorOmitsURL(t *testing.T) {
+	server, _, _ := recordingServer(t, http.StatusOK)
+	server.Close()
+
+	err := NewTelegramNotifier(server.URL, "secret-token", "1", server.Client()).Notify(context.Background(), testAlert())
+	if err == nil {
+		t.Fatal("expected error for closed server")
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("expected bot token to be kept out of the error, got %v", err)
+	}
+}
+
+func TestConsoleNotifier(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewConsoleNotifier(&out).Notify(context.Background(), testAlert()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "TRADING SIGNAL ALERT") || !strings.Contains(out.String(), "Symbol: BTC") {
+		t.Errorf("expected alert banner and body, got %q", out.String())
+	}
+}
+
+type stubNotifier struct {
+	name string
+	err  error
+}
+
+func (s stubNotifier) Name() string { return s.name }
+
+func (s stubNotifier) Notify(ctx context.Context, alert Alert) error { return s.err }
+
+func TestDispatcher_FanOut(t *testing.T) {
+	delivered := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_delivered", Help: "test"},
+		[]string{"channel"},
+	)
+	failed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_alerts_delivery_failed", Help: "test"},
+		[]string{"channel"},
+	)
+
+	server, _, bodies := recordingServer(t, http.StatusOK)
+	outage := errors.New("connection refused")
+
+	dispatcher := NewDispatcher([]Notifier{
+		NewWebhookNotifier(server.URL, server.Client()),
+		stubNotifier{name: ChannelSMTP, err: outage},
+		stubNotifier{name: ChannelTelegram},
+	}, time.Second, *delivered, *failed)
+
+	err := dispatcher.Notify(context.Background(), testAlert())
+
+	var deliveryErr *DeliveryError
+	if !errors.As(err, &deliveryErr) {
+		t.Fatalf("expected a DeliveryError, got %v", err)
+	}
+	if deliveryErr.Delivered != 2 || len(deliveryErr.Failed) != 1 {
+		t.Errorf("expected 2 delivered and 1 failed, got %d and %d", deliveryErr.Delivered, len(deliveryErr.Failed))
+	}
+	if !errors.Is(err, outage) {
+		t.Errorf("expected the channel error to be wrapped, got %v", err)
+	}
+	if channels := deliveryErr.Channels(); len(channels) != 1 || channels[0] != ChannelSMTP {
+		t.Errorf("expected smtp as the failed channel, got %v", channels)
+	}
+	if len(*bodies) != 1 {
+		t.Errorf("expected the webhook to be called once, got %d", len(*bodies))
+	}
+
+	if got := testutil.ToFloat64(delivered.WithLabelValues(ChannelWebhook)); got != 1 {
+		t.Errorf("expected 1 webhook delivery, got %.0f", got)
+	}
+	if got := testutil.ToFloat64(delivered.WithLabelValues(ChannelTelegram)); got != 1 {
+		t.Errorf("expected 1 telegram delivery, got %.0f", got)
+	}
+	if got := testutil.ToFloat64(failed.WithLabelValues(ChannelSMTP)); got != 1 {
+		t.Errorf("expected 1 smtp failure, got %.0f", got)
+	}
+
+	if name := dispatcher.Name(); name != "webhook,smtp,telegram" {
+		t.Errorf("expected channel list as name, got %s", name)
+	}
+	if channels := dispatcher.Select([]string{"Telegram", "webhook"}).Channels(); strings.Join(channels, ",") != "webhook,telegram" {
+		t.Errorf("expected selected channels in configured order, got %v", channels)
+	}
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts    = 3
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 30 * time.Second
+)
+
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// AttemptTimeout bounds each delivery attempt; zero leaves it to the
+	// caller's context.
+	AttemptTimeout time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    DefaultMaxAttempts,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		AttemptTimeout: DefaultTimeout,
+	}
+}
+
+// Backoff returns the wait before the given retry, doubling from
+// InitialBackoff up to MaxBackoff. retry counts from 1.
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < retry && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+// Budget is the longest a delivery can take with every attempt timing out,
+// which makes a sensible overall deadline for a Dispatcher.
+func (p RetryPolicy) Budget() time.Duration {
+	budget := time.Duration(p.MaxAttempts) * p.AttemptTimeout
+	for retry := 1; retry < p.MaxAttempts; retry++ {
+		budget += p.Backoff(retry)
+	}
+	return budget
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, such as a rejected payload or
+// bad credentials.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// RetryNotifier retries a channel with exponential backoff until it
+// succeeds, fails permanently or runs out of attempts.
+type RetryNotifier struct {
+	notifier Notifier
+	policy   RetryPolicy
+}
+
+func NewRetryNotifier(notifier Notifier, policy RetryPolicy) *RetryNotifier {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	return &RetryNotifier{notifier: notifier, policy: policy}
+}
+
+func (r *RetryNotifier) Name() string {
+	return r.notifier.Name()
+}
+
+func (r *RetryNotifier) Notify(ctx context.Context, alert Alert) error {
+	var err error
+	for attempt := 1; attempt <= r.policy.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("gave up after %d attempts: %w", attempt-1, err)
+			case <-time.After(r.policy.Backoff(attempt - 1)):
+			}
+		}
+
+		if err = r.attempt(ctx, alert); err == nil {
+			return nil
+		}
+		if IsPermanent(err) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("gave up after %d attempts: %w", r.policy.MaxAttempts, err)
+}
+
+func (r *RetryNotifier) attempt(ctx context.Context, alert Alert) error {
+	if r.policy.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.policy.AttemptTimeout)
+		defer cancel()
+	}
+	return r.notifier.Notify(ctx, alert)
+}
@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// flakyNotifier fails until it has been called failures times.
+type flakyNotifier struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyNotifier) Name() string { return ChannelWebhook }
+
+func (f *flakyNotifier) Notify(ctx context.Context, alert Alert) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return f.err
+	}
+	return nil
+}
+
+func fastPolicy(attempts int) RetryPolicy {
+	return RetryPolicy{MaxAttempts: attempts, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 6, InitialBackoff: 500 * time.Millisecond, MaxBackoff: 3 * time.Second}
+
+	expected := []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	for retry, want := range expected {
+		if got := policy.Backoff(retry + 1); got != want {
+			t.Errorf("expected backoff %v before retry %d, got %v", want, retry+1, got)
+		}
+	}
+
+	policy = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Minute, AttemptTimeout: 10 * time.Second}
+	if budget := policy.Budget(); budget != 33*time.Second {
+		t.Errorf("expected budget of 3 attempts plus 1s and 2s backoff, got %v", budget)
+	}
+}
+
+func TestRetryNotifier(t *testing.T) {
+	unavailable := errors.New("unexpected status 503")
+
+	t.Run("succeeds after transient failures", func(t *testing.T) {
+		flaky := &flakyNotifier{failures: 2, err: unavailable}
+		if err := NewRetryNotifier(flaky, fastPolicy(3)).Notify(context.Background(), testAlert()); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if flaky.calls != 3 {
+			t.Errorf("expected 3 attempts, got %d", flaky.calls)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		flaky := &flakyNotifier{failures: 10, err: unavailable}
+		err := NewRetryNotifier(flaky, fastPolicy(3)).Notify(context.Background(), testAlert())
+		if !errors.Is(err, unavailable) {
+			t.Fatalf("expected last error to be wrapped, got %v", err)
+		}
+		if flaky.calls != 3 {
+			t.Errorf("expected 3 attempts, got %d", flaky.calls)
+		}
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		flaky := &flakyNotifier{failures: 10, err: Permanent(errors.New("unexpected status 400"))}
+		if err := NewRetryNotifier(flaky, fastPolicy(3)).Notify(context.Background(), testAlert()); err == nil {
+			t.Fatal("expected error")
+		}
+		if flaky.calls != 1 {
+			t.Errorf("expected a single attempt, got %d", flaky.calls)
+		}
+	})
+
+	t.Run("stops when the context ends", func(t *testing.T) {
+		flaky := &flakyNotifier{failures: 10, err: unavailable}
+		policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if err := NewRetryNotifier(flaky, policy).Notify(ctx, testAlert()); !errors.Is(err, unavailable) {
+			t.Fatalf("expected last error after cancellation, got %v", err)
+		}
+		if flaky.calls != 1 {
+			t.Errorf("expected no retry after the deadline, got %d attempts", flaky.calls)
+		}
+	})
+}
+
+func TestPostJSON_ClientErrorsArePermanent(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusNotFound, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusServiceUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		server, _, _ := recordingServer(t, tt.status)
+		err := NewWebhookNotifier(server.URL, server.Client()).Notify(context.Background(), testAlert())
+		if IsPermanent(err) != tt.permanent {
+			t.Errorf("expected status %d permanent=%v, got %v", tt.status, tt.permanent, err)
+		}
+	}
+}
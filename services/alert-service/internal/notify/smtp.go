@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPNotifier emails alerts in their formatted content type, plain text by
+// default. STARTTLS is used whenever the server offers it, and credentials
+// are only sent when a username is set.
+type SMTPNotifier struct {
+	host     string
+	addr     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func NewSMTPNotifier(host string, port int, username, password, from string, to []string) *SMTPNotifier {
+	return &SMTPNotifier{
+		host:     host,
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		username: username,
+		password: password,
+		from:     from,
+		to:       to,
+	}
+}
+
+func (s *SMTPNotifier) Name() string {
+	return ChannelSMTP
+}
+
+func (s *SMTPNotifier) Notify(ctx context.Context, alert Alert) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	for _, recipient := range s.to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("failed to add recipient %s: %w", recipient, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := writer.Write(s.message(alert)); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (s *SMTPNotifier) message(alert Alert) []byte {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("From: %s\r\n", s.from))
+	builder.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(s.to, ", ")))
+	builder.WriteString(fmt.Sprintf("Subject: %s\r\n", alert.Subject))
+	builder.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	contentType := alert.ContentType
+	if contentType == "" {
+		contentType = ContentTypePlain
+	}
+	builder.WriteString(fmt.Sprintf("Content-Type: %s\r\n", contentType))
+	builder.WriteString("\r\n")
+	builder.WriteString(strings.ReplaceAll(alert.Body, "\n", "\r\n"))
+
+	return []byte(builder.String())
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer speaks just enough SMTP to accept one message and returns
+// the envelope and data it received.
+type fakeSMTPServer struct {
+	listener   net.Listener
+	from       string
+	recipients []string
+	data       string
+	done       chan struct{}
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeSMTPServer{listener: listener, done: make(chan struct{})}
+	go server.serve()
+	return server
+}
+
+func (f *fakeSMTPServer) port() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSMTPServer) serve() {
+	defer close(f.done)
+
+	conn, err := f.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ESMTP")
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch command {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 localhost")
+		case "MAIL":
+			f.from = strings.TrimPrefix(line, "MAIL FROM:")
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			f.recipients = append(f.recipients, strings.TrimPrefix(line, "RCPT TO:"))
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotLines()
+			if err != nil {
+				return
+			}
+			f.data = strings.Join(data, "\n")
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	server := newFakeSMTPServer(t)
+
+	notifier := NewSMTPNotifier("127.0.0.1", server.port(), "", "", "alerts@example.com", []string{"desk@example.com", "ops@example.com"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := notifier.Notify(ctx, testAlert()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	<-server.done
+
+	if server.from != "<alerts@example.com>" {
+		t.Errorf("expected sender alerts@example.com, got %s", server.from)
+	}
+	if len(server.recipients) != 2 {
+		t.Errorf("expected 2 recipients, got %v", server.recipients)
+	}
+	if !strings.Contains(server.data, "Subject: BTC bullish volume_spike signal (strong)") {
+		t.Errorf("expected subject header, got:\n%s", server.data)
+	}
+	if !strings.Contains(server.data, "Symbol: BTC") {
+		t.Errorf("expected alert body, got:\n%s", server.data)
+	}
+	if !strings.Contains(server.data, "Content-Type: text/plain; charset=UTF-8") {
+		t.Errorf("expected plain text by default, got:\n%s", server.data)
+	}
+}
+
+func TestSMTPNotifier_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	notifier := NewSMTPNotifier("127.0.0.1", port, "", "", "alerts@example.com", []string{"desk@example.com"})
+	if err := notifier.Notify(context.Background(), testAlert()); err == nil {
+		t.Errorf("expected error for unreachable server on port %d", port)
+	}
+}
@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"crypto-trackers/pkg/events"
+)
+
+const (
+	ChannelConsole  = "console"
+	ChannelWebhook  = "webhook"
+	ChannelSlack    = "slack"
+	ChannelSMTP     = "smtp"
+	ChannelTelegram = "telegram"
+
+	DefaultTelegramAPIURL = "https://api.telegram.org"
+)
+
+// WebhookNotifier posts the signal as JSON, with the formatted alert in
+// "message", to an arbitrary HTTP endpoint. Digests are posted as
+// {"digest": ..., "message": ...}.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+type webhookPayload struct {
+	*events.TradingSignal
+	Message string `json:"message"`
+}
+
+type webhookDigestPayload struct {
+	Digest  *Digest `json:"digest"`
+	Message string  `json:"message"`
+}
+
+func NewWebhookNotifier(url string, client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: client}
+}
+
+func (w *WebhookNotifier) Name() string {
+	return ChannelWebhook
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	if alert.Digest != nil {
+		return postJSON(ctx, w.client, w.url, webhookDigestPayload{Digest: alert.Digest, Message: alert.Body})
+	}
+	return postJSON(ctx, w.client, w.url, webhookPayload{TradingSignal: alert.Signal, Message: alert.Body})
+}
+
+// SlackNotifier posts to a Slack-compatible incoming webhook, which also
+// covers Mattermost and Discord's /slack endpoint. Plain text alerts are
+// sent as a code block under a bold subject; other formats are sent as
+// rendered.
+type SlackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewSlackNotifier(url string, client *http.Client) *SlackNotifier {
+	return &SlackNotifier{url: url, client: client}
+}
+
+func (s *SlackNotifier) Name() string {
+	return ChannelSlack
+}
+
+func (s *SlackNotifier) Notify(ctx context.Context, alert Alert) error {
+	text := alert.Body
+	if alert.ContentType == "" || alert.ContentType == ContentTypePlain {
+		text = fmt.Sprintf("*%s*\n```\n%s```", alert.Subject, alert.Body)
+	}
+	return postJSON(ctx, s.client, s.url, map[string]string{"text": text})
+}
+
+// TelegramNotifier sends through a Telegram-style bot API's sendMessage
+// method. apiURL is configurable so self-hosted bot API servers work too.
+type TelegramNotifier struct {
+	apiURL string
+	token  string
+	chatID string
+	client *http.Client
+}
+
+func NewTelegramNotifier(apiURL, token, chatID string, client *http.Client) *TelegramNotifier {
+	if apiURL == "" {
+		apiURL = DefaultTelegramAPIURL
+	}
+	return &TelegramNotifier{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		token:  token,
+		chatID: chatID,
+		client: client,
+	}
+}
+
+func (t *TelegramNotifier) Name() string {
+	return ChannelTelegram
+}
+
+func (t *TelegramNotifier) Notify(ctx context.Context, alert Alert) error {
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", t.apiURL, t.token)
+	return postJSON(ctx, t.client, endpoint, map[string]string{
+		"chat_id": t.chatID,
+		"text":    alert.Subject + "\n\n" + alert.Body,
+	})
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL can carry credentials (Telegram tokens, Slack webhook
+		// paths), so report only the failure itself.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+		// Other client errors mean the request itself is wrong, so sending
+		// it again will not help.
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return Permanent(err)
+		}
+		return err
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
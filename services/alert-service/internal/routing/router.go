@@ -0,0 +1,134 @@
+package routing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+const DefaultReloadInterval = 30 * time.Second
+
+// Router maps signals to the channels of every rule they match. The rules
+// file is re-read by Watch, and a file that fails to load leaves the
+// previous rules in place.
+type Router struct {
+	path     string
+	mutex    sync.RWMutex
+	rules    []Rule
+	contents []byte
+}
+
+func NewRouter(path string) (*Router, error) {
+	router := &Router{path: path}
+	if _, err := router.Reload(); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
+
+// Route returns the channels for signal in rule order without duplicates.
+// An empty result means no rule matched and the signal should be dropped.
+func (r *Router) Route(signal *events.TradingSignal) []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var channels []string
+	seen := make(map[string]bool)
+	for i := range r.rules {
+		if !r.rules[i].Matches(signal) {
+			continue
+		}
+		for _, channel := range r.rules[i].Channels {
+			if !seen[channel] {
+				seen[channel] = true
+				channels = append(channels, channel)
+			}
+		}
+	}
+	return channels
+}
+
+// Channels lists every channel referenced by the current rules.
+func (r *Router) Channels() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var channels []string
+	seen := make(map[string]bool)
+	for _, rule := range r.rules {
+		for _, channel := range rule.Channels {
+			if !seen[channel] {
+				seen[channel] = true
+				channels = append(channels, channel)
+			}
+		}
+	}
+	return channels
+}
+
+// Reload re-reads the rules file and reports whether it changed.
+func (r *Router) Reload() (bool, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read routing rules: %w", err)
+	}
+
+	r.mutex.RLock()
+	unchanged := r.contents != nil && bytes.Equal(data, r.contents)
+	r.mutex.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	rules, err := Parse(data)
+	if err != nil {
+		return false, err
+	}
+
+	r.mutex.Lock()
+	r.rules = rules
+	r.contents = data
+	r.mutex.Unlock()
+
+	return true, nil
+}
+
+// Watch polls the rules file until ctx is done. Polling rather than file
+// notifications copes with the symlink swap Kubernetes uses to update
+// mounted ConfigMaps.
+func (r *Router) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.Reload()
+			if err != nil {
+				log.Printf("Keeping previous routing rules: %v", err)
+				continue
+			}
+			if changed {
+				log.Printf("Reloaded %d routing rules from %s", r.Len(), r.path)
+			}
+		}
+	}
+}
+
+func (r *Router) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return len(r.rules)
+}
@@ -0,0 +1,108 @@
+package routing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"crypto-trackers/pkg/events"
+)
+
+func writeRules(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+}
+
+func TestRouter_Route(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routing.yaml")
+	writeRules(t, path, teamRules)
+
+	router, err := NewRouter(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	spike := &events.TradingSignal{Symbol: "BTC", SignalType: "volume_spike", SignalStrength: "strong",
+		Direction: "bullish", Details: map[string]interface{}{"spike_multiplier": 3.0}}
+	if channels := router.Route(spike); !reflect.DeepEqual(channels, []string{"telegram", "slack", "smtp"}) {
+		t.Errorf("expected channels of both matching rules in order, got %v", channels)
+	}
+
+	cross := &events.TradingSignal{Symbol: "ETH", SignalType: "moving_average_crossover", SignalStrength: "strong", Direction: "bearish"}
+	if channels := router.Route(cross); !reflect.DeepEqual(channels, []string{"slack", "smtp"}) {
+		t.Errorf("expected slack once, got %v", channels)
+	}
+
+	unmatched := &events.TradingSignal{Symbol: "DOGE", SignalType: "rsi_threshold", SignalStrength: "weak", Direction: "bullish"}
+	if channels := router.Route(unmatched); len(channels) != 0 {
+		t.Errorf("expected no channels, got %v", channels)
+	}
+
+	if channels := router.Channels(); !reflect.DeepEqual(channels, []string{"telegram", "slack", "smtp"}) {
+		t.Errorf("expected every referenced channel, got %v", channels)
+	}
+}
+
+func TestRouter_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routing.yaml")
+	writeRules(t, path, "rules:\n  - match: {symbol: BTC}\n    channels: [slack]\n")
+
+	router, err := NewRouter(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	doge := &events.TradingSignal{Symbol: "DOGE"}
+	if len(router.Route(doge)) != 0 {
+		t.Fatal("expected DOGE to be unrouted initially")
+	}
+
+	if changed, err := router.Reload(); err != nil || changed {
+		t.Errorf("expected unchanged file not to reload, got changed=%v err=%v", changed, err)
+	}
+
+	writeRules(t, path, "rules:\n  - match: {symbol: [BTC, DOGE]}\n    channels: [slack]\n")
+	if changed, err := router.Reload(); err != nil || !changed {
+		t.Fatalf("expected reload, got changed=%v err=%v", changed, err)
+	}
+	if len(router.Route(doge)) != 1 {
+		t.Error("expected DOGE to be routed after reload")
+	}
+
+	writeRules(t, path, "rules:\n  - match: {symbol: BTC}\n")
+	if _, err := router.Reload(); err == nil {
+		t.Error("expected invalid rules to fail")
+	}
+	if len(router.Route(doge)) != 1 {
+		t.Error("expected previous rules to stay in place after a failed reload")
+	}
+}
+
+func TestRouter_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routing.yaml")
+	writeRules(t, path, "rules: []\n")
+
+	router, err := NewRouter(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go router.Watch(ctx, 5*time.Millisecond)
+
+	writeRules(t, path, "rules:\n  - channels: [console]\n")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for router.Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected Watch to pick up the new rules")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,193 @@
+package routing
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"crypto-trackers/pkg/events"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StringList accepts either a single YAML scalar or a sequence, so a match
+// can be written as `symbol: BTC` or `symbol: [BTC, ETH]`.
+type StringList []string
+
+func (l *StringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = StringList{value.Value}
+		return nil
+	}
+
+	var values []string
+	if err := value.Decode(&values); err != nil {
+		return err
+	}
+	*l = values
+	return nil
+}
+
+func (l StringList) matches(value string) bool {
+	if len(l) == 0 {
+		return true
+	}
+	for _, candidate := range l {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match selects signals. Empty fields match anything; values within a field
+// are alternatives and all fields must match.
+type Match struct {
+	Symbol         StringList `yaml:"symbol" json:"symbol,omitempty"`
+	SignalType     StringList `yaml:"signal_type" json:"signal_type,omitempty"`
+	SignalStrength StringList `yaml:"signal_strength" json:"signal_strength,omitempty"`
+	Direction      StringList `yaml:"direction" json:"direction,omitempty"`
+	// Details holds numeric conditions such as "spike_multiplier > 2".
+	Details []string `yaml:"details" json:"details,omitempty"`
+}
+
+type Rule struct {
+	Name     string     `yaml:"name" json:"name"`
+	Match    Match      `yaml:"match" json:"match"`
+	Channels StringList `yaml:"channels" json:"channels"`
+
+	conditions []Condition
+}
+
+func (r *Rule) Matches(signal *events.TradingSignal) bool {
+	if !r.Match.Symbol.matches(signal.Symbol) ||
+		!r.Match.SignalType.matches(signal.SignalType) ||
+		!r.Match.SignalStrength.matches(signal.SignalStrength) ||
+		!r.Match.Direction.matches(signal.Direction) {
+		return false
+	}
+
+	for _, condition := range r.conditions {
+		if !condition.Matches(signal.Details) {
+			return false
+		}
+	}
+	return true
+}
+
+type File struct {
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+func Load(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routing rules: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse reads a YAML or JSON rules document. Unnamed rules are named after
+// their position, channel names are lower-cased and detail conditions are
+// compiled.
+func Parse(data []byte) ([]Rule, error) {
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse routing rules: %w", err)
+	}
+
+	for i := range file.Rules {
+		rule := &file.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if len(rule.Channels) == 0 {
+			return nil, fmt.Errorf("routing rule %q has no channels", rule.Name)
+		}
+		for j, channel := range rule.Channels {
+			rule.Channels[j] = strings.ToLower(strings.TrimSpace(channel))
+		}
+
+		for _, expr := range rule.Match.Details {
+			condition, err := ParseCondition(expr)
+			if err != nil {
+				return nil, fmt.Errorf("routing rule %q: %w", rule.Name, err)
+			}
+			rule.conditions = append(rule.conditions, condition)
+		}
+	}
+
+	return file.Rules, nil
+}
+
+// Condition compares a numeric Details value against a constant. Signals
+// without the field, or with a non-numeric value, never match.
+type Condition struct {
+	Field string
+	Op    string
+	Value float64
+}
+
+// Two-character operators come first so ">=" is not read as ">".
+var operators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+func ParseCondition(expr string) (Condition, error) {
+	for _, op := range operators {
+		field, value, found := strings.Cut(expr, op)
+		if !found {
+			continue
+		}
+
+		field = strings.TrimSpace(field)
+		if field == "" {
+			return Condition{}, fmt.Errorf("condition %q has no field", expr)
+		}
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return Condition{}, fmt.Errorf("condition %q must compare against a number", expr)
+		}
+		return Condition{Field: field, Op: op, Value: number}, nil
+	}
+
+	return Condition{}, fmt.Errorf("condition %q needs one of %s", expr, strings.Join(operators, " "))
+}
+
+func (c Condition) Matches(details map[string]interface{}) bool {
+	actual, ok := toFloat(details[c.Field])
+	if !ok {
+		return false
+	}
+
+	switch c.Op {
+	case ">":
+		return actual > c.Value
+	case ">=":
+		return actual >= c.Value
+	case "<":
+		return actual < c.Value
+	case "<=":
+		return actual <= c.Value
+	case "==":
+		return actual == c.Value
+	case "!=":
+		return actual != c.Value
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
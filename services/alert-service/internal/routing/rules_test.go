@@ -0,0 +1,124 @@
+package routing
+
+import (
+	"testing"
+
+	"crypto-trackers/pkg/events"
+)
+
+const teamRules = `
+rules:
+  - name: whale volume
+    match:
+      signal_type: volume_spike
+      details:
+        - spike_multiplier > 2
+    channels: [telegram]
+  - name: majors
+    match:
+      symbol: [BTC, eth]
+      signal_strength: [strong, medium]
+    channels: [slack, smtp]
+  - name: bearish crosses
+    match:
+      signal_type: moving_average_crossover
+      direction: bearish
+    channels: [slack]
+`
+
+func TestParse(t *testing.T) {
+	rules, err := Parse([]byte(teamRules))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 rules, got %d", len(rules))
+	}
+	if len(rules[0].Match.SignalType) != 1 || rules[0].Match.SignalType[0] != "volume_spike" {
+		t.Errorf("expected a scalar signal_type to become a one-item list, got %v", rules[0].Match.SignalType)
+	}
+
+	invalid := map[string]string{
+		"no channels":       "rules:\n  - match: {symbol: BTC}\n",
+		"bad operator":      "rules:\n  - match: {details: [\"spike_multiplier ~ 2\"]}\n    channels: [slack]\n",
+		"non-numeric value": "rules:\n  - match: {details: [\"spike_multiplier > high\"]}\n    channels: [slack]\n",
+	}
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse([]byte(data)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		expr    string
+		details map[string]interface{}
+		matches bool
+	}{
+		{"spike_multiplier > 2", map[string]interface{}{"spike_multiplier": 2.5}, true},
+		{"spike_multiplier > 2", map[string]interface{}{"spike_multiplier": 2.0}, false},
+		{"spike_multiplier >= 2", map[string]interface{}{"spike_multiplier": 2.0}, true},
+		{"rsi<=30", map[string]interface{}{"rsi": 28.4}, true},
+		{"rsi < 30", map[string]interface{}{"rsi": 30}, false},
+		{"period == 20", map[string]interface{}{"period": 20}, true},
+		{"period != 20", map[string]interface{}{"period": 50}, true},
+		{"spike_multiplier > 2", map[string]interface{}{}, false},
+		{"spike_multiplier > 2", map[string]interface{}{"spike_multiplier": "3"}, false},
+	}
+
+	for _, tt := range tests {
+		condition, err := ParseCondition(tt.expr)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", tt.expr, err)
+		}
+		if got := condition.Matches(tt.details); got != tt.matches {
+			t.Errorf("expected %q against %v to be %v", tt.expr, tt.details, tt.matches)
+		}
+	}
+}
+
+func TestRule_Matches(t *testing.T) {
+	rules, err := Parse([]byte(teamRules))
+	if err != nil {
+		t.Fatalf("failed to parse rules: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		signal   *events.TradingSignal
+		expected []bool
+	}{
+		{
+			name: "large volume spike on a major",
+			signal: &events.TradingSignal{Symbol: "ETH", SignalType: "volume_spike", SignalStrength: "strong",
+				Direction: "bullish", Details: map[string]interface{}{"spike_multiplier": 2.4}},
+			expected: []bool{true, true, false},
+		},
+		{
+			name: "small volume spike on an altcoin",
+			signal: &events.TradingSignal{Symbol: "PEPE", SignalType: "volume_spike", SignalStrength: "weak",
+				Direction: "neutral", Details: map[string]interface{}{"spike_multiplier": 1.4}},
+			expected: []bool{false, false, false},
+		},
+		{
+			name: "weak death cross on BTC",
+			signal: &events.TradingSignal{Symbol: "BTC", SignalType: "moving_average_crossover", SignalStrength: "weak",
+				Direction: "Bearish"},
+			expected: []bool{false, false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range rules {
+				if got := rules[i].Matches(tt.signal); got != tt.expected[i] {
+					t.Errorf("expected rule %q to match=%v, got %v", rules[i].Name, tt.expected[i], got)
+				}
+			}
+		})
+	}
+}
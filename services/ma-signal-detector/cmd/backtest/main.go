@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"crypto-trackers/pkg/backtest"
+	"ma-signal-detector/internal/signals"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func main() {
+	maType := flag.String("ma-type", "sma", "moving average type: sma, ema or wma")
+	fastPeriod := flag.Int("fast", signals.DefaultFastPeriod, "fast moving average period")
+	slowPeriod := flag.Int("slow", signals.DefaultSlowPeriod, "slow moving average period")
+	format := flag.String("format", backtest.FormatText, "output format: text or json")
+	verbose := flag.Bool("verbose", false, "keep detector logs")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] prices.csv|prices.jsonl ...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := backtest.CheckFormat(*format); err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "Invalid -format: %v\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	settings, err := signals.NewMASettings(*maType, *fastPeriod, *slowPeriod)
+	if err != nil {
+		log.Fatalf("Invalid moving average settings: %v", err)
+	}
+
+	events, err := backtest.LoadEvents(flag.Args())
+	if err != nil {
+		log.Fatalf("Failed to load price events: %v", err)
+	}
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	recorder := backtest.NewSignalRecorder()
+	detector := signals.NewMADetector(
+		recorder,
+		settings,
+		*prometheus.NewCounterVec(prometheus.CounterOpts{Name: "price_events_processed_total"}, []string{"symbol"}),
+		*prometheus.NewCounterVec(prometheus.CounterOpts{Name: "trading_signals_generated_total"}, []string{"symbol", "type"}),
+		*prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "price_event_processing_seconds"}, []string{"symbol"}),
+	)
+
+	if err := backtest.Run(events, detector.ProcessPriceEvent); err != nil {
+		fmt.Fprintf(os.Stderr, "Backtest failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := backtest.Evaluate(events, recorder.Signals(), backtest.DefaultHorizons)
+
+	if err := report.Write(os.Stdout, *format, backtest.DefaultHorizons); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		os.Exit(1)
+	}
+}
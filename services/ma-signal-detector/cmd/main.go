@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -11,9 +12,13 @@ import (
 	"syscall"
 	"time"
 
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio"
+	"crypto-trackers/pkg/overrides"
+	"crypto-trackers/pkg/wire"
 	"ma-signal-detector/internal/config"
-	"ma-signal-detector/internal/kafka"
 	"ma-signal-detector/internal/signals"
+	"ma-signal-detector/internal/state"
 
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
@@ -50,20 +55,32 @@ var (
 		},
 		[]string{"symbol"},
 	)
+	poisonMessages = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_poison_messages_total",
+			Help: "Total number of consumed messages that could not be decoded",
+		},
+		[]string{"topic"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(priceEventsProcessed)
 	prometheus.MustRegister(signalsGenerated)
 	prometheus.MustRegister(processingTime)
+	prometheus.MustRegister(poisonMessages)
 }
 
 type Server struct {
-	config   *config.Config
-	ready    bool
-	consumer *kafka.Consumer
-	producer kafka.SignalProducer
-	detector *signals.MADetector
+	config     *config.Config
+	ready      bool
+	consumer   *kafkaio.Consumer[events.PriceEvent]
+	producer   kafkaio.SignalProducer
+	detector   *signals.MADetector
+	store      state.Store
+	brokers    []string
+	codec      *wire.Codec
+	restoredAt time.Time
 }
 
 func NewServer(cfg *config.Config) *Server {
@@ -92,27 +109,139 @@ func (s *Server) setReady(ready bool) {
 	s.ready = ready
 }
 
+func (s *Server) initializeState() error {
+	store, err := state.NewStore(s.config.StateStore, s.config.StatePath)
+	if err != nil {
+		return err
+	}
+	if store == nil {
+		return nil
+	}
+	s.store = store
+
+	snapshot, err := store.Load()
+	if err != nil {
+		return err
+	}
+	s.detector.Restore(snapshot)
+	if snapshot != nil {
+		s.restoredAt = snapshot.SavedAt
+	}
+
+	return nil
+}
+
+func (s *Server) bootstrap(ctx context.Context) error {
+	if !s.config.BootstrapEnabled {
+		return nil
+	}
+
+	since := time.Now().Add(-time.Duration(s.config.BootstrapLookback) * time.Hour)
+	if s.restoredAt.After(since) {
+		since = s.restoredAt
+	}
+
+	s.detector.SetWarmingUp(true)
+	defer s.detector.SetWarmingUp(false)
+
+	log.Printf("Warming up from crypto-prices since %s", since.Format(time.RFC3339))
+	result, err := kafkaio.Replay(ctx, s.brokers, events.TopicPrices, since, s.codec, s.detector.ProcessPriceEvent)
+	if err != nil {
+		return err
+	}
+	s.consumer.SkipUntil(result.EndOffsets)
+
+	log.Printf("Warm-up complete: replayed %d price events", result.Events)
+	return nil
+}
+
+func (s *Server) saveState() {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.Save(s.detector.Snapshot()); err != nil {
+		log.Printf("Failed to save state snapshot: %v", err)
+		return
+	}
+	log.Println("Saved state snapshot")
+}
+
+// applySymbolOverrides builds per-symbol settings, taking any field the
+// override leaves unset from the service-wide configuration.
+func (s *Server) applySymbolOverrides(settings *signals.MASettings) error {
+	symbols, err := overrides.Load[config.SymbolOverride](s.config.SymbolOverridesPath)
+	if err != nil {
+		return err
+	}
+
+	settings.Overrides = make(map[string]signals.MASettings)
+	for symbol, override := range symbols {
+		if override.MAType == nil && override.MAFastPeriod == nil && override.MASlowPeriod == nil {
+			continue
+		}
+
+		maType, fast, slow := s.config.MAType, s.config.MAFastPeriod, s.config.MASlowPeriod
+		if override.MAType != nil {
+			maType = *override.MAType
+		}
+		if override.MAFastPeriod != nil {
+			fast = *override.MAFastPeriod
+		}
+		if override.MASlowPeriod != nil {
+			slow = *override.MASlowPeriod
+		}
+
+		symbolSettings, err := signals.NewMASettings(maType, fast, slow)
+		if err != nil {
+			return fmt.Errorf("invalid moving average override for %s: %w", symbol, err)
+		}
+		settings.Overrides[symbol] = symbolSettings
+		log.Printf("Using %s %d/%d for %s", strings.ToUpper(maType), fast, slow, symbol)
+	}
+
+	return nil
+}
+
 func (s *Server) initializeKafka() error {
 	brokers := strings.Split(s.config.KafkaBootstrapServers, ",")
+	s.brokers = brokers
 
-	producer, err := kafka.NewProducer(brokers)
+	codec, err := wire.NewCodec(s.config.KafkaWireFormat, wire.NewRegistry(s.config.SchemaRegistryURL))
 	if err != nil {
 		return err
 	}
-	s.producer = producer
+	s.codec = codec
 
-	detector := signals.NewMADetector(producer, *priceEventsProcessed, *signalsGenerated, *processingTime)
-	s.detector = detector
+	settings, err := signals.NewMASettings(s.config.MAType, s.config.MAFastPeriod, s.config.MASlowPeriod)
+	if err != nil {
+		return err
+	}
+	if err := s.applySymbolOverrides(&settings); err != nil {
+		return err
+	}
 
-	consumer, err := kafka.NewConsumer(
-		brokers,
-		s.config.KafkaGroupID,
-		[]string{"crypto-prices"},
-		detector.ProcessPriceEvent,
-	)
+	producer, err := kafkaio.NewProducer(brokers, codec)
+	if err != nil {
+		return err
+	}
+
+	consumerSettings := kafkaio.DetectorSettings{
+		GroupID:         s.config.KafkaGroupID,
+		RetryTopic:      s.config.ConsumerRetryTopic,
+		Retries:         s.config.ConsumerMaxRetries,
+		Backoff:         time.Duration(s.config.ConsumerRetryBackoff) * time.Millisecond,
+		DeadLetterTopic: s.config.ConsumerDLQTopic,
+		PoisonQueue:     s.config.ConsumerPoisonDLQ,
+	}
+	outbox, consumer, err := kafkaio.NewDetectorConsumer(brokers, codec, producer, consumerSettings, *poisonMessages,
+		func(publisher kafkaio.SignalProducer) func(*events.PriceEvent) error {
+			s.detector = signals.NewMADetector(publisher, settings, *priceEventsProcessed, *signalsGenerated, *processingTime)
+			return s.detector.ProcessPriceEvent
+		})
 	if err != nil {
 		return err
 	}
+	s.producer = outbox
 	s.consumer = consumer
 
 	return nil
@@ -120,6 +249,9 @@ func (s *Server) initializeKafka() error {
 
 func main() {
 	cfg := config.New()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 	server := NewServer(cfg)
 
 	router := mux.NewRouter()
@@ -145,9 +277,21 @@ func main() {
 		log.Fatalf("Failed to initialize Kafka: %v", err)
 	}
 
+	if err := server.initializeState(); err != nil {
+		log.Fatalf("Failed to restore state: %v", err)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if err := server.bootstrap(ctx); err != nil {
+		log.Printf("Warm-up failed, starting with partial history: %v", err)
+	}
+
+	if server.store != nil {
+		go state.SaveEvery(ctx, server.store, server.detector, time.Duration(cfg.SnapshotInterval)*time.Second)
+	}
+
 	go func() {
 		if err := server.consumer.Start(ctx); err != nil {
 			log.Printf("Consumer error: %v", err)
@@ -155,7 +299,7 @@ func main() {
 	}()
 
 	server.setReady(true)
-	log.Println("MA Signal Detector is ready and consuming messages")
+	log.Printf("MA Signal Detector is ready and consuming messages (%s %d/%d)", strings.ToUpper(cfg.MAType), cfg.MAFastPeriod, cfg.MASlowPeriod)
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -170,6 +314,7 @@ func main() {
 	if server.consumer != nil {
 		server.consumer.Close()
 	}
+	server.saveState()
 	if server.producer != nil {
 		server.producer.Close()
 	}
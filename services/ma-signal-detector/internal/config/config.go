@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 )
@@ -8,17 +9,64 @@ import (
 type Config struct {
 	KafkaBootstrapServers string
 	KafkaGroupID          string
+	KafkaWireFormat       string
+	SchemaRegistryURL     string
+	ConsumerMaxRetries    int
+	ConsumerRetryBackoff  int
+	ConsumerDLQTopic      string
+	ConsumerRetryTopic    string
+	ConsumerPoisonDLQ     bool
 	Port                  string
 	LogLevel              string
+	MAType                string
+	MAFastPeriod          int
+	MASlowPeriod          int
+	SymbolOverridesPath   string
+	StateStore            string
+	StatePath             string
+	SnapshotInterval      int
+	BootstrapEnabled      bool
+	BootstrapLookback     int
+}
+
+// SymbolOverride sets a symbol's moving-average type and periods.
+type SymbolOverride struct {
+	MAType       *string `yaml:"ma_type" json:"ma_type,omitempty"`
+	MAFastPeriod *int    `yaml:"ma_fast_period" json:"ma_fast_period,omitempty"`
+	MASlowPeriod *int    `yaml:"ma_slow_period" json:"ma_slow_period,omitempty"`
 }
 
 func New() *Config {
 	return &Config{
 		KafkaBootstrapServers: getEnv("KAFKA_BOOTSTRAP_SERVERS", "kafka-service:9092"),
 		KafkaGroupID:          getEnv("KAFKA_GROUP_ID", "ma-signal-detector"),
+		KafkaWireFormat:       getEnv("KAFKA_WIRE_FORMAT", "json"),
+		SchemaRegistryURL:     getEnv("SCHEMA_REGISTRY_URL", ""),
+		ConsumerMaxRetries:    getEnvInt("CONSUMER_MAX_RETRIES", 3),
+		ConsumerRetryBackoff:  getEnvInt("CONSUMER_RETRY_BACKOFF_MS", 500),
+		ConsumerDLQTopic:      getEnv("CONSUMER_DLQ_TOPIC", "ma-signal-detector.dlq"),
+		ConsumerRetryTopic:    getEnv("CONSUMER_RETRY_TOPIC", "ma-signal-detector.retry"),
+		ConsumerPoisonDLQ:     getEnvBool("CONSUMER_POISON_DLQ_ENABLED", true),
 		Port:                  getEnv("PORT", "8080"),
 		LogLevel:              getEnv("LOG_LEVEL", "INFO"),
+		MAType:                getEnv("MA_TYPE", "sma"),
+		MAFastPeriod:          getEnvInt("MA_FAST_PERIOD", 20),
+		MASlowPeriod:          getEnvInt("MA_SLOW_PERIOD", 50),
+		SymbolOverridesPath:   getEnv("SYMBOL_OVERRIDES_PATH", ""),
+		StateStore:            getEnv("STATE_STORE", "none"),
+		StatePath:             getEnv("STATE_PATH", "/data/ma-state.json"),
+		SnapshotInterval:      getEnvInt("SNAPSHOT_INTERVAL_SECONDS", 60),
+		BootstrapEnabled:      getEnvBool("BOOTSTRAP_ENABLED", false),
+		BootstrapLookback:     getEnvInt("BOOTSTRAP_LOOKBACK_HOURS", 24),
+	}
+}
+
+// Validate rejects settings the service cannot start with.
+func (c *Config) Validate() error {
+	if c.SnapshotInterval <= 0 {
+		return fmt.Errorf("SNAPSHOT_INTERVAL_SECONDS must be positive, got %d", c.SnapshotInterval)
 	}
+	return nil
 }
 
 func getEnv(key, defaultValue string) string {
@@ -36,3 +84,12 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
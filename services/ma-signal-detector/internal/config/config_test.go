@@ -0,0 +1,36 @@
+package config
+
+import (
+	"testing"
+
+	"crypto-trackers/pkg/overrides"
+)
+
+func TestValidate(t *testing.T) {
+	cfg := New()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected defaults to be valid, got %v", err)
+	}
+
+	for _, interval := range []int{0, -5} {
+		cfg.SnapshotInterval = interval
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected snapshot interval %d to be rejected", interval)
+		}
+	}
+}
+
+func TestSymbolOverride(t *testing.T) {
+	symbols, err := overrides.Parse[SymbolOverride]([]byte(`{"symbols": {"btc": {"ma_type": "ema", "ma_fast_period": 50, "ma_slow_period": 200}, "ETH": {}}}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	btc := symbols["BTC"]
+	if btc.MAType == nil || *btc.MAType != "ema" || btc.MAFastPeriod == nil || *btc.MAFastPeriod != 50 || btc.MASlowPeriod == nil || *btc.MASlowPeriod != 200 {
+		t.Errorf("expected BTC EMA 50/200, got %+v", btc)
+	}
+	if eth := symbols["ETH"]; eth.MAType != nil || eth.MAFastPeriod != nil || eth.MASlowPeriod != nil {
+		t.Errorf("expected no ETH overrides, got %+v", eth)
+	}
+}
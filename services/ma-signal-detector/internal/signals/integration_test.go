@@ -1,7 +1,8 @@
 package signals
 
 import (
-	"ma-signal-detector/internal/kafka"
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio/kafkatest"
 	"testing"
 	"time"
 
@@ -9,7 +10,7 @@ import (
 )
 
 func TestMADetector_EndToEndIntegration(t *testing.T) {
-	producer := &mockProducer{}
+	producer := &kafkatest.SignalProducer{}
 
 	priceEventsProcessed := prometheus.NewCounterVec(
 		prometheus.CounterOpts{Name: "test_price_events_processed", Help: "test"},
@@ -24,17 +25,17 @@ func TestMADetector_EndToEndIntegration(t *testing.T) {
 		[]string{"symbol"},
 	)
 
-	detector := NewMADetector(producer, *priceEventsProcessed, *signalsGenerated, *processingTime)
+	detector := NewMADetector(producer, DefaultMASettings(), *priceEventsProcessed, *signalsGenerated, *processingTime)
 
 	t.Run("golden cross signal generation", func(t *testing.T) {
-		producer.signals = nil
-		goldDetector := NewMADetector(producer, *priceEventsProcessed, *signalsGenerated, *processingTime)
+		producer.Reset()
+		goldDetector := NewMADetector(producer, DefaultMASettings(), *priceEventsProcessed, *signalsGenerated, *processingTime)
 
 		basePrice := 50000.0
 
-		for i := 0; i < SMA50Period; i++ {
+		for i := 0; i < DefaultSlowPeriod; i++ {
 			price := basePrice
-			event := &kafka.PriceEvent{
+			event := &events.PriceEvent{
 				Timestamp: time.Now().Add(time.Duration(i) * time.Minute),
 				Symbol:    "GOLD",
 				PriceUSD:  price,
@@ -42,21 +43,21 @@ func TestMADetector_EndToEndIntegration(t *testing.T) {
 			goldDetector.ProcessPriceEvent(event)
 		}
 
-		for i := 0; i < SMA20Period+5; i++ {
+		for i := 0; i < DefaultFastPeriod+5; i++ {
 			price := basePrice + float64(i+1)*100
-			event := &kafka.PriceEvent{
-				Timestamp: time.Now().Add(time.Duration(SMA50Period+i) * time.Minute),
+			event := &events.PriceEvent{
+				Timestamp: time.Now().Add(time.Duration(DefaultSlowPeriod+i) * time.Minute),
 				Symbol:    "GOLD",
 				PriceUSD:  price,
 			}
 			goldDetector.ProcessPriceEvent(event)
 		}
 
-		if len(producer.signals) == 0 {
+		if len(producer.Signals()) == 0 {
 			t.Fatal("expected at least one signal")
 		}
 
-		signal := producer.signals[0]
+		signal := producer.Signals()[0]
 		if signal.Direction != "bullish" {
 			t.Errorf("expected bullish direction for golden cross, got %s", signal.Direction)
 		}
@@ -69,16 +70,16 @@ func TestMADetector_EndToEndIntegration(t *testing.T) {
 	})
 
 	t.Run("duplicate signal prevention", func(t *testing.T) {
-		initialSignalCount := len(producer.signals)
+		initialSignalCount := len(producer.Signals())
 
-		event := &kafka.PriceEvent{
+		event := &events.PriceEvent{
 			Timestamp: time.Now(),
 			Symbol:    "BTC",
 			PriceUSD:  50000.0,
 		}
 		detector.ProcessPriceEvent(event)
 
-		if len(producer.signals) != initialSignalCount {
+		if len(producer.Signals()) != initialSignalCount {
 			t.Error("duplicate signal should not be generated")
 		}
 	})
@@ -86,10 +87,11 @@ func TestMADetector_EndToEndIntegration(t *testing.T) {
 	t.Run("concurrent access safety", func(t *testing.T) {
 		done := make(chan bool)
 
+		start := time.Now()
 		for i := 0; i < 10; i++ {
 			go func(i int) {
-				event := &kafka.PriceEvent{
-					Timestamp: time.Now(),
+				event := &events.PriceEvent{
+					Timestamp: start.Add(time.Duration(i) * time.Second),
 					Symbol:    "CONCURRENT",
 					PriceUSD:  float64(50000 + i),
 				}
@@ -102,9 +104,17 @@ func TestMADetector_EndToEndIntegration(t *testing.T) {
 			<-done
 		}
 
+		// Events that lose the race to a newer one are dropped, so only
+		// the order of what was kept is certain
 		history := detector.priceHistory["CONCURRENT"]
-		if len(history.Prices) != 10 {
-			t.Errorf("expected 10 prices after concurrent access, got %d", len(history.Prices))
+		if len(history.Prices) == 0 || len(history.Prices) > 10 {
+			t.Errorf("expected 1 to 10 prices after concurrent access, got %d", len(history.Prices))
+		}
+		for i := 1; i < len(history.Prices); i++ {
+			if history.Prices[i] <= history.Prices[i-1] {
+				t.Errorf("expected prices in event-time order, got %v", history.Prices)
+				break
+			}
 		}
 	})
 }
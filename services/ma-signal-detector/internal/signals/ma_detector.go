@@ -2,9 +2,12 @@ package signals
 
 import (
 	"context"
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio"
 	"fmt"
 	"log"
-	"ma-signal-detector/internal/kafka"
+	"ma-signal-detector/internal/state"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,31 +15,38 @@ import (
 )
 
 const (
-	MaxHistorySize = 100
-	MinSignalSize  = 50
-	SMA20Period    = 20
-	SMA50Period    = 50
+	MaxHistorySize    = 100
+	DefaultFastPeriod = 20
+	DefaultSlowPeriod = 50
 )
 
+// PriceHistory holds a symbol's prices in event-time order. LastTimestamp
+// is the newest event applied; older or repeated events, such as a retried
+// tick arriving after newer ones, are dropped rather than appended out of
+// order.
 type PriceHistory struct {
-	Prices []float64
-	mutex  sync.RWMutex
+	Prices        []float64
+	LastTimestamp time.Time
+	mutex         sync.RWMutex
 }
 
 type MADetector struct {
 	priceHistory         map[string]*PriceHistory
 	lastSignals          map[string]string
+	settings             MASettings
+	warmingUp            bool
 	mutex                sync.RWMutex
-	producer             kafka.SignalProducer
+	producer             kafkaio.SignalProducer
 	priceEventsProcessed prometheus.CounterVec
 	signalsGenerated     prometheus.CounterVec
 	processingTime       prometheus.HistogramVec
 }
 
-func NewMADetector(producer kafka.SignalProducer, priceEventsProcessed prometheus.CounterVec, signalsGenerated prometheus.CounterVec, processingTime prometheus.HistogramVec) *MADetector {
+func NewMADetector(producer kafkaio.SignalProducer, settings MASettings, priceEventsProcessed prometheus.CounterVec, signalsGenerated prometheus.CounterVec, processingTime prometheus.HistogramVec) *MADetector {
 	return &MADetector{
 		priceHistory:         make(map[string]*PriceHistory),
 		lastSignals:          make(map[string]string),
+		settings:             settings,
 		producer:             producer,
 		priceEventsProcessed: priceEventsProcessed,
 		signalsGenerated:     signalsGenerated,
@@ -44,7 +54,15 @@ func NewMADetector(producer kafka.SignalProducer, priceEventsProcessed prometheu
 	}
 }
 
-func (ma *MADetector) ProcessPriceEvent(event *kafka.PriceEvent) error {
+// SetWarmingUp toggles warm-up mode, in which history and crossover state are
+// updated as usual but no signals are published.
+func (ma *MADetector) SetWarmingUp(warmingUp bool) {
+	ma.mutex.Lock()
+	defer ma.mutex.Unlock()
+	ma.warmingUp = warmingUp
+}
+
+func (ma *MADetector) ProcessPriceEvent(event *events.PriceEvent) error {
 	timer := prometheus.NewTimer(ma.processingTime.WithLabelValues(event.Symbol))
 	defer timer.ObserveDuration()
 
@@ -53,18 +71,27 @@ func (ma *MADetector) ProcessPriceEvent(event *kafka.PriceEvent) error {
 	ma.mutex.Lock()
 	defer ma.mutex.Unlock()
 
+	settings := ma.settings.ForSymbol(event.Symbol)
+	historySize := settings.HistorySize()
 	history, exists := ma.priceHistory[event.Symbol]
 	if !exists {
 		history = &PriceHistory{
-			Prices: make([]float64, 0, MaxHistorySize),
+			Prices: make([]float64, 0, historySize),
 		}
 		ma.priceHistory[event.Symbol] = history
 		log.Printf("Started tracking price history for %s", event.Symbol)
 	}
 
 	history.mutex.Lock()
+	if !event.Timestamp.After(history.LastTimestamp) {
+		history.mutex.Unlock()
+		log.Printf("Dropping price event for %s at %s, not after the last applied event at %s",
+			event.Symbol, event.Timestamp.Format(time.RFC3339), history.LastTimestamp.Format(time.RFC3339))
+		return nil
+	}
+	history.LastTimestamp = event.Timestamp
 	history.Prices = append(history.Prices, event.PriceUSD)
-	if len(history.Prices) > MaxHistorySize {
+	if len(history.Prices) > historySize {
 		history.Prices = history.Prices[1:]
 	}
 	priceCount := len(history.Prices)
@@ -72,92 +99,139 @@ func (ma *MADetector) ProcessPriceEvent(event *kafka.PriceEvent) error {
 
 	log.Printf("Processed price event for %s: $%.2f (history: %d points)", event.Symbol, event.PriceUSD, priceCount)
 
-	if priceCount >= MinSignalSize {
-		return ma.checkForCrossover(event.Symbol, event.Timestamp)
+	if priceCount >= settings.MinSignalSize() {
+		return ma.checkForCrossover(event.Symbol, event.Timestamp, settings)
 	}
 
 	return nil
 }
 
-func (ma *MADetector) checkForCrossover(symbol string, timestamp time.Time) error {
+func (ma *MADetector) checkForCrossover(symbol string, timestamp time.Time, settings MASettings) error {
 	history := ma.priceHistory[symbol]
 	history.mutex.RLock()
 	prices := make([]float64, len(history.Prices))
 	copy(prices, history.Prices)
 	history.mutex.RUnlock()
 
-	if len(prices) < SMA50Period {
+	if len(prices) < settings.MinSignalSize() {
 		return nil
 	}
 
-	currentSMA20 := calculateSMA(prices, SMA20Period)
-	currentSMA50 := calculateSMA(prices, SMA50Period)
-
-	if len(prices) < SMA50Period+1 {
-		return nil
-	}
+	average := settings.Average
+	currentFast := average.Calculate(prices, settings.FastPeriod)
+	currentSlow := average.Calculate(prices, settings.SlowPeriod)
 
 	prevPrices := prices[:len(prices)-1]
-	prevSMA20 := calculateSMA(prevPrices, SMA20Period)
-	prevSMA50 := calculateSMA(prevPrices, SMA50Period)
+	prevFast := average.Calculate(prevPrices, settings.FastPeriod)
+	prevSlow := average.Calculate(prevPrices, settings.SlowPeriod)
 
 	var signalType string
 	var direction string
 
-	if prevSMA20 <= prevSMA50 && currentSMA20 > currentSMA50 {
+	if prevFast <= prevSlow && currentFast > currentSlow {
 		signalType = "golden_cross"
 		direction = "bullish"
-	} else if prevSMA20 >= prevSMA50 && currentSMA20 < currentSMA50 {
+	} else if prevFast >= prevSlow && currentFast < currentSlow {
 		signalType = "death_cross"
 		direction = "bearish"
 	}
 
 	if signalType != "" && ma.lastSignals[symbol] != signalType {
 		ma.lastSignals[symbol] = signalType
+		if ma.warmingUp {
+			log.Printf("Suppressed %s signal for %s during warm-up", signalType, symbol)
+			return nil
+		}
 		ma.signalsGenerated.WithLabelValues(symbol, signalType).Inc()
-		return ma.publishSignal(symbol, timestamp, signalType, direction, currentSMA20, currentSMA50)
+		return ma.publishSignal(symbol, timestamp, settings, signalType, direction, currentFast, currentSlow)
 	}
 
 	return nil
 }
 
-func (ma *MADetector) publishSignal(symbol string, timestamp time.Time, crossoverType, direction string, sma20, sma50 float64) error {
-	signal := &kafka.TradingSignal{
+func (ma *MADetector) publishSignal(symbol string, timestamp time.Time, settings MASettings, crossoverType, direction string, fastMA, slowMA float64) error {
+	signal := &events.TradingSignal{
+		SchemaVersion:  events.SchemaVersion,
 		Timestamp:      timestamp,
 		Symbol:         symbol,
-		SignalType:     "moving_average_crossover",
 		SignalStrength: "strong",
 		Direction:      direction,
-		Details: map[string]interface{}{
-			"sma_20":         sma20,
-			"sma_50":         sma50,
-			"crossover_type": crossoverType,
-		},
-		ServiceID: "ma-detector-v1",
+		ServiceID:      "ma-detector-v1",
+	}
+	if err := signal.SetDetails(&events.MACrossoverDetails{
+		MAType:        settings.Average.Type(),
+		FastPeriod:    settings.FastPeriod,
+		SlowPeriod:    settings.SlowPeriod,
+		FastMA:        fastMA,
+		SlowMA:        slowMA,
+		CrossoverType: crossoverType,
+	}); err != nil {
+		return fmt.Errorf("failed to encode %s signal for %s: %w", crossoverType, symbol, err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := ma.producer.PublishSignal(ctx, "trading-signals", signal); err != nil {
+	if err := ma.producer.PublishSignal(ctx, events.TopicSignals, signal); err != nil {
 		log.Printf("Failed to publish signal for %s: %v", symbol, err)
 		return fmt.Errorf("failed to publish %s signal for %s: %w", crossoverType, symbol, err)
 	}
 
-	log.Printf("Published %s signal for %s (SMA20: %.2f, SMA50: %.2f)", crossoverType, symbol, sma20, sma50)
+	label := strings.ToUpper(settings.Average.Type())
+	log.Printf("Published %s signal for %s (%s%d: %.2f, %s%d: %.2f)", crossoverType, symbol,
+		label, settings.FastPeriod, fastMA, label, settings.SlowPeriod, slowMA)
 	return nil
 }
 
-func calculateSMA(prices []float64, period int) float64 {
-	if len(prices) < period {
-		return 0
+func (ma *MADetector) Snapshot() *state.Snapshot {
+	ma.mutex.RLock()
+	defer ma.mutex.RUnlock()
+
+	snapshot := &state.Snapshot{
+		SavedAt:        time.Now(),
+		PriceHistory:   make(map[string][]float64, len(ma.priceHistory)),
+		LastTimestamps: make(map[string]time.Time, len(ma.priceHistory)),
+		LastSignals:    make(map[string]string, len(ma.lastSignals)),
+	}
+
+	for symbol, history := range ma.priceHistory {
+		history.mutex.RLock()
+		prices := make([]float64, len(history.Prices))
+		copy(prices, history.Prices)
+		lastTimestamp := history.LastTimestamp
+		history.mutex.RUnlock()
+		snapshot.PriceHistory[symbol] = prices
+		snapshot.LastTimestamps[symbol] = lastTimestamp
 	}
 
-	sum := 0.0
-	start := len(prices) - period
-	for i := start; i < len(prices); i++ {
-		sum += prices[i]
+	for symbol, signalType := range ma.lastSignals {
+		snapshot.LastSignals[symbol] = signalType
+	}
+
+	return snapshot
+}
+
+func (ma *MADetector) Restore(snapshot *state.Snapshot) {
+	if snapshot == nil {
+		return
+	}
+
+	ma.mutex.Lock()
+	defer ma.mutex.Unlock()
+
+	for symbol, prices := range snapshot.PriceHistory {
+		historySize := ma.settings.ForSymbol(symbol).HistorySize()
+		if len(prices) > historySize {
+			prices = prices[len(prices)-historySize:]
+		}
+		restored := make([]float64, len(prices), historySize)
+		copy(restored, prices)
+		ma.priceHistory[symbol] = &PriceHistory{Prices: restored, LastTimestamp: snapshot.LastTimestamps[symbol]}
+	}
+
+	for symbol, signalType := range snapshot.LastSignals {
+		ma.lastSignals[symbol] = signalType
 	}
 
-	return sum / float64(period)
+	log.Printf("Restored state for %d symbols (snapshot saved at %s)", len(snapshot.PriceHistory), snapshot.SavedAt.Format(time.RFC3339))
 }
@@ -1,36 +1,16 @@
 package signals
 
 import (
-	"context"
-	"ma-signal-detector/internal/kafka"
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio/kafkatest"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-type mockProducer struct {
-	signals   []*kafka.TradingSignal
-	shouldErr bool
-}
-
-func (m *mockProducer) PublishSignal(ctx context.Context, topic string, signal *kafka.TradingSignal) error {
-	if ctx.Err() != nil {
-		return ctx.Err()
-	}
-	if m.shouldErr {
-		return context.DeadlineExceeded
-	}
-	m.signals = append(m.signals, signal)
-	return nil
-}
-
-func (m *mockProducer) Close() error {
-	return nil
-}
-
 func TestMADetector_ProcessPriceEvent(t *testing.T) {
-	producer := &mockProducer{}
+	producer := &kafkatest.SignalProducer{}
 
 	priceEventsProcessed := prometheus.NewCounterVec(
 		prometheus.CounterOpts{Name: "test_price_events_processed", Help: "test"},
@@ -45,10 +25,10 @@ func TestMADetector_ProcessPriceEvent(t *testing.T) {
 		[]string{"symbol"},
 	)
 
-	detector := NewMADetector(producer, *priceEventsProcessed, *signalsGenerated, *processingTime)
+	detector := NewMADetector(producer, DefaultMASettings(), *priceEventsProcessed, *signalsGenerated, *processingTime)
 
 	t.Run("first price event creates history", func(t *testing.T) {
-		event := &kafka.PriceEvent{
+		event := &events.PriceEvent{
 			Timestamp: time.Now(),
 			Symbol:    "BTC",
 			PriceUSD:  50000.0,
@@ -74,17 +54,18 @@ func TestMADetector_ProcessPriceEvent(t *testing.T) {
 	})
 
 	t.Run("insufficient data points no signal", func(t *testing.T) {
-		for i := 0; i < MinSignalSize-1; i++ {
-			event := &kafka.PriceEvent{
-				Timestamp: time.Now(),
+		start := time.Now()
+		for i := 0; i < DefaultMASettings().MinSignalSize()-1; i++ {
+			event := &events.PriceEvent{
+				Timestamp: start.Add(time.Duration(i) * time.Minute),
 				Symbol:    "ETH",
 				PriceUSD:  float64(3000 + i),
 			}
 			detector.ProcessPriceEvent(event)
 		}
 
-		if len(producer.signals) != 0 {
-			t.Errorf("expected no signals with insufficient data, got %d", len(producer.signals))
+		if len(producer.Signals()) != 0 {
+			t.Errorf("expected no signals with insufficient data, got %d", len(producer.Signals()))
 		}
 	})
 }
@@ -133,7 +114,7 @@ func TestCalculateSMA(t *testing.T) {
 }
 
 func TestMADetector_CrossoverDetection(t *testing.T) {
-	producer := &mockProducer{}
+	producer := &kafkatest.SignalProducer{}
 
 	priceEventsProcessed := prometheus.NewCounterVec(
 		prometheus.CounterOpts{Name: "test_price_events_processed", Help: "test"},
@@ -148,18 +129,18 @@ func TestMADetector_CrossoverDetection(t *testing.T) {
 		[]string{"symbol"},
 	)
 
-	detector := NewMADetector(producer, *priceEventsProcessed, *signalsGenerated, *processingTime)
+	detector := NewMADetector(producer, DefaultMASettings(), *priceEventsProcessed, *signalsGenerated, *processingTime)
 
-	prices := make([]float64, SMA50Period+5)
-	for i := 0; i < SMA50Period; i++ {
+	prices := make([]float64, DefaultSlowPeriod+5)
+	for i := 0; i < DefaultSlowPeriod; i++ {
 		prices[i] = 100.0
 	}
-	for i := SMA50Period; i < len(prices); i++ {
+	for i := DefaultSlowPeriod; i < len(prices); i++ {
 		prices[i] = 110.0
 	}
 
 	for i, price := range prices {
-		event := &kafka.PriceEvent{
+		event := &events.PriceEvent{
 			Timestamp: time.Now().Add(time.Duration(i) * time.Minute),
 			Symbol:    "TEST",
 			PriceUSD:  price,
@@ -167,11 +148,11 @@ func TestMADetector_CrossoverDetection(t *testing.T) {
 		detector.ProcessPriceEvent(event)
 	}
 
-	if len(producer.signals) == 0 {
+	if len(producer.Signals()) == 0 {
 		t.Error("expected at least one signal to be generated")
 	}
 
-	signal := producer.signals[0]
+	signal := producer.Signals()[0]
 	if signal.SignalType != "moving_average_crossover" {
 		t.Errorf("expected signal type 'moving_average_crossover', got %s", signal.SignalType)
 	}
@@ -180,3 +161,227 @@ func TestMADetector_CrossoverDetection(t *testing.T) {
 		t.Errorf("expected symbol 'TEST', got %s", signal.Symbol)
 	}
 }
+
+func TestMADetector_SnapshotRestore(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+
+	priceEventsProcessed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_price_events_processed", Help: "test"},
+		[]string{"symbol"},
+	)
+	signalsGenerated := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_signals_generated", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	processingTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "test_processing_time", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	detector := NewMADetector(producer, DefaultMASettings(), *priceEventsProcessed, *signalsGenerated, *processingTime)
+
+	start := time.Now()
+	for i := 0; i < DefaultSlowPeriod+5; i++ {
+		price := 100.0
+		if i >= DefaultSlowPeriod {
+			price = 110.0
+		}
+		detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Symbol:    "SNAP",
+			PriceUSD:  price,
+		})
+	}
+
+	if len(producer.Signals()) != 1 {
+		t.Fatalf("expected 1 signal before snapshot, got %d", len(producer.Signals()))
+	}
+
+	snapshot := detector.Snapshot()
+
+	restored := NewMADetector(producer, DefaultMASettings(), *priceEventsProcessed, *signalsGenerated, *processingTime)
+	restored.Restore(snapshot)
+
+	if len(restored.priceHistory["SNAP"].Prices) != DefaultSlowPeriod+5 {
+		t.Errorf("expected %d restored prices, got %d", DefaultSlowPeriod+5, len(restored.priceHistory["SNAP"].Prices))
+	}
+
+	if restored.lastSignals["SNAP"] != "golden_cross" {
+		t.Errorf("expected last signal golden_cross, got %s", restored.lastSignals["SNAP"])
+	}
+
+	last := start.Add(time.Duration(DefaultSlowPeriod+4) * time.Minute)
+	if !restored.priceHistory["SNAP"].LastTimestamp.Equal(last) {
+		t.Errorf("expected last timestamp %s to be restored, got %s", last, restored.priceHistory["SNAP"].LastTimestamp)
+	}
+
+	restored.ProcessPriceEvent(&events.PriceEvent{
+		Timestamp: last.Add(time.Minute),
+		Symbol:    "SNAP",
+		PriceUSD:  110.0,
+	})
+
+	if len(producer.Signals()) != 1 {
+		t.Errorf("expected restored detector not to republish golden cross, got %d signals", len(producer.Signals()))
+	}
+}
+
+func TestMADetector_DropsEventsOlderThanHistory(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+
+	priceEventsProcessed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_price_events_processed", Help: "test"},
+		[]string{"symbol"},
+	)
+	signalsGenerated := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_signals_generated", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	processingTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "test_processing_time", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	detector := NewMADetector(producer, DefaultMASettings(), *priceEventsProcessed, *signalsGenerated, *processingTime)
+
+	// A flat history where one high tick failed and is re-injected through
+	// the retry topic after the newer ones were applied
+	start := time.Now()
+	for i := 0; i < DefaultSlowPeriod+5; i++ {
+		detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Symbol:    "LATE",
+			PriceUSD:  100.0,
+		})
+	}
+	before := append([]float64(nil), detector.priceHistory["LATE"].Prices...)
+
+	for _, offset := range []int{DefaultSlowPeriod, DefaultSlowPeriod + 4} {
+		if err := detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: start.Add(time.Duration(offset) * time.Minute),
+			Symbol:    "LATE",
+			PriceUSD:  1000.0,
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	after := detector.priceHistory["LATE"].Prices
+	if len(after) != len(before) || after[len(after)-1] != 100.0 {
+		t.Errorf("expected older and repeated events to leave the history unchanged, got %v", after)
+	}
+	if len(producer.Signals()) != 0 {
+		t.Errorf("expected no crossover from an out-of-order tick, got %d signals", len(producer.Signals()))
+	}
+}
+
+func TestMADetector_WarmUpSuppressesSignals(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+
+	priceEventsProcessed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_price_events_processed", Help: "test"},
+		[]string{"symbol"},
+	)
+	signalsGenerated := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_signals_generated", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	processingTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "test_processing_time", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	detector := NewMADetector(producer, DefaultMASettings(), *priceEventsProcessed, *signalsGenerated, *processingTime)
+	detector.SetWarmingUp(true)
+
+	start := time.Now()
+	for i := 0; i < DefaultSlowPeriod+5; i++ {
+		price := 100.0
+		if i >= DefaultSlowPeriod {
+			price = 110.0
+		}
+		detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Symbol:    "WARM",
+			PriceUSD:  price,
+		})
+	}
+
+	if len(producer.Signals()) != 0 {
+		t.Errorf("expected no signals during warm-up, got %d", len(producer.Signals()))
+	}
+
+	if detector.lastSignals["WARM"] != "golden_cross" {
+		t.Errorf("expected warm-up to record golden_cross, got %s", detector.lastSignals["WARM"])
+	}
+
+	detector.SetWarmingUp(false)
+	for i := 0; i < DefaultSlowPeriod; i++ {
+		detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: start.Add(time.Duration(DefaultSlowPeriod+5+i) * time.Minute),
+			Symbol:    "WARM",
+			PriceUSD:  90.0,
+		})
+	}
+
+	if len(producer.Signals()) != 1 || producer.Signals()[0].Direction != "bearish" {
+		t.Errorf("expected a single live death cross after warm-up, got %d signals", len(producer.Signals()))
+	}
+}
+
+func TestMADetector_SymbolOverrides(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+
+	priceEventsProcessed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_price_events_processed", Help: "test"},
+		[]string{"symbol"},
+	)
+	signalsGenerated := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_signals_generated", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	processingTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "test_processing_time", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	fast, err := NewMASettings(MATypeEMA, 3, 5)
+	if err != nil {
+		t.Fatalf("failed to create override settings: %v", err)
+	}
+	settings := DefaultMASettings()
+	settings.Overrides = map[string]MASettings{"PEPE": fast}
+
+	detector := NewMADetector(producer, settings, *priceEventsProcessed, *signalsGenerated, *processingTime)
+
+	prices := []float64{100, 100, 100, 100, 100, 100, 110, 120}
+	for _, symbol := range []string{"BTC", "PEPE"} {
+		for i, price := range prices {
+			detector.ProcessPriceEvent(&events.PriceEvent{
+				Timestamp: time.Now().Add(time.Duration(i) * time.Minute),
+				Symbol:    symbol,
+				PriceUSD:  price,
+			})
+		}
+	}
+
+	if len(producer.Signals()) != 1 {
+		t.Fatalf("expected only the overridden symbol to signal, got %d signals", len(producer.Signals()))
+	}
+
+	signal := producer.Signals()[0]
+	if signal.Symbol != "PEPE" {
+		t.Errorf("expected PEPE signal, got %s", signal.Symbol)
+	}
+	details, err := signal.DecodeDetails()
+	if err != nil {
+		t.Fatalf("failed to decode details: %v", err)
+	}
+	if ma := details.(*events.MACrossoverDetails); ma.MAType != MATypeEMA || ma.FastPeriod != 3 || ma.SlowPeriod != 5 {
+		t.Errorf("expected EMA 3/5 in details, got %+v", ma)
+	}
+
+	if settings.ForSymbol("BTC").SlowPeriod != DefaultSlowPeriod {
+		t.Errorf("expected BTC to use the default slow period")
+	}
+}
@@ -0,0 +1,166 @@
+package signals
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	MATypeSMA = "sma"
+	MATypeEMA = "ema"
+	MATypeWMA = "wma"
+)
+
+type MovingAverage interface {
+	Type() string
+	Calculate(prices []float64, period int) float64
+}
+
+type MASettings struct {
+	Average    MovingAverage
+	FastPeriod int
+	SlowPeriod int
+	// Overrides replaces these settings entirely for individual symbols.
+	Overrides map[string]MASettings
+}
+
+func NewMovingAverage(maType string) (MovingAverage, error) {
+	switch strings.ToLower(maType) {
+	case MATypeSMA:
+		return simpleMovingAverage{}, nil
+	case MATypeEMA:
+		return exponentialMovingAverage{}, nil
+	case MATypeWMA:
+		return weightedMovingAverage{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported moving average type %q", maType)
+	}
+}
+
+func NewMASettings(maType string, fastPeriod, slowPeriod int) (MASettings, error) {
+	average, err := NewMovingAverage(maType)
+	if err != nil {
+		return MASettings{}, err
+	}
+
+	if fastPeriod < 1 || slowPeriod < 1 {
+		return MASettings{}, fmt.Errorf("moving average periods must be positive (fast: %d, slow: %d)", fastPeriod, slowPeriod)
+	}
+
+	if fastPeriod >= slowPeriod {
+		return MASettings{}, fmt.Errorf("fast period %d must be shorter than slow period %d", fastPeriod, slowPeriod)
+	}
+
+	return MASettings{
+		Average:    average,
+		FastPeriod: fastPeriod,
+		SlowPeriod: slowPeriod,
+	}, nil
+}
+
+func DefaultMASettings() MASettings {
+	return MASettings{
+		Average:    simpleMovingAverage{},
+		FastPeriod: DefaultFastPeriod,
+		SlowPeriod: DefaultSlowPeriod,
+	}
+}
+
+// ForSymbol returns the override for symbol, or the shared settings when it
+// has none.
+func (s MASettings) ForSymbol(symbol string) MASettings {
+	if override, ok := s.Overrides[symbol]; ok {
+		return override
+	}
+	return s
+}
+
+// HistorySize keeps enough points to compute the previous slow average,
+// with a floor of MaxHistorySize so EMAs have room to converge.
+func (s MASettings) HistorySize() int {
+	if s.SlowPeriod+1 > MaxHistorySize {
+		return s.SlowPeriod + 1
+	}
+	return MaxHistorySize
+}
+
+func (s MASettings) MinSignalSize() int {
+	return s.SlowPeriod + 1
+}
+
+type simpleMovingAverage struct{}
+
+func (simpleMovingAverage) Type() string {
+	return MATypeSMA
+}
+
+func (simpleMovingAverage) Calculate(prices []float64, period int) float64 {
+	return calculateSMA(prices, period)
+}
+
+type exponentialMovingAverage struct{}
+
+func (exponentialMovingAverage) Type() string {
+	return MATypeEMA
+}
+
+func (exponentialMovingAverage) Calculate(prices []float64, period int) float64 {
+	return calculateEMA(prices, period)
+}
+
+type weightedMovingAverage struct{}
+
+func (weightedMovingAverage) Type() string {
+	return MATypeWMA
+}
+
+func (weightedMovingAverage) Calculate(prices []float64, period int) float64 {
+	return calculateWMA(prices, period)
+}
+
+func calculateSMA(prices []float64, period int) float64 {
+	if len(prices) < period {
+		return 0
+	}
+
+	sum := 0.0
+	start := len(prices) - period
+	for i := start; i < len(prices); i++ {
+		sum += prices[i]
+	}
+
+	return sum / float64(period)
+}
+
+// calculateEMA seeds with the SMA of the first period prices and then
+// applies the standard 2/(period+1) smoothing over the remaining history.
+func calculateEMA(prices []float64, period int) float64 {
+	if len(prices) < period {
+		return 0
+	}
+
+	ema := calculateSMA(prices[:period], period)
+	alpha := 2.0 / float64(period+1)
+	for i := period; i < len(prices); i++ {
+		ema = alpha*prices[i] + (1-alpha)*ema
+	}
+
+	return ema
+}
+
+func calculateWMA(prices []float64, period int) float64 {
+	if len(prices) < period {
+		return 0
+	}
+
+	sum := 0.0
+	weights := 0.0
+	start := len(prices) - period
+	for i := start; i < len(prices); i++ {
+		weight := float64(i - start + 1)
+		sum += prices[i] * weight
+		weights += weight
+	}
+
+	return sum / weights
+}
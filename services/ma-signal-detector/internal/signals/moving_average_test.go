@@ -0,0 +1,178 @@
+package signals
+
+import (
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio/kafkatest"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCalculateEMA(t *testing.T) {
+	tests := []struct {
+		name     string
+		prices   []float64
+		period   int
+		expected float64
+	}{
+		{
+			name:     "insufficient data",
+			prices:   []float64{100, 110},
+			period:   3,
+			expected: 0,
+		},
+		{
+			name:     "exact period equals SMA",
+			prices:   []float64{100, 110, 120},
+			period:   3,
+			expected: 110,
+		},
+		{
+			name:     "smoothing applied after seed",
+			prices:   []float64{100, 110, 120, 130},
+			period:   3,
+			expected: 120,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculateEMA(tt.prices, tt.period)
+			if math.Abs(result-tt.expected) > 1e-9 {
+				t.Errorf("expected %f, got %f", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestCalculateWMA(t *testing.T) {
+	tests := []struct {
+		name     string
+		prices   []float64
+		period   int
+		expected float64
+	}{
+		{
+			name:     "insufficient data",
+			prices:   []float64{100},
+			period:   2,
+			expected: 0,
+		},
+		{
+			name:     "weights recent prices more",
+			prices:   []float64{100, 110, 120},
+			period:   3,
+			expected: (100*1 + 110*2 + 120*3) / 6.0,
+		},
+		{
+			name:     "only last period used",
+			prices:   []float64{500, 100, 200},
+			period:   2,
+			expected: (100*1 + 200*2) / 3.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculateWMA(tt.prices, tt.period)
+			if math.Abs(result-tt.expected) > 1e-9 {
+				t.Errorf("expected %f, got %f", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestNewMASettings(t *testing.T) {
+	tests := []struct {
+		name      string
+		maType    string
+		fast      int
+		slow      int
+		expectErr bool
+	}{
+		{"sma defaults", "sma", 20, 50, false},
+		{"ema upper case", "EMA", 9, 21, false},
+		{"wma long pair", "wma", 50, 200, false},
+		{"unknown type", "hma", 9, 21, true},
+		{"fast not shorter than slow", "sma", 50, 50, true},
+		{"zero period", "ema", 0, 21, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := NewMASettings(tt.maType, tt.fast, tt.slow)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if settings.HistorySize() < settings.MinSignalSize() {
+				t.Errorf("history size %d smaller than min signal size %d", settings.HistorySize(), settings.MinSignalSize())
+			}
+		})
+	}
+}
+
+func TestMADetector_ConfiguredEMACrossover(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+
+	priceEventsProcessed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_price_events_processed", Help: "test"},
+		[]string{"symbol"},
+	)
+	signalsGenerated := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_signals_generated", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	processingTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "test_processing_time", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	settings, err := NewMASettings("ema", 9, 21)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	detector := NewMADetector(producer, settings, *priceEventsProcessed, *signalsGenerated, *processingTime)
+
+	for i := 0; i < 30; i++ {
+		price := 100.0
+		if i >= 25 {
+			price = 120.0
+		}
+		detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: time.Now().Add(time.Duration(i) * time.Minute),
+			Symbol:    "EMA",
+			PriceUSD:  price,
+		})
+	}
+
+	if len(producer.Signals()) == 0 {
+		t.Fatal("expected EMA crossover signal")
+	}
+
+	decoded, err := producer.Signals()[0].DecodeDetails()
+	if err != nil {
+		t.Fatalf("failed to decode details: %v", err)
+	}
+	details := decoded.(*events.MACrossoverDetails)
+	if details.MAType != "ema" {
+		t.Errorf("expected ma_type ema, got %v", details.MAType)
+	}
+	if details.FastPeriod != 9 || details.SlowPeriod != 21 {
+		t.Errorf("expected periods 9/21, got %v/%v", details.FastPeriod, details.SlowPeriod)
+	}
+	if details.CrossoverType != "golden_cross" {
+		t.Errorf("expected golden_cross, got %v", details.CrossoverType)
+	}
+	if _, ok := producer.Signals()[0].Details["sma_20"]; ok {
+		t.Error("expected fixed sma_20 key to be replaced")
+	}
+}
@@ -0,0 +1,127 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	StoreTypeNone = "none"
+	StoreTypeFile = "file"
+)
+
+// Snapshot is the detector state saved between restarts. LastTimestamps is
+// absent from snapshots written by older versions.
+type Snapshot struct {
+	SavedAt        time.Time            `json:"saved_at"`
+	PriceHistory   map[string][]float64 `json:"price_history"`
+	LastTimestamps map[string]time.Time `json:"last_timestamps,omitempty"`
+	LastSignals    map[string]string    `json:"last_signals"`
+}
+
+type Store interface {
+	Save(snapshot *Snapshot) error
+	Load() (*Snapshot, error)
+}
+
+type Snapshotter interface {
+	Snapshot() *Snapshot
+}
+
+func NewStore(storeType, path string) (Store, error) {
+	switch strings.ToLower(storeType) {
+	case "", StoreTypeNone:
+		return nil, nil
+	case StoreTypeFile:
+		if path == "" {
+			return nil, errors.New("file state store requires a path")
+		}
+		return NewFileStore(path), nil
+	default:
+		return nil, fmt.Errorf("unsupported state store type %q", storeType)
+	}
+}
+
+type FileStore struct {
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (f *FileStore) Save(snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync state snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close state snapshot: %w", err)
+	}
+
+	// Rename is atomic, so a crash mid-write never leaves a truncated snapshot
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace state snapshot: %w", err)
+	}
+
+	return nil
+}
+
+func (f *FileStore) Load() (*Snapshot, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+func SaveEvery(ctx context.Context, store Store, source Snapshotter, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := store.Save(source.Snapshot()); err != nil {
+				log.Printf("Failed to save state snapshot: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package state
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileStore_SaveAndLoad(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "nested", "state.json"))
+
+	t.Run("missing file returns nil snapshot", func(t *testing.T) {
+		snapshot, err := store.Load()
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if snapshot != nil {
+			t.Errorf("expected nil snapshot, got %+v", snapshot)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		saved := &Snapshot{
+			SavedAt:      time.Date(2024, 6, 16, 14, 30, 0, 0, time.UTC),
+			PriceHistory: map[string][]float64{"BTC": {67000, 67100, 67200}},
+			LastSignals:  map[string]string{"BTC": "golden_cross"},
+		}
+
+		if err := store.Save(saved); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		loaded, err := store.Load()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(loaded.PriceHistory["BTC"]) != 3 {
+			t.Errorf("expected 3 prices, got %d", len(loaded.PriceHistory["BTC"]))
+		}
+		if loaded.LastSignals["BTC"] != "golden_cross" {
+			t.Errorf("expected golden_cross, got %s", loaded.LastSignals["BTC"])
+		}
+		if !loaded.SavedAt.Equal(saved.SavedAt) {
+			t.Errorf("expected saved_at %v, got %v", saved.SavedAt, loaded.SavedAt)
+		}
+	})
+}
+
+func TestNewStore(t *testing.T) {
+	tests := []struct {
+		name      string
+		storeType string
+		path      string
+		expectNil bool
+		expectErr bool
+	}{
+		{"none disables persistence", "none", "/tmp/state.json", true, false},
+		{"empty disables persistence", "", "", true, false},
+		{"file store", "file", "/tmp/state.json", false, false},
+		{"file store without path", "file", "", true, true},
+		{"unknown type", "redis", "/tmp/state.json", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewStore(tt.storeType, tt.path)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if (store == nil) != tt.expectNil {
+				t.Errorf("expected nil store %v, got %v", tt.expectNil, store)
+			}
+		})
+	}
+}
+
+type countingSnapshotter struct {
+	mutex sync.Mutex
+	calls int
+}
+
+func (c *countingSnapshotter) Snapshot() *Snapshot {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls++
+	return &Snapshot{SavedAt: time.Now()}
+}
+
+func TestSaveEvery(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	source := &countingSnapshotter{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	SaveEvery(ctx, store, source, 10*time.Millisecond)
+
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	if source.calls == 0 {
+		t.Error("expected at least one periodic snapshot")
+	}
+}
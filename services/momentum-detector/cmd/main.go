@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio"
+	"crypto-trackers/pkg/wire"
+	"momentum-detector/internal/config"
+	"momentum-detector/internal/signals"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+type ReadyResponse struct {
+	Status string `json:"status"`
+}
+
+var (
+	priceEventsProcessed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "price_events_processed_total",
+			Help: "Total number of price events processed",
+		},
+		[]string{"symbol"},
+	)
+	signalsGenerated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "trading_signals_generated_total",
+			Help: "Total number of trading signals generated",
+		},
+		[]string{"symbol", "type"},
+	)
+	processingTime = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "price_event_processing_seconds",
+			Help: "Time spent processing price events",
+		},
+		[]string{"symbol"},
+	)
+	bollingerProcessingTime = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "bollinger_processing_seconds",
+			Help: "Time spent updating Bollinger Bands",
+		},
+		[]string{"symbol"},
+	)
+	poisonMessages = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_poison_messages_total",
+			Help: "Total number of consumed messages that could not be decoded",
+		},
+		[]string{"topic"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(priceEventsProcessed)
+	prometheus.MustRegister(signalsGenerated)
+	prometheus.MustRegister(processingTime)
+	prometheus.MustRegister(bollingerProcessingTime)
+	prometheus.MustRegister(poisonMessages)
+}
+
+type Server struct {
+	config    *config.Config
+	ready     bool
+	consumer  *kafkaio.Consumer[events.PriceEvent]
+	producer  kafkaio.SignalProducer
+	detector  *signals.MomentumDetector
+	bollinger *signals.BollingerDetector
+	brokers   []string
+	codec     *wire.Codec
+}
+
+func NewServer(cfg *config.Config) *Server {
+	return &Server{
+		config: cfg,
+		ready:  false,
+	}
+}
+
+func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HealthResponse{Status: "healthy"})
+}
+
+func (s *Server) readyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	status := "ready"
+	if !s.ready {
+		status = "not ready"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(ReadyResponse{Status: status})
+}
+
+func (s *Server) setReady(ready bool) {
+	s.ready = ready
+}
+
+func (s *Server) bootstrap(ctx context.Context) error {
+	if !s.config.BootstrapEnabled {
+		return nil
+	}
+
+	since := time.Now().Add(-time.Duration(s.config.BootstrapLookback) * time.Hour)
+
+	s.detector.SetWarmingUp(true)
+	s.bollinger.SetWarmingUp(true)
+	defer s.detector.SetWarmingUp(false)
+	defer s.bollinger.SetWarmingUp(false)
+
+	log.Printf("Warming up from crypto-prices since %s", since.Format(time.RFC3339))
+	result, err := kafkaio.Replay(ctx, s.brokers, events.TopicPrices, since, s.codec, s.processPriceEvent)
+	if err != nil {
+		return err
+	}
+	s.consumer.SkipUntil(result.EndOffsets)
+
+	log.Printf("Warm-up complete: replayed %d price events", result.Events)
+	return nil
+}
+
+// processPriceEvent fans each price event out to every detector so that one
+// failing publish does not stop the others from seeing the event.
+func (s *Server) processPriceEvent(event *events.PriceEvent) error {
+	return errors.Join(
+		s.detector.ProcessPriceEvent(event),
+		s.bollinger.ProcessPriceEvent(event),
+	)
+}
+
+func (s *Server) initializeKafka() error {
+	brokers := strings.Split(s.config.KafkaBootstrapServers, ",")
+	s.brokers = brokers
+
+	codec, err := wire.NewCodec(s.config.KafkaWireFormat, wire.NewRegistry(s.config.SchemaRegistryURL))
+	if err != nil {
+		return err
+	}
+	s.codec = codec
+
+	settings, err := signals.NewMomentumSettings(
+		s.config.RSIPeriod, s.config.RSIOverbought, s.config.RSIOversold,
+		s.config.MACDFastPeriod, s.config.MACDSlowPeriod, s.config.MACDSignalPeriod,
+	)
+	if err != nil {
+		return err
+	}
+
+	bollingerSettings, err := signals.NewBollingerSettings(
+		s.config.BollingerPeriod, s.config.BollingerStdDevs,
+		s.config.SqueezePercentile, s.config.SqueezeLookback,
+	)
+	if err != nil {
+		return err
+	}
+
+	producer, err := kafkaio.NewProducer(brokers, codec)
+	if err != nil {
+		return err
+	}
+
+	consumerSettings := kafkaio.DetectorSettings{
+		GroupID:         s.config.KafkaGroupID,
+		RetryTopic:      s.config.ConsumerRetryTopic,
+		Retries:         s.config.ConsumerMaxRetries,
+		Backoff:         time.Duration(s.config.ConsumerRetryBackoff) * time.Millisecond,
+		DeadLetterTopic: s.config.ConsumerDLQTopic,
+		PoisonQueue:     s.config.ConsumerPoisonDLQ,
+	}
+	outbox, consumer, err := kafkaio.NewDetectorConsumer(brokers, codec, producer, consumerSettings, *poisonMessages,
+		func(publisher kafkaio.SignalProducer) func(*events.PriceEvent) error {
+			s.detector = signals.NewMomentumDetector(publisher, settings, *priceEventsProcessed, *signalsGenerated, *processingTime)
+			s.bollinger = signals.NewBollingerDetector(publisher, bollingerSettings, *signalsGenerated, *bollingerProcessingTime)
+			return s.processPriceEvent
+		})
+	if err != nil {
+		return err
+	}
+	s.producer = outbox
+	s.consumer = consumer
+
+	return nil
+}
+
+func main() {
+	cfg := config.New()
+	server := NewServer(cfg)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/health", server.healthHandler).Methods("GET")
+	router.HandleFunc("/ready", server.readyHandler).Methods("GET")
+	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
+
+	httpServer := &http.Server{
+		Addr:         ":" + cfg.Port,
+		Handler:      router,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+	}
+
+	go func() {
+		log.Printf("Starting Momentum Detector on port %s", cfg.Port)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	if err := server.initializeKafka(); err != nil {
+		log.Fatalf("Failed to initialize Kafka: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := server.bootstrap(ctx); err != nil {
+		log.Printf("Warm-up failed, starting with partial history: %v", err)
+	}
+
+	go func() {
+		if err := server.consumer.Start(ctx); err != nil {
+			log.Printf("Consumer error: %v", err)
+		}
+	}()
+
+	server.setReady(true)
+	log.Printf("Momentum Detector is ready and consuming messages (RSI%d %.0f/%.0f, MACD %d/%d/%d, BB %d/%.1f)",
+		cfg.RSIPeriod, cfg.RSIOversold, cfg.RSIOverbought, cfg.MACDFastPeriod, cfg.MACDSlowPeriod, cfg.MACDSignalPeriod,
+		cfg.BollingerPeriod, cfg.BollingerStdDevs)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+
+	log.Println("Shutting down Momentum Detector")
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+
+	if server.consumer != nil {
+		server.consumer.Close()
+	}
+	if server.producer != nil {
+		server.producer.Close()
+	}
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error during shutdown: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+type Config struct {
+	KafkaBootstrapServers string
+	KafkaGroupID          string
+	KafkaWireFormat       string
+	SchemaRegistryURL     string
+	ConsumerMaxRetries    int
+	ConsumerRetryBackoff  int
+	ConsumerDLQTopic      string
+	ConsumerRetryTopic    string
+	ConsumerPoisonDLQ     bool
+	Port                  string
+	LogLevel              string
+	RSIPeriod             int
+	RSIOverbought         float64
+	RSIOversold           float64
+	MACDFastPeriod        int
+	MACDSlowPeriod        int
+	MACDSignalPeriod      int
+	BollingerPeriod       int
+	BollingerStdDevs      float64
+	SqueezePercentile     float64
+	SqueezeLookback       int
+	BootstrapEnabled      bool
+	BootstrapLookback     int
+}
+
+func New() *Config {
+	return &Config{
+		KafkaBootstrapServers: getEnv("KAFKA_BOOTSTRAP_SERVERS", "kafka-service:9092"),
+		KafkaGroupID:          getEnv("KAFKA_GROUP_ID", "momentum-detector"),
+		KafkaWireFormat:       getEnv("KAFKA_WIRE_FORMAT", "json"),
+		SchemaRegistryURL:     getEnv("SCHEMA_REGISTRY_URL", ""),
+		ConsumerMaxRetries:    getEnvInt("CONSUMER_MAX_RETRIES", 3),
+		ConsumerRetryBackoff:  getEnvInt("CONSUMER_RETRY_BACKOFF_MS", 500),
+		ConsumerDLQTopic:      getEnv("CONSUMER_DLQ_TOPIC", "momentum-detector.dlq"),
+		ConsumerRetryTopic:    getEnv("CONSUMER_RETRY_TOPIC", "momentum-detector.retry"),
+		ConsumerPoisonDLQ:     getEnvBool("CONSUMER_POISON_DLQ_ENABLED", true),
+		Port:                  getEnv("PORT", "8080"),
+		LogLevel:              getEnv("LOG_LEVEL", "INFO"),
+		RSIPeriod:             getEnvInt("RSI_PERIOD", 14),
+		RSIOverbought:         getEnvFloat("RSI_OVERBOUGHT", 70),
+		RSIOversold:           getEnvFloat("RSI_OVERSOLD", 30),
+		MACDFastPeriod:        getEnvInt("MACD_FAST_PERIOD", 12),
+		MACDSlowPeriod:        getEnvInt("MACD_SLOW_PERIOD", 26),
+		MACDSignalPeriod:      getEnvInt("MACD_SIGNAL_PERIOD", 9),
+		BollingerPeriod:       getEnvInt("BOLLINGER_PERIOD", 20),
+		BollingerStdDevs:      getEnvFloat("BOLLINGER_STD_DEVS", 2),
+		SqueezePercentile:     getEnvFloat("BOLLINGER_SQUEEZE_PERCENTILE", 10),
+		SqueezeLookback:       getEnvInt("BOLLINGER_SQUEEZE_LOOKBACK", 120),
+		BootstrapEnabled:      getEnvBool("BOOTSTRAP_ENABLED", false),
+		BootstrapLookback:     getEnvInt("BOOTSTRAP_LOOKBACK_HOURS", 24),
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,117 @@
+package signals
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	DefaultBollingerPeriod   = 20
+	DefaultBollingerStdDevs  = 2.0
+	DefaultSqueezePercentile = 10.0
+	DefaultSqueezeLookback   = 120
+)
+
+type BollingerSettings struct {
+	Period            int
+	StdDevs           float64
+	SqueezePercentile float64
+	SqueezeLookback   int
+}
+
+func NewBollingerSettings(period int, stdDevs, squeezePercentile float64, squeezeLookback int) (BollingerSettings, error) {
+	if period < 2 {
+		return BollingerSettings{}, fmt.Errorf("Bollinger period must be at least 2, got %d", period)
+	}
+
+	if stdDevs <= 0 {
+		return BollingerSettings{}, fmt.Errorf("Bollinger standard deviations must be positive, got %.2f", stdDevs)
+	}
+
+	if squeezePercentile < 0 || squeezePercentile >= 100 {
+		return BollingerSettings{}, fmt.Errorf("squeeze percentile must be in [0, 100), got %.1f", squeezePercentile)
+	}
+
+	if squeezeLookback < 2 {
+		return BollingerSettings{}, fmt.Errorf("squeeze lookback must be at least 2, got %d", squeezeLookback)
+	}
+
+	return BollingerSettings{
+		Period:            period,
+		StdDevs:           stdDevs,
+		SqueezePercentile: squeezePercentile,
+		SqueezeLookback:   squeezeLookback,
+	}, nil
+}
+
+func DefaultBollingerSettings() BollingerSettings {
+	return BollingerSettings{
+		Period:            DefaultBollingerPeriod,
+		StdDevs:           DefaultBollingerStdDevs,
+		SqueezePercentile: DefaultSqueezePercentile,
+		SqueezeLookback:   DefaultSqueezeLookback,
+	}
+}
+
+type Bands struct {
+	Upper     float64
+	Middle    float64
+	Lower     float64
+	PercentB  float64
+	Bandwidth float64
+}
+
+// calculateBands uses the population standard deviation of the last period
+// prices, as in Bollinger's original definition. %B and bandwidth are zero
+// when the bands collapse onto the mean.
+func calculateBands(prices []float64, period int, stdDevs float64) (Bands, bool) {
+	if len(prices) < period || period < 1 {
+		return Bands{}, false
+	}
+
+	window := prices[len(prices)-period:]
+
+	mean := 0.0
+	for _, price := range window {
+		mean += price
+	}
+	mean /= float64(period)
+
+	variance := 0.0
+	for _, price := range window {
+		variance += (price - mean) * (price - mean)
+	}
+	deviation := math.Sqrt(variance / float64(period))
+
+	bands := Bands{
+		Upper:  mean + stdDevs*deviation,
+		Middle: mean,
+		Lower:  mean - stdDevs*deviation,
+	}
+
+	if width := bands.Upper - bands.Lower; width > 0 {
+		bands.PercentB = (window[len(window)-1] - bands.Lower) / width
+		if mean != 0 {
+			bands.Bandwidth = width / mean
+		}
+	}
+
+	return bands, true
+}
+
+// percentileRank returns the share of history at or below value, in percent,
+// so a value that matches every point in a flat history ranks at 100.
+func percentileRank(history []float64, value float64) float64 {
+	if len(history) == 0 {
+		return 0
+	}
+
+	atOrBelow := 0
+	for _, v := range history {
+		if v <= value {
+			atOrBelow++
+		}
+	}
+
+	return 100 * float64(atOrBelow) / float64(len(history))
+}
@@ -0,0 +1,238 @@
+package signals
+
+import (
+	"context"
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	bandInside = "inside"
+	bandUpper  = "upper"
+	bandLower  = "lower"
+)
+
+// BandHistory is a symbol's band state. Like PriceHistory, it only takes
+// events newer than LastTimestamp.
+type BandHistory struct {
+	Prices        []float64
+	Bandwidths    []float64
+	Position      string
+	InSqueeze     bool
+	LastTimestamp time.Time
+	mutex         sync.RWMutex
+}
+
+type BollingerDetector struct {
+	bandHistory      map[string]*BandHistory
+	settings         BollingerSettings
+	warmingUp        bool
+	mutex            sync.RWMutex
+	producer         kafkaio.SignalProducer
+	signalsGenerated prometheus.CounterVec
+	processingTime   prometheus.HistogramVec
+}
+
+func NewBollingerDetector(producer kafkaio.SignalProducer, settings BollingerSettings, signalsGenerated prometheus.CounterVec, processingTime prometheus.HistogramVec) *BollingerDetector {
+	return &BollingerDetector{
+		bandHistory:      make(map[string]*BandHistory),
+		settings:         settings,
+		producer:         producer,
+		signalsGenerated: signalsGenerated,
+		processingTime:   processingTime,
+	}
+}
+
+// SetWarmingUp toggles warm-up mode, in which band position and squeeze state
+// are tracked as usual but no signals are published.
+func (bd *BollingerDetector) SetWarmingUp(warmingUp bool) {
+	bd.mutex.Lock()
+	defer bd.mutex.Unlock()
+	bd.warmingUp = warmingUp
+}
+
+func (bd *BollingerDetector) ProcessPriceEvent(event *events.PriceEvent) error {
+	timer := prometheus.NewTimer(bd.processingTime.WithLabelValues(event.Symbol))
+	defer timer.ObserveDuration()
+
+	bd.mutex.Lock()
+	defer bd.mutex.Unlock()
+
+	history, exists := bd.bandHistory[event.Symbol]
+	if !exists {
+		history = &BandHistory{
+			Prices:     make([]float64, 0, bd.settings.Period),
+			Bandwidths: make([]float64, 0, bd.settings.SqueezeLookback),
+			Position:   bandInside,
+		}
+		bd.bandHistory[event.Symbol] = history
+	}
+
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	if !event.Timestamp.After(history.LastTimestamp) {
+		log.Printf("Dropping price event for %s at %s, not after the last applied event at %s",
+			event.Symbol, event.Timestamp.Format(time.RFC3339), history.LastTimestamp.Format(time.RFC3339))
+		return nil
+	}
+	history.LastTimestamp = event.Timestamp
+
+	history.Prices = append(history.Prices, event.PriceUSD)
+	if len(history.Prices) > bd.settings.Period {
+		history.Prices = history.Prices[1:]
+	}
+
+	bands, ok := calculateBands(history.Prices, bd.settings.Period, bd.settings.StdDevs)
+	if !ok {
+		return nil
+	}
+
+	breakoutErr := bd.checkBreakout(event, history, bands)
+	squeezeErr := bd.checkSqueeze(event, history, bands)
+
+	return errors.Join(breakoutErr, squeezeErr)
+}
+
+// checkBreakout signals when the price first closes outside a band; staying
+// outside on later events does not signal again.
+func (bd *BollingerDetector) checkBreakout(event *events.PriceEvent, history *BandHistory, bands Bands) error {
+	position := bandInside
+	if event.PriceUSD > bands.Upper {
+		position = bandUpper
+	} else if event.PriceUSD < bands.Lower {
+		position = bandLower
+	}
+
+	previous := history.Position
+	history.Position = position
+	if position == bandInside || position == previous || bd.warmingUp {
+		return nil
+	}
+
+	direction := "bullish"
+	breakoutType := "upper_breakout"
+	if position == bandLower {
+		direction = "bearish"
+		breakoutType = "lower_breakout"
+	}
+
+	// A close a quarter band-width or more beyond the band is a strong move
+	strength := "medium"
+	if math.Abs(bands.PercentB-0.5) >= 0.75 {
+		strength = "strong"
+	}
+
+	bd.signalsGenerated.WithLabelValues(event.Symbol, "bollinger_"+breakoutType).Inc()
+
+	signal := &events.TradingSignal{
+		SchemaVersion:  events.SchemaVersion,
+		Timestamp:      event.Timestamp,
+		Symbol:         event.Symbol,
+		SignalStrength: strength,
+		Direction:      direction,
+		ServiceID:      "momentum-detector-v1",
+	}
+	if err := signal.SetDetails(&events.BollingerBreakoutDetails{
+		BollingerBands: bd.bandDetails(event.PriceUSD, bands),
+		BreakoutType:   breakoutType,
+	}); err != nil {
+		return fmt.Errorf("failed to encode Bollinger %s signal for %s: %w", breakoutType, event.Symbol, err)
+	}
+
+	if err := bd.publishSignal(signal); err != nil {
+		return err
+	}
+
+	log.Printf("Published Bollinger %s signal for %s: $%.2f (upper: %.2f, lower: %.2f)", breakoutType, event.Symbol, event.PriceUSD, bands.Upper, bands.Lower)
+	return nil
+}
+
+// checkSqueeze signals when bandwidth drops to or below the configured
+// percentile of its recent history. The lookback has to fill before the first
+// squeeze can be reported.
+func (bd *BollingerDetector) checkSqueeze(event *events.PriceEvent, history *BandHistory, bands Bands) error {
+	defer func() {
+		history.Bandwidths = append(history.Bandwidths, bands.Bandwidth)
+		if len(history.Bandwidths) > bd.settings.SqueezeLookback {
+			history.Bandwidths = history.Bandwidths[1:]
+		}
+	}()
+
+	if len(history.Bandwidths) < bd.settings.SqueezeLookback {
+		return nil
+	}
+
+	rank := percentileRank(history.Bandwidths, bands.Bandwidth)
+	inSqueeze := rank <= bd.settings.SqueezePercentile
+
+	wasInSqueeze := history.InSqueeze
+	history.InSqueeze = inSqueeze
+	if !inSqueeze || wasInSqueeze || bd.warmingUp {
+		return nil
+	}
+
+	strength := "medium"
+	if rank == 0 {
+		strength = "strong"
+	}
+
+	bd.signalsGenerated.WithLabelValues(event.Symbol, "bollinger_squeeze").Inc()
+
+	signal := &events.TradingSignal{
+		SchemaVersion:  events.SchemaVersion,
+		Timestamp:      event.Timestamp,
+		Symbol:         event.Symbol,
+		SignalStrength: strength,
+		Direction:      "neutral",
+		ServiceID:      "momentum-detector-v1",
+	}
+	if err := signal.SetDetails(&events.BollingerSqueezeDetails{
+		BollingerBands:      bd.bandDetails(event.PriceUSD, bands),
+		BandwidthPercentile: rank,
+		SqueezePercentile:   bd.settings.SqueezePercentile,
+		SqueezeLookback:     bd.settings.SqueezeLookback,
+	}); err != nil {
+		return fmt.Errorf("failed to encode Bollinger squeeze signal for %s: %w", event.Symbol, err)
+	}
+
+	if err := bd.publishSignal(signal); err != nil {
+		return err
+	}
+
+	log.Printf("Published Bollinger squeeze signal for %s: bandwidth %.4f at percentile %.1f", event.Symbol, bands.Bandwidth, rank)
+	return nil
+}
+
+func (bd *BollingerDetector) bandDetails(price float64, bands Bands) events.BollingerBands {
+	return events.BollingerBands{
+		Price:      price,
+		UpperBand:  bands.Upper,
+		MiddleBand: bands.Middle,
+		LowerBand:  bands.Lower,
+		PercentB:   bands.PercentB,
+		Bandwidth:  bands.Bandwidth,
+		Period:     bd.settings.Period,
+		StdDevs:    bd.settings.StdDevs,
+	}
+}
+
+func (bd *BollingerDetector) publishSignal(signal *events.TradingSignal) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := bd.producer.PublishSignal(ctx, events.TopicSignals, signal); err != nil {
+		log.Printf("Failed to publish %s signal for %s: %v", signal.SignalType, signal.Symbol, err)
+		return fmt.Errorf("failed to publish %s signal for %s: %w", signal.SignalType, signal.Symbol, err)
+	}
+
+	return nil
+}
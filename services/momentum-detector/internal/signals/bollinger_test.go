@@ -0,0 +1,288 @@
+package signals
+
+import (
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio"
+	"crypto-trackers/pkg/kafkaio/kafkatest"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func newTestBollingerDetector(producer kafkaio.SignalProducer, settings BollingerSettings) *BollingerDetector {
+	signalsGenerated := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_bollinger_signals_generated", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	processingTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "test_bollinger_processing_time", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	return NewBollingerDetector(producer, settings, *signalsGenerated, *processingTime)
+}
+
+func feedBollinger(detector *BollingerDetector, symbol string, prices []float64) {
+	start := time.Now()
+	for i, price := range prices {
+		detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Symbol:    symbol,
+			PriceUSD:  price,
+		})
+	}
+}
+
+// oscillating alternates around base with the given amplitude so the bands
+// have a constant, known width.
+func oscillating(base, amplitude float64, count int) []float64 {
+	prices := make([]float64, count)
+	for i := range prices {
+		if i%2 == 0 {
+			prices[i] = base + amplitude
+		} else {
+			prices[i] = base - amplitude
+		}
+	}
+	return prices
+}
+
+func TestCalculateBands(t *testing.T) {
+	t.Run("insufficient data", func(t *testing.T) {
+		if _, ok := calculateBands([]float64{1, 2, 3}, 5, 2); ok {
+			t.Error("expected no bands with fewer prices than the period")
+		}
+	})
+
+	t.Run("known values", func(t *testing.T) {
+		// Mean 5, population standard deviation 2
+		prices := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+		bands, ok := calculateBands(prices, 8, 2)
+		if !ok {
+			t.Fatal("expected bands")
+		}
+
+		if bands.Middle != 5 || bands.Upper != 9 || bands.Lower != 1 {
+			t.Errorf("expected bands 1/5/9, got %.2f/%.2f/%.2f", bands.Lower, bands.Middle, bands.Upper)
+		}
+		if bands.PercentB != 1 {
+			t.Errorf("expected %%B 1 for a close on the upper band, got %.4f", bands.PercentB)
+		}
+		if math.Abs(bands.Bandwidth-1.6) > 1e-9 {
+			t.Errorf("expected bandwidth 1.6, got %.4f", bands.Bandwidth)
+		}
+	})
+
+	t.Run("uses the most recent period only", func(t *testing.T) {
+		bands, _ := calculateBands([]float64{1000, 10, 10, 10}, 3, 2)
+		if bands.Middle != 10 {
+			t.Errorf("expected middle band 10, got %.2f", bands.Middle)
+		}
+	})
+
+	t.Run("flat prices collapse the bands", func(t *testing.T) {
+		bands, _ := calculateBands([]float64{10, 10, 10}, 3, 2)
+		if bands.Upper != bands.Lower || bands.Bandwidth != 0 || bands.PercentB != 0 {
+			t.Errorf("expected collapsed bands, got %+v", bands)
+		}
+	})
+}
+
+func TestPercentileRank(t *testing.T) {
+	history := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	tests := []struct {
+		value    float64
+		expected float64
+	}{
+		{0.5, 0},
+		{1, 10},
+		{3.5, 30},
+		{11, 100},
+	}
+
+	for _, test := range tests {
+		if rank := percentileRank(history, test.value); rank != test.expected {
+			t.Errorf("expected rank %.0f for %.1f, got %.0f", test.expected, test.value, rank)
+		}
+	}
+}
+
+func TestNewBollingerSettings(t *testing.T) {
+	tests := []struct {
+		name       string
+		period     int
+		stdDevs    float64
+		percentile float64
+		lookback   int
+		wantErr    bool
+	}{
+		{"defaults", 20, 2, 10, 120, false},
+		{"period too short", 1, 2, 10, 120, true},
+		{"non-positive std devs", 20, 0, 10, 120, true},
+		{"percentile out of range", 20, 2, 100, 120, true},
+		{"lookback too short", 20, 2, 10, 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewBollingerSettings(test.period, test.stdDevs, test.percentile, test.lookback)
+			if (err != nil) != test.wantErr {
+				t.Errorf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestBollingerDetector_Breakout(t *testing.T) {
+	t.Run("upper breakout is bullish", func(t *testing.T) {
+		producer := &kafkatest.SignalProducer{}
+		detector := newTestBollingerDetector(producer, DefaultBollingerSettings())
+
+		feedBollinger(detector, "BTC", append(oscillating(100, 1, 20), 110))
+
+		breakouts := producer.SignalsOfType("bollinger_breakout")
+		if len(breakouts) != 1 {
+			t.Fatalf("expected 1 breakout signal, got %d", len(breakouts))
+		}
+
+		signal := breakouts[0]
+		if signal.Direction != "bullish" {
+			t.Errorf("expected bullish direction, got %s", signal.Direction)
+		}
+		if signal.Details["breakout_type"] != "upper_breakout" {
+			t.Errorf("expected upper_breakout, got %v", signal.Details["breakout_type"])
+		}
+		if signal.Details["percent_b"].(float64) <= 1 {
+			t.Errorf("expected %%B above 1, got %v", signal.Details["percent_b"])
+		}
+		for _, key := range []string{"upper_band", "middle_band", "lower_band", "bandwidth"} {
+			if _, ok := signal.Details[key]; !ok {
+				t.Errorf("expected %s in details", key)
+			}
+		}
+	})
+
+	t.Run("lower breakout is bearish", func(t *testing.T) {
+		producer := &kafkatest.SignalProducer{}
+		detector := newTestBollingerDetector(producer, DefaultBollingerSettings())
+
+		feedBollinger(detector, "ETH", append(oscillating(100, 1, 20), 90))
+
+		breakouts := producer.SignalsOfType("bollinger_breakout")
+		if len(breakouts) != 1 || breakouts[0].Direction != "bearish" {
+			t.Fatalf("expected 1 bearish breakout, got %d signals", len(breakouts))
+		}
+	})
+
+	t.Run("staying outside the band signals once", func(t *testing.T) {
+		producer := &kafkatest.SignalProducer{}
+		detector := newTestBollingerDetector(producer, DefaultBollingerSettings())
+
+		feedBollinger(detector, "BTC", append(oscillating(100, 1, 20), 110, 120))
+
+		if breakouts := producer.SignalsOfType("bollinger_breakout"); len(breakouts) != 1 {
+			t.Errorf("expected 1 breakout signal, got %d", len(breakouts))
+		}
+	})
+
+	t.Run("no signal while warming up", func(t *testing.T) {
+		producer := &kafkatest.SignalProducer{}
+		detector := newTestBollingerDetector(producer, DefaultBollingerSettings())
+
+		detector.SetWarmingUp(true)
+		feedBollinger(detector, "BTC", append(oscillating(100, 1, 20), 110))
+		detector.SetWarmingUp(false)
+
+		if len(producer.Signals()) != 0 {
+			t.Errorf("expected no signals during warm-up, got %d", len(producer.Signals()))
+		}
+	})
+}
+
+func TestBollingerDetector_Squeeze(t *testing.T) {
+	settings, err := NewBollingerSettings(4, 2, 10, 10)
+	if err != nil {
+		t.Fatalf("failed to create settings: %v", err)
+	}
+
+	t.Run("contracting bandwidth triggers a squeeze", func(t *testing.T) {
+		producer := &kafkatest.SignalProducer{}
+		detector := newTestBollingerDetector(producer, settings)
+
+		prices := oscillating(100, 5, 16)
+		prices = append(prices, oscillating(100, 0.5, 6)...)
+		feedBollinger(detector, "BTC", prices)
+
+		squeezes := producer.SignalsOfType("bollinger_squeeze")
+		if len(squeezes) != 1 {
+			t.Fatalf("expected 1 squeeze signal, got %d", len(squeezes))
+		}
+
+		signal := squeezes[0]
+		if signal.Direction != "neutral" {
+			t.Errorf("expected neutral direction, got %s", signal.Direction)
+		}
+		if signal.Details["bandwidth_percentile"].(float64) > 10 {
+			t.Errorf("expected bandwidth percentile at most 10, got %v", signal.Details["bandwidth_percentile"])
+		}
+	})
+
+	t.Run("steady bandwidth does not squeeze", func(t *testing.T) {
+		producer := &kafkatest.SignalProducer{}
+		detector := newTestBollingerDetector(producer, settings)
+
+		feedBollinger(detector, "BTC", oscillating(100, 5, 40))
+
+		if squeezes := producer.SignalsOfType("bollinger_squeeze"); len(squeezes) != 0 {
+			t.Errorf("expected no squeeze signals, got %d", len(squeezes))
+		}
+	})
+
+	t.Run("no squeeze before lookback fills", func(t *testing.T) {
+		producer := &kafkatest.SignalProducer{}
+		detector := newTestBollingerDetector(producer, settings)
+
+		prices := oscillating(100, 5, 6)
+		prices = append(prices, oscillating(100, 0.5, 4)...)
+		feedBollinger(detector, "BTC", prices)
+
+		if squeezes := producer.SignalsOfType("bollinger_squeeze"); len(squeezes) != 0 {
+			t.Errorf("expected no squeeze signals, got %d", len(squeezes))
+		}
+	})
+}
+
+func TestBollingerDetector_DropsEventsOlderThanHistory(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+	detector := newTestBollingerDetector(producer, DefaultBollingerSettings())
+
+	start := time.Now()
+	prices := oscillating(100, 1, 20)
+	for i, price := range prices {
+		detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Symbol:    "LATE",
+			PriceUSD:  price,
+		})
+	}
+
+	// A retried tick older than the newest applied one would otherwise be
+	// read as a breakout
+	if err := detector.ProcessPriceEvent(&events.PriceEvent{
+		Timestamp: start.Add(5 * time.Minute),
+		Symbol:    "LATE",
+		PriceUSD:  110,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if breakouts := producer.SignalsOfType("bollinger_breakout"); len(breakouts) != 0 {
+		t.Errorf("expected no breakout from an out-of-order tick, got %d", len(breakouts))
+	}
+	if last := detector.bandHistory["LATE"].Prices; last[len(last)-1] != prices[len(prices)-1] {
+		t.Errorf("expected the history to end with the newest price, got %v", last)
+	}
+}
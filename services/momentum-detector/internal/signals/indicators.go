@@ -0,0 +1,181 @@
+package signals
+
+import "fmt"
+
+const (
+	DefaultRSIPeriod  = 14
+	DefaultOverbought = 70.0
+	DefaultOversold   = 30.0
+	DefaultMACDFast   = 12
+	DefaultMACDSlow   = 26
+	DefaultMACDSignal = 9
+	MinHistorySize    = 100
+)
+
+type MomentumSettings struct {
+	RSIPeriod      int
+	OverboughtLine float64
+	OversoldLine   float64
+	MACDFast       int
+	MACDSlow       int
+	MACDSignal     int
+}
+
+func NewMomentumSettings(rsiPeriod int, overbought, oversold float64, macdFast, macdSlow, macdSignal int) (MomentumSettings, error) {
+	if rsiPeriod < 2 {
+		return MomentumSettings{}, fmt.Errorf("RSI period must be at least 2, got %d", rsiPeriod)
+	}
+
+	if oversold <= 0 || overbought >= 100 || oversold >= overbought {
+		return MomentumSettings{}, fmt.Errorf("RSI levels must satisfy 0 < oversold < overbought < 100 (oversold: %.1f, overbought: %.1f)", oversold, overbought)
+	}
+
+	if macdFast < 1 || macdSignal < 1 || macdFast >= macdSlow {
+		return MomentumSettings{}, fmt.Errorf("MACD periods must satisfy 0 < fast < slow and signal > 0 (fast: %d, slow: %d, signal: %d)", macdFast, macdSlow, macdSignal)
+	}
+
+	return MomentumSettings{
+		RSIPeriod:      rsiPeriod,
+		OverboughtLine: overbought,
+		OversoldLine:   oversold,
+		MACDFast:       macdFast,
+		MACDSlow:       macdSlow,
+		MACDSignal:     macdSignal,
+	}, nil
+}
+
+func DefaultMomentumSettings() MomentumSettings {
+	return MomentumSettings{
+		RSIPeriod:      DefaultRSIPeriod,
+		OverboughtLine: DefaultOverbought,
+		OversoldLine:   DefaultOversold,
+		MACDFast:       DefaultMACDFast,
+		MACDSlow:       DefaultMACDSlow,
+		MACDSignal:     DefaultMACDSignal,
+	}
+}
+
+// HistorySize keeps enough prices for Wilder and EMA smoothing to settle
+// well past the minimum needed for a first value.
+func (s MomentumSettings) HistorySize() int {
+	return max(MinHistorySize, 2*(s.MACDSlow+s.MACDSignal), 4*s.RSIPeriod)
+}
+
+// RSIMinSize is the number of prices needed for a current and previous RSI.
+func (s MomentumSettings) RSIMinSize() int {
+	return s.RSIPeriod + 2
+}
+
+// MACDMinSize is the number of prices needed for a current and previous
+// signal line value.
+func (s MomentumSettings) MACDMinSize() int {
+	return s.MACDSlow + s.MACDSignal
+}
+
+// calculateRSI uses Wilder's smoothing: the first average gain and loss are
+// simple means over period changes, later ones are smoothed by 1/period.
+func calculateRSI(prices []float64, period int) float64 {
+	if len(prices) < period+1 {
+		return 0
+	}
+
+	avgGain, avgLoss := 0.0, 0.0
+	for i := 1; i <= period; i++ {
+		change := prices[i] - prices[i-1]
+		if change > 0 {
+			avgGain += change
+		} else {
+			avgLoss -= change
+		}
+	}
+	avgGain /= float64(period)
+	avgLoss /= float64(period)
+
+	for i := period + 1; i < len(prices); i++ {
+		change := prices[i] - prices[i-1]
+		gain, loss := 0.0, 0.0
+		if change > 0 {
+			gain = change
+		} else {
+			loss = -change
+		}
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+	}
+
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+
+	rs := avgGain / avgLoss
+	return 100 - 100/(1+rs)
+}
+
+// emaSeries returns the EMA for every index from period-1 onwards, seeded with
+// the SMA of the first period values.
+func emaSeries(values []float64, period int) []float64 {
+	if len(values) < period {
+		return nil
+	}
+
+	series := make([]float64, 0, len(values)-period+1)
+	sum := 0.0
+	for i := 0; i < period; i++ {
+		sum += values[i]
+	}
+	ema := sum / float64(period)
+	series = append(series, ema)
+
+	alpha := 2.0 / float64(period+1)
+	for i := period; i < len(values); i++ {
+		ema = alpha*values[i] + (1-alpha)*ema
+		series = append(series, ema)
+	}
+
+	return series
+}
+
+type MACDValue struct {
+	MACD      float64
+	Signal    float64
+	Histogram float64
+}
+
+// calculateMACD returns the last two MACD values, previous first, or false
+// when there is not enough history for both.
+func calculateMACD(prices []float64, fast, slow, signal int) ([2]MACDValue, bool) {
+	var result [2]MACDValue
+
+	fastSeries := emaSeries(prices, fast)
+	slowSeries := emaSeries(prices, slow)
+	if slowSeries == nil {
+		return result, false
+	}
+
+	// Align the fast series with the slow one, which starts slow-fast later
+	offset := slow - fast
+	macdLine := make([]float64, len(slowSeries))
+	for i := range slowSeries {
+		macdLine[i] = fastSeries[i+offset] - slowSeries[i]
+	}
+
+	signalSeries := emaSeries(macdLine, signal)
+	if len(signalSeries) < 2 {
+		return result, false
+	}
+
+	for i := 0; i < 2; i++ {
+		macd := macdLine[len(macdLine)-2+i]
+		signalValue := signalSeries[len(signalSeries)-2+i]
+		result[i] = MACDValue{
+			MACD:      macd,
+			Signal:    signalValue,
+			Histogram: macd - signalValue,
+		}
+	}
+
+	return result, true
+}
@@ -0,0 +1,135 @@
+package signals
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateRSI(t *testing.T) {
+	tests := []struct {
+		name     string
+		prices   []float64
+		period   int
+		expected float64
+	}{
+		{
+			name:     "insufficient data",
+			prices:   []float64{100, 101},
+			period:   3,
+			expected: 0,
+		},
+		{
+			name:     "only gains",
+			prices:   []float64{100, 101, 102, 103},
+			period:   3,
+			expected: 100,
+		},
+		{
+			name:     "flat prices",
+			prices:   []float64{100, 100, 100, 100},
+			period:   3,
+			expected: 50,
+		},
+		{
+			name:     "equal gains and losses",
+			prices:   []float64{100, 102, 100, 102, 100},
+			period:   4,
+			expected: 50,
+		},
+		{
+			name:     "wilder smoothing after seed",
+			prices:   []float64{100, 102, 101, 103},
+			period:   2,
+			expected: 100 - 100/(1+(2.0/2+2)/2/((1.0/2)/2)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculateRSI(tt.prices, tt.period)
+			if math.Abs(result-tt.expected) > 1e-9 {
+				t.Errorf("expected %f, got %f", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestEMASeries(t *testing.T) {
+	series := emaSeries([]float64{100, 110, 120, 130}, 3)
+
+	if len(series) != 2 {
+		t.Fatalf("expected 2 values, got %d", len(series))
+	}
+	if series[0] != 110 || series[1] != 120 {
+		t.Errorf("expected [110 120], got %v", series)
+	}
+
+	if emaSeries([]float64{1}, 3) != nil {
+		t.Error("expected nil series with insufficient data")
+	}
+}
+
+func TestCalculateMACD(t *testing.T) {
+	t.Run("insufficient data", func(t *testing.T) {
+		_, ok := calculateMACD(make([]float64, 9), 3, 6, 4)
+		if ok {
+			t.Error("expected not enough data")
+		}
+	})
+
+	t.Run("rising prices keep MACD positive", func(t *testing.T) {
+		prices := make([]float64, 40)
+		for i := range prices {
+			prices[i] = 100 + float64(i)
+		}
+
+		values, ok := calculateMACD(prices, 3, 6, 4)
+		if !ok {
+			t.Fatal("expected MACD values")
+		}
+		if values[1].MACD <= 0 {
+			t.Errorf("expected positive MACD in uptrend, got %f", values[1].MACD)
+		}
+		if math.Abs(values[1].Histogram-(values[1].MACD-values[1].Signal)) > 1e-9 {
+			t.Errorf("expected histogram to equal MACD minus signal")
+		}
+	})
+}
+
+func TestNewMomentumSettings(t *testing.T) {
+	tests := []struct {
+		name       string
+		rsiPeriod  int
+		overbought float64
+		oversold   float64
+		fast       int
+		slow       int
+		signal     int
+		expectErr  bool
+	}{
+		{"defaults", 14, 70, 30, 12, 26, 9, false},
+		{"rsi period too short", 1, 70, 30, 12, 26, 9, true},
+		{"levels inverted", 14, 30, 70, 12, 26, 9, true},
+		{"overbought out of range", 14, 100, 30, 12, 26, 9, true},
+		{"macd fast not shorter", 14, 70, 30, 26, 26, 9, true},
+		{"macd signal zero", 14, 70, 30, 12, 26, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := NewMomentumSettings(tt.rsiPeriod, tt.overbought, tt.oversold, tt.fast, tt.slow, tt.signal)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if settings.HistorySize() < settings.MACDMinSize() || settings.HistorySize() < settings.RSIMinSize() {
+				t.Errorf("history size %d too small for indicators", settings.HistorySize())
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package signals
+
+import (
+	"context"
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PriceHistory holds a symbol's prices in event-time order. LastTimestamp
+// is the newest event applied; older or repeated events, such as a retried
+// tick arriving after newer ones, are dropped rather than appended out of
+// order.
+type PriceHistory struct {
+	Prices        []float64
+	LastTimestamp time.Time
+	mutex         sync.RWMutex
+}
+
+type MomentumDetector struct {
+	priceHistory         map[string]*PriceHistory
+	settings             MomentumSettings
+	warmingUp            bool
+	mutex                sync.RWMutex
+	producer             kafkaio.SignalProducer
+	priceEventsProcessed prometheus.CounterVec
+	signalsGenerated     prometheus.CounterVec
+	processingTime       prometheus.HistogramVec
+}
+
+func NewMomentumDetector(producer kafkaio.SignalProducer, settings MomentumSettings, priceEventsProcessed prometheus.CounterVec, signalsGenerated prometheus.CounterVec, processingTime prometheus.HistogramVec) *MomentumDetector {
+	return &MomentumDetector{
+		priceHistory:         make(map[string]*PriceHistory),
+		settings:             settings,
+		producer:             producer,
+		priceEventsProcessed: priceEventsProcessed,
+		signalsGenerated:     signalsGenerated,
+		processingTime:       processingTime,
+	}
+}
+
+// SetWarmingUp toggles warm-up mode, in which price history is built up as
+// usual but no signals are published.
+func (md *MomentumDetector) SetWarmingUp(warmingUp bool) {
+	md.mutex.Lock()
+	defer md.mutex.Unlock()
+	md.warmingUp = warmingUp
+}
+
+func (md *MomentumDetector) ProcessPriceEvent(event *events.PriceEvent) error {
+	timer := prometheus.NewTimer(md.processingTime.WithLabelValues(event.Symbol))
+	defer timer.ObserveDuration()
+
+	md.priceEventsProcessed.WithLabelValues(event.Symbol).Inc()
+
+	md.mutex.Lock()
+	defer md.mutex.Unlock()
+
+	historySize := md.settings.HistorySize()
+	history, exists := md.priceHistory[event.Symbol]
+	if !exists {
+		history = &PriceHistory{
+			Prices: make([]float64, 0, historySize),
+		}
+		md.priceHistory[event.Symbol] = history
+		log.Printf("Started tracking price history for %s", event.Symbol)
+	}
+
+	history.mutex.Lock()
+	if !event.Timestamp.After(history.LastTimestamp) {
+		history.mutex.Unlock()
+		log.Printf("Dropping price event for %s at %s, not after the last applied event at %s",
+			event.Symbol, event.Timestamp.Format(time.RFC3339), history.LastTimestamp.Format(time.RFC3339))
+		return nil
+	}
+	history.LastTimestamp = event.Timestamp
+	history.Prices = append(history.Prices, event.PriceUSD)
+	if len(history.Prices) > historySize {
+		history.Prices = history.Prices[1:]
+	}
+	prices := make([]float64, len(history.Prices))
+	copy(prices, history.Prices)
+	history.mutex.Unlock()
+
+	log.Printf("Processed price event for %s: $%.2f (history: %d points)", event.Symbol, event.PriceUSD, len(prices))
+
+	if md.warmingUp {
+		return nil
+	}
+
+	rsiErr := md.checkRSI(event.Symbol, event.Timestamp, prices)
+	macdErr := md.checkMACD(event.Symbol, event.Timestamp, prices)
+
+	return errors.Join(rsiErr, macdErr)
+}
+
+func (md *MomentumDetector) checkRSI(symbol string, timestamp time.Time, prices []float64) error {
+	if len(prices) < md.settings.RSIMinSize() {
+		return nil
+	}
+
+	currentRSI := calculateRSI(prices, md.settings.RSIPeriod)
+	prevRSI := calculateRSI(prices[:len(prices)-1], md.settings.RSIPeriod)
+
+	var thresholdType string
+	var direction string
+
+	if prevRSI < md.settings.OverboughtLine && currentRSI >= md.settings.OverboughtLine {
+		thresholdType = "overbought"
+		direction = "bearish"
+	} else if prevRSI > md.settings.OversoldLine && currentRSI <= md.settings.OversoldLine {
+		thresholdType = "oversold"
+		direction = "bullish"
+	}
+
+	if thresholdType == "" {
+		return nil
+	}
+
+	strength := "medium"
+	if math.Abs(currentRSI-50) >= 30 {
+		strength = "strong"
+	}
+
+	md.signalsGenerated.WithLabelValues(symbol, "rsi_"+thresholdType).Inc()
+
+	signal := &events.TradingSignal{
+		SchemaVersion:  events.SchemaVersion,
+		Timestamp:      timestamp,
+		Symbol:         symbol,
+		SignalStrength: strength,
+		Direction:      direction,
+		ServiceID:      "momentum-detector-v1",
+	}
+	if err := signal.SetDetails(&events.RSIThresholdDetails{
+		RSI:             currentRSI,
+		PreviousRSI:     prevRSI,
+		RSIPeriod:       md.settings.RSIPeriod,
+		OverboughtLevel: md.settings.OverboughtLine,
+		OversoldLevel:   md.settings.OversoldLine,
+		ThresholdType:   thresholdType,
+	}); err != nil {
+		return fmt.Errorf("failed to encode RSI %s signal for %s: %w", thresholdType, symbol, err)
+	}
+
+	if err := md.publishSignal(signal); err != nil {
+		return err
+	}
+
+	log.Printf("Published RSI %s signal for %s (RSI%d: %.2f)", thresholdType, symbol, md.settings.RSIPeriod, currentRSI)
+	return nil
+}
+
+func (md *MomentumDetector) checkMACD(symbol string, timestamp time.Time, prices []float64) error {
+	if len(prices) < md.settings.MACDMinSize() {
+		return nil
+	}
+
+	values, ok := calculateMACD(prices, md.settings.MACDFast, md.settings.MACDSlow, md.settings.MACDSignal)
+	if !ok {
+		return nil
+	}
+	prev, current := values[0], values[1]
+
+	var crossoverType string
+	var direction string
+
+	if prev.MACD <= prev.Signal && current.MACD > current.Signal {
+		crossoverType = "bullish_crossover"
+		direction = "bullish"
+	} else if prev.MACD >= prev.Signal && current.MACD < current.Signal {
+		crossoverType = "bearish_crossover"
+		direction = "bearish"
+	}
+
+	if crossoverType == "" {
+		return nil
+	}
+
+	// Crossovers on the far side of the zero line run against the prevailing trend
+	strength := "medium"
+	if (direction == "bullish" && current.MACD < 0) || (direction == "bearish" && current.MACD > 0) {
+		strength = "strong"
+	}
+
+	md.signalsGenerated.WithLabelValues(symbol, "macd_"+crossoverType).Inc()
+
+	signal := &events.TradingSignal{
+		SchemaVersion:  events.SchemaVersion,
+		Timestamp:      timestamp,
+		Symbol:         symbol,
+		SignalStrength: strength,
+		Direction:      direction,
+		ServiceID:      "momentum-detector-v1",
+	}
+	if err := signal.SetDetails(&events.MACDCrossoverDetails{
+		MACD:          current.MACD,
+		SignalLine:    current.Signal,
+		Histogram:     current.Histogram,
+		FastPeriod:    md.settings.MACDFast,
+		SlowPeriod:    md.settings.MACDSlow,
+		SignalPeriod:  md.settings.MACDSignal,
+		CrossoverType: crossoverType,
+	}); err != nil {
+		return fmt.Errorf("failed to encode MACD %s signal for %s: %w", crossoverType, symbol, err)
+	}
+
+	if err := md.publishSignal(signal); err != nil {
+		return err
+	}
+
+	log.Printf("Published MACD %s signal for %s (MACD: %.4f, signal: %.4f)", crossoverType, symbol, current.MACD, current.Signal)
+	return nil
+}
+
+func (md *MomentumDetector) publishSignal(signal *events.TradingSignal) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := md.producer.PublishSignal(ctx, events.TopicSignals, signal); err != nil {
+		log.Printf("Failed to publish %s signal for %s: %v", signal.SignalType, signal.Symbol, err)
+		return fmt.Errorf("failed to publish %s signal for %s: %w", signal.SignalType, signal.Symbol, err)
+	}
+
+	return nil
+}
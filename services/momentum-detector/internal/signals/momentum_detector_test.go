@@ -0,0 +1,223 @@
+package signals
+
+import (
+	"context"
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio"
+	"crypto-trackers/pkg/kafkaio/kafkatest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func newTestDetector(producer kafkaio.SignalProducer) *MomentumDetector {
+	priceEventsProcessed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_price_events_processed", Help: "test"},
+		[]string{"symbol"},
+	)
+	signalsGenerated := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_signals_generated", Help: "test"},
+		[]string{"symbol", "signal_type"},
+	)
+	processingTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "test_processing_time", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	return NewMomentumDetector(producer, DefaultMomentumSettings(), *priceEventsProcessed, *signalsGenerated, *processingTime)
+}
+
+func feedPrices(detector *MomentumDetector, symbol string, prices []float64) {
+	start := time.Now()
+	for i, price := range prices {
+		detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Symbol:    symbol,
+			PriceUSD:  price,
+		})
+	}
+}
+
+func TestMomentumDetector_ProcessPriceEvent(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+	detector := newTestDetector(producer)
+
+	t.Run("first price event creates history", func(t *testing.T) {
+		err := detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: time.Now(),
+			Symbol:    "BTC",
+			PriceUSD:  50000.0,
+		})
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+
+		if len(detector.priceHistory["BTC"].Prices) != 1 {
+			t.Errorf("expected 1 price in history, got %d", len(detector.priceHistory["BTC"].Prices))
+		}
+	})
+
+	t.Run("insufficient data no signal", func(t *testing.T) {
+		prices := make([]float64, DefaultRSIPeriod)
+		for i := range prices {
+			prices[i] = 3000 + float64(i*10)
+		}
+		feedPrices(detector, "ETH", prices)
+
+		if len(producer.Signals()) != 0 {
+			t.Errorf("expected no signals with insufficient data, got %d", len(producer.Signals()))
+		}
+	})
+}
+
+func TestMomentumDetector_RSIThreshold(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+	detector := newTestDetector(producer)
+
+	prices := make([]float64, 0, 40)
+	for i := 0; i < 30; i++ {
+		if i%2 == 0 {
+			prices = append(prices, 100)
+		} else {
+			prices = append(prices, 101)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		prices = append(prices, 100-float64(i+1)*2)
+	}
+	feedPrices(detector, "RSI", prices)
+
+	rsiSignals := producer.SignalsOfType("rsi_threshold")
+	if len(rsiSignals) != 1 {
+		t.Fatalf("expected 1 RSI signal on entering oversold, got %d", len(rsiSignals))
+	}
+
+	signal := rsiSignals[0]
+	if signal.Direction != "bullish" {
+		t.Errorf("expected bullish direction for oversold, got %s", signal.Direction)
+	}
+	if signal.Details["threshold_type"] != "oversold" {
+		t.Errorf("expected threshold_type oversold, got %v", signal.Details["threshold_type"])
+	}
+	if rsi, ok := signal.Details["rsi"].(float64); !ok || rsi > DefaultOversold {
+		t.Errorf("expected RSI at or below %f, got %v", DefaultOversold, signal.Details["rsi"])
+	}
+	if signal.ServiceID != "momentum-detector-v1" {
+		t.Errorf("expected service id momentum-detector-v1, got %s", signal.ServiceID)
+	}
+}
+
+func TestMomentumDetector_MACDCrossover(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+	detector := newTestDetector(producer)
+
+	prices := make([]float64, 0, 60)
+	for i := 0; i < 40; i++ {
+		prices = append(prices, 200-float64(i))
+	}
+	for i := 0; i < 20; i++ {
+		prices = append(prices, 160+float64(i)*3)
+	}
+	feedPrices(detector, "MACD", prices)
+
+	macdSignals := producer.SignalsOfType("macd_crossover")
+	if len(macdSignals) == 0 {
+		t.Fatal("expected MACD crossover signal on trend reversal")
+	}
+
+	signal := macdSignals[0]
+	if signal.Direction != "bullish" {
+		t.Errorf("expected bullish crossover, got %s", signal.Direction)
+	}
+	if signal.Details["crossover_type"] != "bullish_crossover" {
+		t.Errorf("expected bullish_crossover, got %v", signal.Details["crossover_type"])
+	}
+	if signal.SignalStrength != "strong" {
+		t.Errorf("expected strong signal for crossover below zero line, got %s", signal.SignalStrength)
+	}
+}
+
+func TestMomentumDetector_WarmUpSuppressesSignals(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+	detector := newTestDetector(producer)
+	detector.SetWarmingUp(true)
+
+	prices := make([]float64, 0, 60)
+	for i := 0; i < 40; i++ {
+		prices = append(prices, 200-float64(i))
+	}
+	for i := 0; i < 20; i++ {
+		prices = append(prices, 160+float64(i)*3)
+	}
+	feedPrices(detector, "WARM", prices)
+
+	if len(producer.Signals()) != 0 {
+		t.Errorf("expected no signals during warm-up, got %d", len(producer.Signals()))
+	}
+}
+
+func TestMomentumDetector_PublishError(t *testing.T) {
+	producer := &kafkatest.SignalProducer{Err: context.DeadlineExceeded}
+	detector := newTestDetector(producer)
+
+	prices := make([]float64, 0, 60)
+	for i := 0; i < 40; i++ {
+		prices = append(prices, 200-float64(i))
+	}
+	for i := 0; i < 20; i++ {
+		prices = append(prices, 160+float64(i)*3)
+	}
+
+	var lastErr error
+	start := time.Now()
+	for i, price := range prices {
+		if err := detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Symbol:    "ERR",
+			PriceUSD:  price,
+		}); err != nil {
+			lastErr = err
+		}
+	}
+
+	if lastErr == nil {
+		t.Error("expected publish failure to be returned")
+	}
+}
+
+func TestMomentumDetector_DropsEventsOlderThanHistory(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+	detector := newTestDetector(producer)
+
+	start := time.Now()
+	for i := 0; i < DefaultMomentumSettings().HistorySize(); i++ {
+		detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Symbol:    "LATE",
+			PriceUSD:  100 + float64(i%2),
+		})
+	}
+	before := append([]float64(nil), detector.priceHistory["LATE"].Prices...)
+	published := len(producer.Signals())
+
+	// A failed tick re-injected through the retry topic after newer ones,
+	// then the newest tick redelivered
+	for _, offset := range []int{5, DefaultMomentumSettings().HistorySize() - 1} {
+		if err := detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: start.Add(time.Duration(offset) * time.Minute),
+			Symbol:    "LATE",
+			PriceUSD:  10,
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	after := detector.priceHistory["LATE"].Prices
+	if len(after) != len(before) || after[len(after)-1] != before[len(before)-1] {
+		t.Errorf("expected older and repeated events to leave the history unchanged, got %v", after)
+	}
+	if len(producer.Signals()) != published {
+		t.Errorf("expected no signal from an out-of-order tick, got %d", len(producer.Signals())-published)
+	}
+}
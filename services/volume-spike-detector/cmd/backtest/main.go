@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"crypto-trackers/pkg/backtest"
+	"volume-spike-detector/internal/signals"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func main() {
+	mode := flag.String("mode", signals.SpikeModeRatio, "spike detection mode: ratio, zscore, mad or ewma")
+	threshold := flag.Float64("threshold", 0, "spike threshold for the mode; 0 uses the mode default")
+	ewmaAlpha := flag.Float64("ewma-alpha", signals.DefaultEWMAAlpha, "smoothing factor for the ewma mode")
+	neutralBand := flag.Float64("neutral-band", signals.DefaultNeutralBand, "absolute 24h price change in percent within which spikes are neutral")
+	windowHours := flag.Int("window-hours", int(signals.DefaultWindow.Hours()), "rolling volume window in hours")
+	lateness := flag.Duration("allowed-lateness", signals.DefaultAllowedLateness, "how late an event may arrive and still be reordered")
+	format := flag.String("format", backtest.FormatText, "output format: text or json")
+	verbose := flag.Bool("verbose", false, "keep detector logs")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] prices.csv|prices.jsonl ...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := backtest.CheckFormat(*format); err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "Invalid -format: %v\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	spike, err := signals.NewSpikeSettings(*mode, *threshold, *ewmaAlpha, *neutralBand)
+	if err != nil {
+		log.Fatalf("Invalid spike settings: %v", err)
+	}
+
+	events, err := backtest.LoadEvents(flag.Args())
+	if err != nil {
+		log.Fatalf("Failed to load price events: %v", err)
+	}
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	window := signals.WindowSettings{
+		Window:          time.Duration(*windowHours) * time.Hour,
+		AllowedLateness: *lateness,
+	}
+
+	recorder := backtest.NewSignalRecorder()
+	detector := signals.NewVolumeDetector(
+		recorder,
+		spike,
+		window,
+		*prometheus.NewCounterVec(prometheus.CounterOpts{Name: "volume_events_processed_total"}, []string{"symbol"}),
+		*prometheus.NewCounterVec(prometheus.CounterOpts{Name: "volume_spikes_detected_total"}, []string{"symbol"}),
+		*prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "volume_processing_seconds"}, []string{"symbol"}),
+	)
+
+	if err := backtest.Run(events, detector.ProcessPriceEvent); err != nil {
+		fmt.Fprintf(os.Stderr, "Backtest failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := backtest.Evaluate(events, recorder.Signals(), backtest.DefaultHorizons)
+
+	if err := report.Write(os.Stdout, *format, backtest.DefaultHorizons); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -11,8 +12,11 @@ import (
 	"syscall"
 	"time"
 
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio"
+	"crypto-trackers/pkg/overrides"
+	"crypto-trackers/pkg/wire"
 	"volume-spike-detector/internal/config"
-	"volume-spike-detector/internal/kafka"
 	"volume-spike-detector/internal/signals"
 
 	"github.com/gorilla/mux"
@@ -50,20 +54,31 @@ var (
 		},
 		[]string{"symbol"},
 	)
+	poisonMessages = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_poison_messages_total",
+			Help: "Total number of consumed messages that could not be decoded",
+		},
+		[]string{"topic"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(volumeEventsProcessed)
 	prometheus.MustRegister(volumeSpikesDetected)
 	prometheus.MustRegister(volumeProcessingTime)
+	prometheus.MustRegister(poisonMessages)
 }
 
 type Server struct {
 	config   *config.Config
 	ready    bool
-	consumer *kafka.Consumer
-	producer kafka.SignalProducer
+	consumer *kafkaio.Consumer[events.PriceEvent]
+	producer kafkaio.SignalProducer
 	detector *signals.VolumeDetector
+	spike    signals.SpikeSettings
+	brokers  []string
+	codec    *wire.Codec
 }
 
 func NewServer(cfg *config.Config) *Server {
@@ -92,34 +107,104 @@ func (s *Server) setReady(ready bool) {
 	s.ready = ready
 }
 
+func applySymbolOverrides(spike *signals.SpikeSettings, path string) error {
+	symbols, err := overrides.Load[config.SymbolOverride](path)
+	if err != nil {
+		return err
+	}
+
+	spike.SymbolThresholds = make(map[string]float64)
+	for symbol, override := range symbols {
+		if override.SpikeThreshold == nil {
+			continue
+		}
+		if *override.SpikeThreshold <= 0 {
+			return fmt.Errorf("spike threshold override for %s must be positive, got %.2f", symbol, *override.SpikeThreshold)
+		}
+		spike.SymbolThresholds[symbol] = *override.SpikeThreshold
+		log.Printf("Using spike threshold %.2f for %s", *override.SpikeThreshold, symbol)
+	}
+
+	return nil
+}
+
 func (s *Server) initializeKafka() error {
 	brokers := strings.Split(s.config.KafkaBootstrapServers, ",")
+	s.brokers = brokers
 
-	producer, err := kafka.NewProducer(brokers)
+	codec, err := wire.NewCodec(s.config.KafkaWireFormat, wire.NewRegistry(s.config.SchemaRegistryURL))
 	if err != nil {
 		return err
 	}
-	s.producer = producer
+	s.codec = codec
 
-	detector := signals.NewVolumeDetector(producer, s.config.SpikeThreshold, *volumeEventsProcessed, *volumeSpikesDetected, *volumeProcessingTime)
-	s.detector = detector
+	spike, err := signals.NewSpikeSettings(s.config.SpikeMode, s.config.SpikeThreshold, s.config.EWMAAlpha, s.config.NeutralBand)
+	if err != nil {
+		return err
+	}
+	if err := applySymbolOverrides(&spike, s.config.SymbolOverridesPath); err != nil {
+		return err
+	}
+	s.spike = spike
 
-	consumer, err := kafka.NewConsumer(
-		brokers,
-		s.config.KafkaGroupID,
-		[]string{"crypto-prices"},
-		detector.ProcessPriceEvent,
-	)
+	producer, err := kafkaio.NewProducer(brokers, codec)
 	if err != nil {
 		return err
 	}
+
+	window := signals.WindowSettings{
+		Window:          time.Duration(s.config.VolumeWindowHours) * time.Hour,
+		AllowedLateness: time.Duration(s.config.AllowedLateness) * time.Second,
+	}
+
+	consumerSettings := kafkaio.DetectorSettings{
+		GroupID:         s.config.KafkaGroupID,
+		RetryTopic:      s.config.ConsumerRetryTopic,
+		Retries:         s.config.ConsumerMaxRetries,
+		Backoff:         time.Duration(s.config.ConsumerRetryBackoff) * time.Millisecond,
+		DeadLetterTopic: s.config.ConsumerDLQTopic,
+		PoisonQueue:     s.config.ConsumerPoisonDLQ,
+	}
+	outbox, consumer, err := kafkaio.NewDetectorConsumer(brokers, codec, producer, consumerSettings, *poisonMessages,
+		func(publisher kafkaio.SignalProducer) func(*events.PriceEvent) error {
+			s.detector = signals.NewVolumeDetector(publisher, spike, window, *volumeEventsProcessed, *volumeSpikesDetected, *volumeProcessingTime)
+			return s.detector.ProcessPriceEvent
+		})
+	if err != nil {
+		return err
+	}
+	s.producer = outbox
 	s.consumer = consumer
 
 	return nil
 }
 
+func (s *Server) bootstrap(ctx context.Context) error {
+	if !s.config.BootstrapEnabled {
+		return nil
+	}
+
+	since := time.Now().Add(-time.Duration(s.config.BootstrapLookback) * time.Hour)
+
+	s.detector.SetWarmingUp(true)
+	defer s.detector.SetWarmingUp(false)
+
+	log.Printf("Warming up from crypto-prices since %s", since.Format(time.RFC3339))
+	result, err := kafkaio.Replay(ctx, s.brokers, events.TopicPrices, since, s.codec, s.detector.ProcessPriceEvent)
+	if err != nil {
+		return err
+	}
+	s.consumer.SkipUntil(result.EndOffsets)
+
+	log.Printf("Warm-up complete: replayed %d price events", result.Events)
+	return nil
+}
+
 func main() {
 	cfg := config.New()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 	server := NewServer(cfg)
 
 	router := mux.NewRouter()
@@ -148,6 +233,10 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if err := server.bootstrap(ctx); err != nil {
+		log.Printf("Warm-up failed, starting with partial history: %v", err)
+	}
+
 	go func() {
 		if err := server.consumer.Start(ctx); err != nil {
 			log.Printf("Consumer error: %v", err)
@@ -155,7 +244,7 @@ func main() {
 	}()
 
 	server.setReady(true)
-	log.Printf("Volume Spike Detector is ready and consuming messages (threshold: %.1fx)", cfg.SpikeThreshold)
+	log.Printf("Volume Spike Detector is ready and consuming messages (%s mode, threshold: %.2f)", server.spike.Rule.Mode(), server.spike.Threshold)
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -5,12 +5,13 @@ go 1.22
 toolchain go1.22.2
 
 require (
-	github.com/IBM/sarama v1.42.1
+	crypto-trackers/pkg v0.0.0
 	github.com/gorilla/mux v1.8.0
 	github.com/prometheus/client_golang v1.22.0
 )
 
 require (
+	github.com/IBM/sarama v1.42.1 // indirect
 	github.com/beorn7/perks v1.0.1 // indirect
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -27,6 +28,7 @@ require (
 	github.com/jcmturner/gokrb5/v8 v8.4.4 // indirect
 	github.com/jcmturner/rpc/v2 v2.0.3 // indirect
 	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/linkedin/goavro/v2 v2.12.0 // indirect
 	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
 	github.com/pierrec/lz4/v4 v4.1.18 // indirect
 	github.com/prometheus/client_model v0.6.1 // indirect
@@ -37,4 +39,7 @@ require (
 	golang.org/x/net v0.33.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	google.golang.org/protobuf v1.36.5 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace crypto-trackers/pkg => ../../pkg
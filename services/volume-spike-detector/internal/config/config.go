@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 )
@@ -8,19 +9,65 @@ import (
 type Config struct {
 	KafkaBootstrapServers string
 	KafkaGroupID          string
+	KafkaWireFormat       string
+	SchemaRegistryURL     string
+	ConsumerMaxRetries    int
+	ConsumerRetryBackoff  int
+	ConsumerDLQTopic      string
+	ConsumerRetryTopic    string
+	ConsumerPoisonDLQ     bool
 	Port                  string
 	LogLevel              string
+	SpikeMode             string
 	SpikeThreshold        float64
+	EWMAAlpha             float64
+	NeutralBand           float64
+	SymbolOverridesPath   string
+	VolumeWindowHours     int
+	AllowedLateness       int
+	BootstrapEnabled      bool
+	BootstrapLookback     int
+}
+
+// SymbolOverride sets a symbol's spike threshold.
+type SymbolOverride struct {
+	SpikeThreshold *float64 `yaml:"spike_threshold" json:"spike_threshold,omitempty"`
 }
 
 func New() *Config {
 	return &Config{
 		KafkaBootstrapServers: getEnv("KAFKA_BOOTSTRAP_SERVERS", "kafka-service:9092"),
 		KafkaGroupID:          getEnv("KAFKA_GROUP_ID", "volume-spike-detector"),
+		KafkaWireFormat:       getEnv("KAFKA_WIRE_FORMAT", "json"),
+		SchemaRegistryURL:     getEnv("SCHEMA_REGISTRY_URL", ""),
+		ConsumerMaxRetries:    getEnvInt("CONSUMER_MAX_RETRIES", 3),
+		ConsumerRetryBackoff:  getEnvInt("CONSUMER_RETRY_BACKOFF_MS", 500),
+		ConsumerDLQTopic:      getEnv("CONSUMER_DLQ_TOPIC", "volume-spike-detector.dlq"),
+		ConsumerRetryTopic:    getEnv("CONSUMER_RETRY_TOPIC", "volume-spike-detector.retry"),
+		ConsumerPoisonDLQ:     getEnvBool("CONSUMER_POISON_DLQ_ENABLED", true),
 		Port:                  getEnv("PORT", "8080"),
 		LogLevel:              getEnv("LOG_LEVEL", "INFO"),
-		SpikeThreshold:        getEnvFloat("SPIKE_THRESHOLD", 1.3),
+		SpikeMode:             getEnv("SPIKE_MODE", "ratio"),
+		SpikeThreshold:        getEnvFloat("SPIKE_THRESHOLD", 0),
+		EWMAAlpha:             getEnvFloat("EWMA_ALPHA", 0.1),
+		NeutralBand:           getEnvFloat("NEUTRAL_BAND_PERCENT", 1.0),
+		SymbolOverridesPath:   getEnv("SYMBOL_OVERRIDES_PATH", ""),
+		VolumeWindowHours:     getEnvInt("VOLUME_WINDOW_HOURS", 168),
+		AllowedLateness:       getEnvInt("ALLOWED_LATENESS_SECONDS", 600),
+		BootstrapEnabled:      getEnvBool("BOOTSTRAP_ENABLED", false),
+		BootstrapLookback:     getEnvInt("BOOTSTRAP_LOOKBACK_HOURS", 168),
+	}
+}
+
+// Validate rejects settings the service cannot start with.
+func (c *Config) Validate() error {
+	if c.VolumeWindowHours <= 0 {
+		return fmt.Errorf("VOLUME_WINDOW_HOURS must be positive, got %d", c.VolumeWindowHours)
+	}
+	if c.AllowedLateness < 0 {
+		return fmt.Errorf("ALLOWED_LATENESS_SECONDS must not be negative, got %d", c.AllowedLateness)
 	}
+	return nil
 }
 
 func getEnv(key, defaultValue string) string {
@@ -47,3 +94,12 @@ func getEnvFloat(key string, defaultValue float64) float64 {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+
+	"crypto-trackers/pkg/overrides"
+)
+
+func TestValidate(t *testing.T) {
+	if err := New().Validate(); err != nil {
+		t.Fatalf("expected defaults to be valid, got %v", err)
+	}
+
+	for name, mutate := range map[string]func(*Config){
+		"zero window":       func(c *Config) { c.VolumeWindowHours = 0 },
+		"negative window":   func(c *Config) { c.VolumeWindowHours = -1 },
+		"negative lateness": func(c *Config) { c.AllowedLateness = -60 },
+	} {
+		cfg := New()
+		mutate(cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	cfg := New()
+	cfg.AllowedLateness = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected zero lateness to be valid, got %v", err)
+	}
+}
+
+func TestSymbolOverride(t *testing.T) {
+	symbols, err := overrides.Parse[SymbolOverride]([]byte("symbols:\n  pepe:\n    spike_threshold: 3\n  ETH: {}\n"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if pepe := symbols["PEPE"]; pepe.SpikeThreshold == nil || *pepe.SpikeThreshold != 3 {
+		t.Errorf("expected PEPE threshold 3, got %+v", pepe)
+	}
+	if eth := symbols["ETH"]; eth.SpikeThreshold != nil {
+		t.Errorf("expected unset ETH threshold, got %v", *eth.SpikeThreshold)
+	}
+}
@@ -1,15 +1,16 @@
 package signals
 
 import (
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio/kafkatest"
 	"testing"
 	"time"
-	"volume-spike-detector/internal/kafka"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 func TestVolumeDetector_EndToEndIntegration(t *testing.T) {
-	producer := &mockProducer{}
+	producer := &kafkatest.SignalProducer{}
 	threshold := 1.5
 
 	eventsProcessed := prometheus.NewCounterVec(
@@ -25,16 +26,16 @@ func TestVolumeDetector_EndToEndIntegration(t *testing.T) {
 		[]string{"symbol"},
 	)
 
-	detector := NewVolumeDetector(producer, threshold, *eventsProcessed, *spikesDetected, *processingTime)
+	detector := NewVolumeDetector(producer, ratioSettings(threshold), DefaultWindowSettings(), *eventsProcessed, *spikesDetected, *processingTime)
 
 	baseVolume := 1000000000.0
 
 	t.Run("medium spike detection", func(t *testing.T) {
-		producer.signals = nil
-		mediumDetector := NewVolumeDetector(producer, threshold, *eventsProcessed, *spikesDetected, *processingTime)
+		producer.Reset()
+		mediumDetector := NewVolumeDetector(producer, ratioSettings(threshold), DefaultWindowSettings(), *eventsProcessed, *spikesDetected, *processingTime)
 
 		for i := 0; i < 5; i++ {
-			event := &kafka.PriceEvent{
+			event := &events.PriceEvent{
 				Timestamp: time.Now().Add(time.Duration(i) * time.Hour),
 				Symbol:    "MEDIUM",
 				Volume24h: baseVolume,
@@ -42,29 +43,29 @@ func TestVolumeDetector_EndToEndIntegration(t *testing.T) {
 			mediumDetector.ProcessPriceEvent(event)
 		}
 
-		spikeEvent := &kafka.PriceEvent{
+		spikeEvent := &events.PriceEvent{
 			Timestamp: time.Now().Add(6 * time.Hour),
 			Symbol:    "MEDIUM",
 			Volume24h: baseVolume * 1.8,
 		}
 		mediumDetector.ProcessPriceEvent(spikeEvent)
 
-		if len(producer.signals) == 0 {
+		if len(producer.Signals()) == 0 {
 			t.Fatal("expected volume spike signal")
 		}
 
-		signal := producer.signals[0]
+		signal := producer.Signals()[0]
 		if signal.SignalStrength != "medium" {
 			t.Errorf("expected strength medium, got %s", signal.SignalStrength)
 		}
 	})
 
 	t.Run("strong spike detection", func(t *testing.T) {
-		producer.signals = nil
-		strongDetector := NewVolumeDetector(producer, threshold, *eventsProcessed, *spikesDetected, *processingTime)
+		producer.Reset()
+		strongDetector := NewVolumeDetector(producer, ratioSettings(threshold), DefaultWindowSettings(), *eventsProcessed, *spikesDetected, *processingTime)
 
 		for i := 0; i < 5; i++ {
-			event := &kafka.PriceEvent{
+			event := &events.PriceEvent{
 				Timestamp: time.Now().Add(time.Duration(i) * time.Hour),
 				Symbol:    "STRONG",
 				Volume24h: baseVolume,
@@ -72,18 +73,18 @@ func TestVolumeDetector_EndToEndIntegration(t *testing.T) {
 			strongDetector.ProcessPriceEvent(event)
 		}
 
-		spikeEvent := &kafka.PriceEvent{
+		spikeEvent := &events.PriceEvent{
 			Timestamp: time.Now().Add(6 * time.Hour),
 			Symbol:    "STRONG",
 			Volume24h: baseVolume * 2.5,
 		}
 		strongDetector.ProcessPriceEvent(spikeEvent)
 
-		if len(producer.signals) == 0 {
+		if len(producer.Signals()) == 0 {
 			t.Fatal("expected volume spike signal")
 		}
 
-		signal := producer.signals[0]
+		signal := producer.Signals()[0]
 		if signal.SignalStrength != "strong" {
 			t.Errorf("expected strength strong, got %s", signal.SignalStrength)
 		}
@@ -91,10 +92,11 @@ func TestVolumeDetector_EndToEndIntegration(t *testing.T) {
 
 	t.Run("historical data cleanup", func(t *testing.T) {
 		now := time.Now()
+		windowDays := int(DefaultWindow.Hours() / 24)
 
-		for i := 0; i < VolumeDays*2; i++ {
-			event := &kafka.PriceEvent{
-				Timestamp: now.Add(time.Duration(i-VolumeDays*2) * 24 * time.Hour),
+		for i := 0; i < windowDays*2; i++ {
+			event := &events.PriceEvent{
+				Timestamp: now.Add(time.Duration(i-windowDays*2) * 24 * time.Hour),
 				Symbol:    "CLEANUP",
 				Volume24h: 1000.0,
 			}
@@ -102,12 +104,12 @@ func TestVolumeDetector_EndToEndIntegration(t *testing.T) {
 		}
 
 		history := detector.volumeHistory["CLEANUP"]
-		if len(history.Volumes) > VolumeDays {
-			t.Errorf("expected history to be cleaned up to max %d days, got %d entries", VolumeDays, len(history.Volumes))
+		if len(history.Volumes) > windowDays+1 {
+			t.Errorf("expected history to be cleaned up to max %d days, got %d entries", windowDays+1, len(history.Volumes))
 		}
 
 		oldestTime := history.Timestamps[0]
-		cutoff := now.Add(-time.Duration(VolumeDays) * 24 * time.Hour)
+		cutoff := history.Watermark.Add(-DefaultWindow)
 		if oldestTime.Before(cutoff) {
 			t.Errorf("expected oldest timestamp to be after cutoff %v, got %v", cutoff, oldestTime)
 		}
@@ -115,11 +117,12 @@ func TestVolumeDetector_EndToEndIntegration(t *testing.T) {
 
 	t.Run("concurrent volume processing", func(t *testing.T) {
 		done := make(chan bool)
+		base := time.Now()
 
 		for i := 0; i < 10; i++ {
 			go func(i int) {
-				event := &kafka.PriceEvent{
-					Timestamp: time.Now().Add(time.Duration(i) * time.Hour),
+				event := &events.PriceEvent{
+					Timestamp: base.Add(time.Duration(i) * time.Second),
 					Symbol:    "CONCURRENT",
 					Volume24h: float64(1000000000 + i*100000000),
 				}
@@ -139,10 +142,10 @@ func TestVolumeDetector_EndToEndIntegration(t *testing.T) {
 	})
 
 	t.Run("no spike below threshold", func(t *testing.T) {
-		producer.signals = nil
+		producer.Reset()
 
 		for i := 0; i < 5; i++ {
-			event := &kafka.PriceEvent{
+			event := &events.PriceEvent{
 				Timestamp: time.Now().Add(time.Duration(i) * time.Hour),
 				Symbol:    "NOSPIKE",
 				Volume24h: baseVolume,
@@ -150,15 +153,15 @@ func TestVolumeDetector_EndToEndIntegration(t *testing.T) {
 			detector.ProcessPriceEvent(event)
 		}
 
-		noSpikeEvent := &kafka.PriceEvent{
+		noSpikeEvent := &events.PriceEvent{
 			Timestamp: time.Now().Add(6 * time.Hour),
 			Symbol:    "NOSPIKE",
 			Volume24h: baseVolume * 1.2,
 		}
 		detector.ProcessPriceEvent(noSpikeEvent)
 
-		if len(producer.signals) != 0 {
-			t.Errorf("expected no signals below threshold, got %d", len(producer.signals))
+		if len(producer.Signals()) != 0 {
+			t.Errorf("expected no signals below threshold, got %d", len(producer.Signals()))
 		}
 	})
 }
@@ -0,0 +1,228 @@
+package signals
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	SpikeModeRatio  = "ratio"
+	SpikeModeZScore = "zscore"
+	SpikeModeMAD    = "mad"
+	SpikeModeEWMA   = "ewma"
+
+	DefaultEWMAAlpha = 0.1
+	// MinStatisticalBaseline is the number of prior volumes the z-score, MAD
+	// and EWMA rules need before their spread estimates mean anything.
+	MinStatisticalBaseline = 10
+	// minRelativeSpread floors the spread at 1% of the baseline so a perfectly
+	// flat history does not turn any uptick into an infinite score.
+	minRelativeSpread = 0.01
+	// madScale makes MAD a consistent estimator of the standard deviation for
+	// normally distributed data.
+	madScale = 1.4826
+)
+
+// SpikeScore is the outcome of comparing the current volume with its
+// history. Value is compared against the configured threshold.
+type SpikeScore struct {
+	Value    float64
+	Baseline float64
+	Spread   float64
+}
+
+type SpikeRule interface {
+	Mode() string
+	DefaultThreshold() float64
+	Score(history []float64, current float64) (SpikeScore, bool)
+}
+
+type ratioRule struct{}
+
+func (ratioRule) Mode() string              { return SpikeModeRatio }
+func (ratioRule) DefaultThreshold() float64 { return 1.3 }
+
+func (ratioRule) Score(history []float64, current float64) (SpikeScore, bool) {
+	mean := calculateAverage(history)
+	if mean == 0 {
+		return SpikeScore{}, false
+	}
+	return SpikeScore{Value: current / mean, Baseline: mean}, true
+}
+
+type zScoreRule struct{}
+
+func (zScoreRule) Mode() string              { return SpikeModeZScore }
+func (zScoreRule) DefaultThreshold() float64 { return 3.0 }
+
+func (zScoreRule) Score(history []float64, current float64) (SpikeScore, bool) {
+	if len(history) < MinStatisticalBaseline {
+		return SpikeScore{}, false
+	}
+
+	mean := calculateAverage(history)
+	variance := 0.0
+	for _, volume := range history {
+		variance += (volume - mean) * (volume - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(len(history)-1))
+
+	return standardScore(current, mean, stdDev)
+}
+
+type madRule struct{}
+
+func (madRule) Mode() string              { return SpikeModeMAD }
+func (madRule) DefaultThreshold() float64 { return 3.5 }
+
+func (madRule) Score(history []float64, current float64) (SpikeScore, bool) {
+	if len(history) < MinStatisticalBaseline {
+		return SpikeScore{}, false
+	}
+
+	median := calculateMedian(history)
+	deviations := make([]float64, len(history))
+	for i, volume := range history {
+		deviations[i] = math.Abs(volume - median)
+	}
+
+	return standardScore(current, median, madScale*calculateMedian(deviations))
+}
+
+type ewmaRule struct {
+	alpha float64
+}
+
+func (ewmaRule) Mode() string              { return SpikeModeEWMA }
+func (ewmaRule) DefaultThreshold() float64 { return 3.0 }
+
+// Score tracks an exponentially weighted mean and variance over the history,
+// so recent volume shapes the baseline more than volume from days ago.
+func (r ewmaRule) Score(history []float64, current float64) (SpikeScore, bool) {
+	if len(history) < MinStatisticalBaseline {
+		return SpikeScore{}, false
+	}
+
+	mean := history[0]
+	variance := 0.0
+	for _, volume := range history[1:] {
+		diff := volume - mean
+		increment := r.alpha * diff
+		mean += increment
+		variance = (1 - r.alpha) * (variance + diff*increment)
+	}
+
+	return standardScore(current, mean, math.Sqrt(variance))
+}
+
+func standardScore(current, baseline, spread float64) (SpikeScore, bool) {
+	if baseline <= 0 {
+		return SpikeScore{}, false
+	}
+
+	spread = math.Max(spread, minRelativeSpread*baseline)
+	return SpikeScore{
+		Value:    (current - baseline) / spread,
+		Baseline: baseline,
+		Spread:   spread,
+	}, true
+}
+
+func NewSpikeRule(mode string, ewmaAlpha float64) (SpikeRule, error) {
+	switch strings.ToLower(mode) {
+	case SpikeModeRatio:
+		return ratioRule{}, nil
+	case SpikeModeZScore:
+		return zScoreRule{}, nil
+	case SpikeModeMAD:
+		return madRule{}, nil
+	case SpikeModeEWMA:
+		if ewmaAlpha <= 0 || ewmaAlpha > 1 {
+			return nil, fmt.Errorf("EWMA alpha must be in (0, 1], got %.3f", ewmaAlpha)
+		}
+		return ewmaRule{alpha: ewmaAlpha}, nil
+	default:
+		return nil, fmt.Errorf("unsupported spike mode %q (expected %s, %s, %s or %s)",
+			mode, SpikeModeRatio, SpikeModeZScore, SpikeModeMAD, SpikeModeEWMA)
+	}
+}
+
+type SpikeSettings struct {
+	Rule        SpikeRule
+	Threshold   float64
+	NeutralBand float64
+	// SymbolThresholds replaces Threshold for individual symbols.
+	SymbolThresholds map[string]float64
+}
+
+// NewSpikeSettings builds the rule for mode. A threshold of zero or less
+// selects the rule's default.
+func NewSpikeSettings(mode string, threshold, ewmaAlpha, neutralBand float64) (SpikeSettings, error) {
+	rule, err := NewSpikeRule(mode, ewmaAlpha)
+	if err != nil {
+		return SpikeSettings{}, err
+	}
+
+	if threshold <= 0 {
+		threshold = rule.DefaultThreshold()
+	}
+
+	if neutralBand < 0 {
+		return SpikeSettings{}, fmt.Errorf("neutral band must not be negative, got %.2f", neutralBand)
+	}
+
+	return SpikeSettings{
+		Rule:        rule,
+		Threshold:   threshold,
+		NeutralBand: neutralBand,
+	}, nil
+}
+
+func DefaultSpikeSettings() SpikeSettings {
+	rule := ratioRule{}
+	return SpikeSettings{
+		Rule:        rule,
+		Threshold:   rule.DefaultThreshold(),
+		NeutralBand: DefaultNeutralBand,
+	}
+}
+
+// ForSymbol returns the settings to use for symbol, with its threshold
+// override applied if there is one.
+func (s SpikeSettings) ForSymbol(symbol string) SpikeSettings {
+	if threshold, ok := s.SymbolThresholds[symbol]; ok {
+		s.Threshold = threshold
+	}
+	return s
+}
+
+// Strength grades a score by how far it clears the threshold. With the
+// default ratio threshold of 1.3 the cut-offs land at about 1.5x and 2.0x.
+func (s SpikeSettings) Strength(score float64) string {
+	switch {
+	case score >= 1.5*s.Threshold:
+		return "strong"
+	case score >= 1.15*s.Threshold:
+		return "medium"
+	default:
+		return "weak"
+	}
+}
+
+func calculateMedian(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
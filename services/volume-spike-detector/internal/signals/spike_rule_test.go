@@ -0,0 +1,265 @@
+package signals
+
+import (
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio/kafkatest"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// noisyVolumes alternates around base so the history has a known spread.
+func noisyVolumes(base, amplitude float64, count int) []float64 {
+	volumes := make([]float64, count)
+	for i := range volumes {
+		if i%2 == 0 {
+			volumes[i] = base + amplitude
+		} else {
+			volumes[i] = base - amplitude
+		}
+	}
+	return volumes
+}
+
+func TestNewSpikeRule(t *testing.T) {
+	for _, mode := range []string{SpikeModeRatio, SpikeModeZScore, SpikeModeMAD, SpikeModeEWMA, "ZScore"} {
+		t.Run(mode, func(t *testing.T) {
+			if _, err := NewSpikeRule(mode, DefaultEWMAAlpha); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+
+	t.Run("unsupported mode", func(t *testing.T) {
+		if _, err := NewSpikeRule("percentile", DefaultEWMAAlpha); err == nil {
+			t.Error("expected error for unsupported mode")
+		}
+	})
+
+	t.Run("invalid EWMA alpha", func(t *testing.T) {
+		if _, err := NewSpikeRule(SpikeModeEWMA, 0); err == nil {
+			t.Error("expected error for zero alpha")
+		}
+	})
+}
+
+func TestNewSpikeSettings_DefaultThresholdPerMode(t *testing.T) {
+	tests := []struct {
+		mode     string
+		expected float64
+	}{
+		{SpikeModeRatio, 1.3},
+		{SpikeModeZScore, 3.0},
+		{SpikeModeMAD, 3.5},
+		{SpikeModeEWMA, 3.0},
+	}
+
+	for _, tt := range tests {
+		settings, err := NewSpikeSettings(tt.mode, 0, DefaultEWMAAlpha, DefaultNeutralBand)
+		if err != nil {
+			t.Fatalf("expected no error for %s, got %v", tt.mode, err)
+		}
+		if settings.Threshold != tt.expected {
+			t.Errorf("expected %s default threshold %.1f, got %.1f", tt.mode, tt.expected, settings.Threshold)
+		}
+	}
+
+	settings, _ := NewSpikeSettings(SpikeModeZScore, 4.5, DefaultEWMAAlpha, DefaultNeutralBand)
+	if settings.Threshold != 4.5 {
+		t.Errorf("expected explicit threshold 4.5, got %.1f", settings.Threshold)
+	}
+}
+
+func TestSpikeRules_Score(t *testing.T) {
+	history := noisyVolumes(1000, 100, 20)
+
+	t.Run("z-score", func(t *testing.T) {
+		score, ok := zScoreRule{}.Score(history, 1500)
+		if !ok {
+			t.Fatal("expected a score")
+		}
+		// Sample standard deviation of ±100 over 20 points is 100*sqrt(20/19)
+		expected := 500 / (100 * math.Sqrt(20.0/19.0))
+		if math.Abs(score.Value-expected) > 1e-9 {
+			t.Errorf("expected z-score %.4f, got %.4f", expected, score.Value)
+		}
+	})
+
+	t.Run("MAD ignores earlier spikes", func(t *testing.T) {
+		spiky := append([]float64{}, history...)
+		spiky[3], spiky[7] = 50000, 60000
+
+		mad, _ := madRule{}.Score(spiky, 2000)
+		z, _ := zScoreRule{}.Score(spiky, 2000)
+
+		if mad.Value < 3.5 {
+			t.Errorf("expected MAD score to stay above 3.5 despite prior spikes, got %.2f", mad.Value)
+		}
+		if z.Value > 1 {
+			t.Errorf("expected prior spikes to mask the z-score, got %.2f", z.Value)
+		}
+	})
+
+	t.Run("EWMA follows recent volume", func(t *testing.T) {
+		rising := append(noisyVolumes(1000, 10, 20), noisyVolumes(3000, 10, 30)...)
+
+		ewma, _ := ewmaRule{alpha: 0.2}.Score(rising, 3000)
+		ratio, _ := ratioRule{}.Score(rising, 3000)
+
+		if math.Abs(ewma.Baseline-3000) > 50 {
+			t.Errorf("expected EWMA baseline near recent volume 3000, got %.0f", ewma.Baseline)
+		}
+		if ewma.Value > 3 {
+			t.Errorf("expected volume at the new level not to score as a spike, got %.2f", ewma.Value)
+		}
+		if ratio.Value <= 1 {
+			t.Errorf("expected the ratio rule to see 3000 above the window mean, got %.2f", ratio.Value)
+		}
+	})
+
+	t.Run("statistical modes need a minimum baseline", func(t *testing.T) {
+		short := history[:MinStatisticalBaseline-1]
+		for _, rule := range []SpikeRule{zScoreRule{}, madRule{}, ewmaRule{alpha: DefaultEWMAAlpha}} {
+			if _, ok := rule.Score(short, 5000); ok {
+				t.Errorf("expected %s to need %d points", rule.Mode(), MinStatisticalBaseline)
+			}
+		}
+	})
+
+	t.Run("flat history has a floored spread", func(t *testing.T) {
+		flat := noisyVolumes(1000, 0, 20)
+		score, ok := zScoreRule{}.Score(flat, 1020)
+		if !ok || math.IsInf(score.Value, 0) {
+			t.Fatalf("expected a finite score, got %v", score.Value)
+		}
+		if score.Value != 2 {
+			t.Errorf("expected score 2 against a 1%% spread floor, got %.2f", score.Value)
+		}
+	})
+}
+
+func TestSpikeSettings_Strength(t *testing.T) {
+	settings := SpikeSettings{Threshold: 3}
+
+	tests := []struct {
+		score    float64
+		expected string
+	}{
+		{3.1, "weak"},
+		{3.5, "medium"},
+		{4.5, "strong"},
+		{10, "strong"},
+	}
+
+	for _, tt := range tests {
+		if strength := settings.Strength(tt.score); strength != tt.expected {
+			t.Errorf("expected %s for score %.1f, got %s", tt.expected, tt.score, strength)
+		}
+	}
+}
+
+func TestVolumeDetector_ZScoreMode(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+
+	eventsProcessed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_events_processed", Help: "test"},
+		[]string{"symbol"},
+	)
+	spikesDetected := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_spikes_detected", Help: "test"},
+		[]string{"symbol"},
+	)
+	processingTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "test_processing_time", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	settings, err := NewSpikeSettings(SpikeModeZScore, 0, DefaultEWMAAlpha, DefaultNeutralBand)
+	if err != nil {
+		t.Fatalf("failed to create settings: %v", err)
+	}
+	detector := NewVolumeDetector(producer, settings, DefaultWindowSettings(), *eventsProcessed, *spikesDetected, *processingTime)
+
+	now := time.Now()
+	for i, volume := range noisyVolumes(1000000000, 50000000, 20) {
+		detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: now.Add(time.Duration(i) * time.Hour),
+			Symbol:    "BTC",
+			Volume24h: volume,
+		})
+	}
+
+	// 1.2x the mean clears three standard deviations of this quiet history
+	detector.ProcessPriceEvent(&events.PriceEvent{
+		Timestamp: now.Add(21 * time.Hour),
+		Symbol:    "BTC",
+		Volume24h: 1200000000,
+	})
+
+	if len(producer.Signals()) != 1 {
+		t.Fatalf("expected 1 signal, got %d", len(producer.Signals()))
+	}
+
+	signal := producer.Signals()[0]
+	if signal.Details["spike_mode"] != SpikeModeZScore {
+		t.Errorf("expected spike_mode zscore, got %v", signal.Details["spike_mode"])
+	}
+	if score := signal.Details["spike_score"].(float64); score < 3 {
+		t.Errorf("expected spike_score above 3, got %.2f", score)
+	}
+	if signal.SignalStrength != "medium" {
+		t.Errorf("expected medium strength for a score of about 3.9, got %s", signal.SignalStrength)
+	}
+}
+
+func TestSpikeSettings_ForSymbol(t *testing.T) {
+	settings := DefaultSpikeSettings()
+	settings.SymbolThresholds = map[string]float64{"PEPE": 3.0}
+
+	if threshold := settings.ForSymbol("PEPE").Threshold; threshold != 3.0 {
+		t.Errorf("expected PEPE override 3.0, got %.1f", threshold)
+	}
+	if threshold := settings.ForSymbol("BTC").Threshold; threshold != 1.3 {
+		t.Errorf("expected BTC to fall back to 1.3, got %.1f", threshold)
+	}
+
+	producer := &kafkatest.SignalProducer{}
+	eventsProcessed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_events_processed", Help: "test"},
+		[]string{"symbol"},
+	)
+	spikesDetected := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_spikes_detected", Help: "test"},
+		[]string{"symbol"},
+	)
+	processingTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "test_processing_time", Help: "test"},
+		[]string{"symbol"},
+	)
+	detector := NewVolumeDetector(producer, settings, DefaultWindowSettings(), *eventsProcessed, *spikesDetected, *processingTime)
+
+	now := time.Now()
+	for _, symbol := range []string{"BTC", "PEPE"} {
+		for i := 0; i < 5; i++ {
+			detector.ProcessPriceEvent(&events.PriceEvent{
+				Timestamp: now.Add(time.Duration(i) * time.Hour),
+				Symbol:    symbol,
+				Volume24h: 1000000.0,
+			})
+		}
+		detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: now.Add(6 * time.Hour),
+			Symbol:    symbol,
+			Volume24h: 2000000.0,
+		})
+	}
+
+	if len(producer.Signals()) != 1 || producer.Signals()[0].Symbol != "BTC" {
+		t.Fatalf("expected only BTC to spike at 2x, got %d signals", len(producer.Signals()))
+	}
+	if producer.Signals()[0].Details["threshold_exceeded"] != 1.3 {
+		t.Errorf("expected BTC threshold 1.3 in details, got %v", producer.Signals()[0].Details["threshold_exceeded"])
+	}
+}
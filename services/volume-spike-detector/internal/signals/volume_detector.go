@@ -2,41 +2,44 @@ package signals
 
 import (
 	"context"
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio"
 	"fmt"
 	"log"
 	"sync"
 	"time"
-	"volume-spike-detector/internal/kafka"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-const (
-	VolumeDays     = 7
-	HoursInDay     = 24
-	MaxHistorySize = VolumeDays * HoursInDay
-)
+// DefaultNeutralBand is the absolute 24h price change, in percent, inside
+// which a volume spike is reported as neutral rather than directional.
+const DefaultNeutralBand = 1.0
 
 type VolumeHistory struct {
 	Volumes    []float64
 	Timestamps []time.Time
+	Watermark  time.Time
 	mutex      sync.RWMutex
 }
 
 type VolumeDetector struct {
 	volumeHistory   map[string]*VolumeHistory
-	threshold       float64
+	settings        SpikeSettings
+	window          WindowSettings
+	warmingUp       bool
 	mutex           sync.RWMutex
-	producer        kafka.SignalProducer
+	producer        kafkaio.SignalProducer
 	eventsProcessed prometheus.CounterVec
 	spikesDetected  prometheus.CounterVec
 	processingTime  prometheus.HistogramVec
 }
 
-func NewVolumeDetector(producer kafka.SignalProducer, threshold float64, eventsProcessed, spikesDetected prometheus.CounterVec, processingTime prometheus.HistogramVec) *VolumeDetector {
+func NewVolumeDetector(producer kafkaio.SignalProducer, settings SpikeSettings, window WindowSettings, eventsProcessed, spikesDetected prometheus.CounterVec, processingTime prometheus.HistogramVec) *VolumeDetector {
 	return &VolumeDetector{
 		volumeHistory:   make(map[string]*VolumeHistory),
-		threshold:       threshold,
+		settings:        settings,
+		window:          window,
 		producer:        producer,
 		eventsProcessed: eventsProcessed,
 		spikesDetected:  spikesDetected,
@@ -44,7 +47,15 @@ func NewVolumeDetector(producer kafka.SignalProducer, threshold float64, eventsP
 	}
 }
 
-func (vd *VolumeDetector) ProcessPriceEvent(event *kafka.PriceEvent) error {
+// SetWarmingUp toggles warm-up mode, in which volume history is built up as
+// usual but no spike signals are published.
+func (vd *VolumeDetector) SetWarmingUp(warmingUp bool) {
+	vd.mutex.Lock()
+	defer vd.mutex.Unlock()
+	vd.warmingUp = warmingUp
+}
+
+func (vd *VolumeDetector) ProcessPriceEvent(event *events.PriceEvent) error {
 	start := time.Now()
 	defer func() {
 		vd.processingTime.WithLabelValues(event.Symbol).Observe(time.Since(start).Seconds())
@@ -58,8 +69,8 @@ func (vd *VolumeDetector) ProcessPriceEvent(event *kafka.PriceEvent) error {
 	history, exists := vd.volumeHistory[event.Symbol]
 	if !exists {
 		history = &VolumeHistory{
-			Volumes:    make([]float64, 0, MaxHistorySize),
-			Timestamps: make([]time.Time, 0, MaxHistorySize),
+			Volumes:    make([]float64, 0),
+			Timestamps: make([]time.Time, 0),
 		}
 		vd.volumeHistory[event.Symbol] = history
 		log.Printf("Started tracking volume history for %s", event.Symbol)
@@ -68,94 +79,117 @@ func (vd *VolumeDetector) ProcessPriceEvent(event *kafka.PriceEvent) error {
 	history.mutex.Lock()
 	defer history.mutex.Unlock()
 
-	now := time.Now()
-	cutoff := now.Add(-time.Duration(VolumeDays) * 24 * time.Hour)
-
-	for i := 0; i < len(history.Timestamps); {
-		if history.Timestamps[i].Before(cutoff) {
-			history.Volumes = history.Volumes[1:]
-			history.Timestamps = history.Timestamps[1:]
-		} else {
-			break
-		}
+	switch history.insert(event.Timestamp, event.Volume24h, vd.window.AllowedLateness) {
+	case rejectedLate:
+		log.Printf("Rejected late volume event for %s at %s (watermark: %s)",
+			event.Symbol, event.Timestamp.Format(time.RFC3339), history.Watermark.Format(time.RFC3339))
+		return nil
+	case rejectedDuplicate:
+		log.Printf("Dropped duplicate volume event for %s at %s",
+			event.Symbol, event.Timestamp.Format(time.RFC3339))
+		return nil
+	case insertedLate:
+		history.evict(vd.window)
+		log.Printf("Reordered late volume event for %s at %s (history: %d points)",
+			event.Symbol, event.Timestamp.Format(time.RFC3339), len(history.Volumes))
+		return nil
 	}
 
-	history.Volumes = append(history.Volumes, event.Volume24h)
-	history.Timestamps = append(history.Timestamps, event.Timestamp)
-
-	if len(history.Volumes) > MaxHistorySize {
-		history.Volumes = history.Volumes[1:]
-		history.Timestamps = history.Timestamps[1:]
-	}
+	history.evict(vd.window)
 
 	volumeCount := len(history.Volumes)
 	log.Printf("Processed volume event for %s: %.0f (history: %d points)", event.Symbol, event.Volume24h, volumeCount)
 
-	if volumeCount >= 2 {
-		return vd.checkForVolumeSpike(event.Symbol, event.Timestamp, event.Volume24h, history.Volumes)
+	if volumeCount >= 2 && !vd.warmingUp {
+		return vd.checkForVolumeSpike(event, history.Volumes)
 	}
 
 	return nil
 }
 
-func (vd *VolumeDetector) checkForVolumeSpike(symbol string, timestamp time.Time, currentVolume float64, volumeHistory []float64) error {
+func (vd *VolumeDetector) checkForVolumeSpike(event *events.PriceEvent, volumeHistory []float64) error {
 	if len(volumeHistory) < 2 {
 		return nil
 	}
 
-	avg7Day := calculateAverage(volumeHistory[:len(volumeHistory)-1])
+	baseline := volumeHistory[:len(volumeHistory)-1]
+	avg7Day := calculateAverage(baseline)
 
 	if avg7Day == 0 {
 		return nil
 	}
 
-	spikeMultiplier := currentVolume / avg7Day
+	settings := vd.settings.ForSymbol(event.Symbol)
+	score, ok := settings.Rule.Score(baseline, event.Volume24h)
+	if !ok {
+		return nil
+	}
 
-	if spikeMultiplier > vd.threshold {
-		return vd.publishVolumeSpike(symbol, timestamp, currentVolume, avg7Day, spikeMultiplier)
+	if score.Value > settings.Threshold {
+		return vd.publishVolumeSpike(event, settings, avg7Day, score)
 	}
 
 	return nil
 }
 
-func (vd *VolumeDetector) publishVolumeSpike(symbol string, timestamp time.Time, currentVolume, avg7Day, spikeMultiplier float64) error {
+func (vd *VolumeDetector) publishVolumeSpike(event *events.PriceEvent, settings SpikeSettings, avg7Day float64, score SpikeScore) error {
+	symbol := event.Symbol
+	currentVolume := event.Volume24h
+	spikeMultiplier := currentVolume / avg7Day
+
 	vd.spikesDetected.WithLabelValues(symbol).Inc()
 
-	signalStrength := "medium"
-	if spikeMultiplier > 2.0 {
-		signalStrength = "strong"
-	} else if spikeMultiplier < 1.5 {
-		signalStrength = "weak"
-	}
+	signalStrength := settings.Strength(score.Value)
+	direction := spikeDirection(event.PriceChange24h, settings.NeutralBand)
 
-	signal := &kafka.TradingSignal{
-		Timestamp:      timestamp,
+	signal := &events.TradingSignal{
+		SchemaVersion:  events.SchemaVersion,
+		Timestamp:      event.Timestamp,
 		Symbol:         symbol,
-		SignalType:     "volume_spike",
 		SignalStrength: signalStrength,
-		Direction:      "bullish",
-		Details: map[string]interface{}{
-			"current_volume":     currentVolume,
-			"avg_volume_7d":      avg7Day,
-			"spike_multiplier":   spikeMultiplier,
-			"threshold_exceeded": vd.threshold,
-		},
-		ServiceID: "volume-detector-v1",
+		Direction:      direction,
+		ServiceID:      "volume-detector-v1",
+	}
+	if err := signal.SetDetails(&events.VolumeSpikeDetails{
+		CurrentVolume:     currentVolume,
+		AvgVolume7d:       avg7Day,
+		SpikeMultiplier:   spikeMultiplier,
+		ThresholdExceeded: settings.Threshold,
+		WindowHours:       vd.window.Window.Hours(),
+		PriceChange24h:    event.PriceChange24h,
+		NeutralBand:       settings.NeutralBand,
+		SpikeMode:         settings.Rule.Mode(),
+		SpikeScore:        score.Value,
+		BaselineVolume:    score.Baseline,
+	}); err != nil {
+		return fmt.Errorf("failed to encode volume spike signal for %s: %w", symbol, err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := vd.producer.PublishSignal(ctx, "trading-signals", signal); err != nil {
+	if err := vd.producer.PublishSignal(ctx, events.TopicSignals, signal); err != nil {
 		log.Printf("Failed to publish volume spike signal for %s: %v", symbol, err)
 		return fmt.Errorf("failed to publish volume spike signal for %s: %w", symbol, err)
 	}
 
-	log.Printf("Published volume spike signal for %s (%.1fx spike: current=%.0f, avg=%.0f)",
-		symbol, spikeMultiplier, currentVolume, avg7Day)
+	log.Printf("Published %s volume spike signal for %s (%s score %.2f, %.1fx spike: current=%.0f, avg=%.0f, price change=%.2f%%)",
+		direction, symbol, settings.Rule.Mode(), score.Value, spikeMultiplier, currentVolume, avg7Day, event.PriceChange24h)
 	return nil
 }
 
+// spikeDirection reads a spike on rising prices as breakout volume and one on
+// falling prices as capitulation; moves within the neutral band are neither.
+func spikeDirection(priceChange, neutralBand float64) string {
+	if priceChange > neutralBand {
+		return "bullish"
+	}
+	if priceChange < -neutralBand {
+		return "bearish"
+	}
+	return "neutral"
+}
+
 func calculateAverage(volumes []float64) float64 {
 	if len(volumes) == 0 {
 		return 0
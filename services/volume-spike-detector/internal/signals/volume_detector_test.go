@@ -1,36 +1,22 @@
 package signals
 
 import (
-	"context"
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio/kafkatest"
 	"testing"
 	"time"
-	"volume-spike-detector/internal/kafka"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-type mockProducer struct {
-	signals   []*kafka.TradingSignal
-	shouldErr bool
-}
-
-func (m *mockProducer) PublishSignal(ctx context.Context, topic string, signal *kafka.TradingSignal) error {
-	if ctx.Err() != nil {
-		return ctx.Err()
-	}
-	if m.shouldErr {
-		return context.DeadlineExceeded
-	}
-	m.signals = append(m.signals, signal)
-	return nil
-}
-
-func (m *mockProducer) Close() error {
-	return nil
+func ratioSettings(threshold float64) SpikeSettings {
+	settings := DefaultSpikeSettings()
+	settings.Threshold = threshold
+	return settings
 }
 
 func TestVolumeDetector_ProcessPriceEvent(t *testing.T) {
-	producer := &mockProducer{}
+	producer := &kafkatest.SignalProducer{}
 	threshold := 1.5
 
 	eventsProcessed := prometheus.NewCounterVec(
@@ -46,10 +32,10 @@ func TestVolumeDetector_ProcessPriceEvent(t *testing.T) {
 		[]string{"symbol"},
 	)
 
-	detector := NewVolumeDetector(producer, threshold, *eventsProcessed, *spikesDetected, *processingTime)
+	detector := NewVolumeDetector(producer, ratioSettings(threshold), DefaultWindowSettings(), *eventsProcessed, *spikesDetected, *processingTime)
 
 	t.Run("first volume event creates history", func(t *testing.T) {
-		event := &kafka.PriceEvent{
+		event := &events.PriceEvent{
 			Timestamp: time.Now(),
 			Symbol:    "BTC",
 			Volume24h: 1000000000.0,
@@ -75,7 +61,7 @@ func TestVolumeDetector_ProcessPriceEvent(t *testing.T) {
 	})
 
 	t.Run("insufficient data no spike detection", func(t *testing.T) {
-		event := &kafka.PriceEvent{
+		event := &events.PriceEvent{
 			Timestamp: time.Now(),
 			Symbol:    "ETH",
 			Volume24h: 500000000.0,
@@ -83,17 +69,17 @@ func TestVolumeDetector_ProcessPriceEvent(t *testing.T) {
 
 		detector.ProcessPriceEvent(event)
 
-		if len(producer.signals) != 0 {
-			t.Errorf("expected no signals with insufficient data, got %d", len(producer.signals))
+		if len(producer.Signals()) != 0 {
+			t.Errorf("expected no signals with insufficient data, got %d", len(producer.Signals()))
 		}
 	})
 
 	t.Run("volume spike detection", func(t *testing.T) {
-		producer.signals = nil
+		producer.Reset()
 
 		baseVolume := 1000000000.0
 		for i := 0; i < 5; i++ {
-			event := &kafka.PriceEvent{
+			event := &events.PriceEvent{
 				Timestamp: time.Now().Add(time.Duration(i) * time.Hour),
 				Symbol:    "SPIKE",
 				Volume24h: baseVolume,
@@ -101,18 +87,18 @@ func TestVolumeDetector_ProcessPriceEvent(t *testing.T) {
 			detector.ProcessPriceEvent(event)
 		}
 
-		spikeEvent := &kafka.PriceEvent{
+		spikeEvent := &events.PriceEvent{
 			Timestamp: time.Now().Add(6 * time.Hour),
 			Symbol:    "SPIKE",
 			Volume24h: baseVolume * 2.0,
 		}
 		detector.ProcessPriceEvent(spikeEvent)
 
-		if len(producer.signals) == 0 {
+		if len(producer.Signals()) == 0 {
 			t.Error("expected volume spike signal to be generated")
 		}
 
-		signal := producer.signals[0]
+		signal := producer.Signals()[0]
 		if signal.SignalType != "volume_spike" {
 			t.Errorf("expected signal type 'volume_spike', got %s", signal.SignalType)
 		}
@@ -171,7 +157,7 @@ func TestCalculateAverage(t *testing.T) {
 }
 
 func TestVolumeDetector_HistoryManagement(t *testing.T) {
-	producer := &mockProducer{}
+	producer := &kafkatest.SignalProducer{}
 
 	eventsProcessed := prometheus.NewCounterVec(
 		prometheus.CounterOpts{Name: "test_events_processed", Help: "test"},
@@ -186,19 +172,19 @@ func TestVolumeDetector_HistoryManagement(t *testing.T) {
 		[]string{"symbol"},
 	)
 
-	detector := NewVolumeDetector(producer, 1.3, *eventsProcessed, *spikesDetected, *processingTime)
+	detector := NewVolumeDetector(producer, ratioSettings(1.3), DefaultWindowSettings(), *eventsProcessed, *spikesDetected, *processingTime)
 
 	now := time.Now()
-	cutoff := now.Add(-time.Duration(VolumeDays+1) * 24 * time.Hour)
+	cutoff := now.Add(-DefaultWindow - 24*time.Hour)
 
-	oldEvent := &kafka.PriceEvent{
+	oldEvent := &events.PriceEvent{
 		Timestamp: cutoff,
 		Symbol:    "OLD",
 		Volume24h: 1000.0,
 	}
 	detector.ProcessPriceEvent(oldEvent)
 
-	newEvent := &kafka.PriceEvent{
+	newEvent := &events.PriceEvent{
 		Timestamp: now,
 		Symbol:    "OLD",
 		Volume24h: 2000.0,
@@ -214,3 +200,137 @@ func TestVolumeDetector_HistoryManagement(t *testing.T) {
 		t.Errorf("expected remaining volume 2000.0, got %f", history.Volumes[0])
 	}
 }
+
+func TestVolumeDetector_WarmUpSuppressesSignals(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+
+	eventsProcessed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_events_processed", Help: "test"},
+		[]string{"symbol"},
+	)
+	spikesDetected := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_spikes_detected", Help: "test"},
+		[]string{"symbol"},
+	)
+	processingTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "test_processing_time", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	detector := NewVolumeDetector(producer, ratioSettings(1.5), DefaultWindowSettings(), *eventsProcessed, *spikesDetected, *processingTime)
+	detector.SetWarmingUp(true)
+
+	baseVolume := 1000000000.0
+	for i := 0; i < 5; i++ {
+		detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: time.Now().Add(time.Duration(i) * time.Hour),
+			Symbol:    "WARM",
+			Volume24h: baseVolume,
+		})
+	}
+	detector.ProcessPriceEvent(&events.PriceEvent{
+		Timestamp: time.Now().Add(6 * time.Hour),
+		Symbol:    "WARM",
+		Volume24h: baseVolume * 3,
+	})
+
+	if len(producer.Signals()) != 0 {
+		t.Errorf("expected no signals during warm-up, got %d", len(producer.Signals()))
+	}
+
+	if len(detector.volumeHistory["WARM"].Volumes) != 6 {
+		t.Errorf("expected warm-up to fill history, got %d volumes", len(detector.volumeHistory["WARM"].Volumes))
+	}
+
+	detector.SetWarmingUp(false)
+	detector.ProcessPriceEvent(&events.PriceEvent{
+		Timestamp: time.Now().Add(7 * time.Hour),
+		Symbol:    "WARM",
+		Volume24h: baseVolume * 3,
+	})
+
+	if len(producer.Signals()) != 1 {
+		t.Errorf("expected live spike after warm-up, got %d signals", len(producer.Signals()))
+	}
+}
+
+func TestSpikeDirection(t *testing.T) {
+	tests := []struct {
+		name        string
+		priceChange float64
+		expected    string
+	}{
+		{"rally", 4.2, "bullish"},
+		{"sell-off", -6.5, "bearish"},
+		{"flat", 0.3, "neutral"},
+		{"small dip", -0.8, "neutral"},
+		{"on the band edge", 1.0, "neutral"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if direction := spikeDirection(tt.priceChange, DefaultNeutralBand); direction != tt.expected {
+				t.Errorf("expected %s for %.1f%% change, got %s", tt.expected, tt.priceChange, direction)
+			}
+		})
+	}
+}
+
+func TestVolumeDetector_DirectionalSpikes(t *testing.T) {
+	eventsProcessed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_events_processed", Help: "test"},
+		[]string{"symbol"},
+	)
+	spikesDetected := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_spikes_detected", Help: "test"},
+		[]string{"symbol"},
+	)
+	processingTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "test_processing_time", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	tests := []struct {
+		name        string
+		priceChange float64
+		expected    string
+	}{
+		{"breakout volume", 5.0, "bullish"},
+		{"capitulation volume", -12.0, "bearish"},
+		{"volume without a move", 0.5, "neutral"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &kafkatest.SignalProducer{}
+			detector := NewVolumeDetector(producer, ratioSettings(1.5), DefaultWindowSettings(), *eventsProcessed, *spikesDetected, *processingTime)
+
+			now := time.Now()
+			for i := 0; i < 5; i++ {
+				detector.ProcessPriceEvent(&events.PriceEvent{
+					Timestamp: now.Add(time.Duration(i) * time.Hour),
+					Symbol:    "BTC",
+					Volume24h: 1000000000.0,
+				})
+			}
+			detector.ProcessPriceEvent(&events.PriceEvent{
+				Timestamp:      now.Add(6 * time.Hour),
+				Symbol:         "BTC",
+				Volume24h:      2500000000.0,
+				PriceChange24h: tt.priceChange,
+			})
+
+			if len(producer.Signals()) != 1 {
+				t.Fatalf("expected 1 signal, got %d", len(producer.Signals()))
+			}
+
+			signal := producer.Signals()[0]
+			if signal.Direction != tt.expected {
+				t.Errorf("expected direction %s, got %s", tt.expected, signal.Direction)
+			}
+			if signal.Details["price_change_24h"] != tt.priceChange {
+				t.Errorf("expected price_change_24h %.1f, got %v", tt.priceChange, signal.Details["price_change_24h"])
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package signals
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	DefaultWindow          = 7 * 24 * time.Hour
+	DefaultAllowedLateness = 10 * time.Minute
+	// EventInterval is the finest event spacing the history is sized for
+	EventInterval = time.Minute
+)
+
+type WindowSettings struct {
+	Window          time.Duration
+	AllowedLateness time.Duration
+}
+
+// MaxHistorySize caps memory per symbol at one event per EventInterval over
+// the window and its allowed lateness.
+func (w WindowSettings) MaxHistorySize() int {
+	return int((w.Window + w.AllowedLateness) / EventInterval)
+}
+
+func DefaultWindowSettings() WindowSettings {
+	return WindowSettings{
+		Window:          DefaultWindow,
+		AllowedLateness: DefaultAllowedLateness,
+	}
+}
+
+type insertResult int
+
+const (
+	insertedInOrder insertResult = iota
+	insertedLate
+	rejectedLate
+	rejectedDuplicate
+)
+
+// insert places the volume by event time. Events older than the watermark by
+// more than the allowed lateness are rejected; later-but-tolerated events are
+// reordered into place. An event at a timestamp the history already holds,
+// such as a redelivery, is rejected as a duplicate. Callers must hold the
+// history lock.
+func (h *VolumeHistory) insert(timestamp time.Time, volume float64, allowedLateness time.Duration) insertResult {
+	if len(h.Timestamps) == 0 || timestamp.After(h.Watermark) {
+		h.Volumes = append(h.Volumes, volume)
+		h.Timestamps = append(h.Timestamps, timestamp)
+		h.Watermark = timestamp
+		return insertedInOrder
+	}
+
+	if h.Watermark.Sub(timestamp) > allowedLateness {
+		return rejectedLate
+	}
+
+	index := sort.Search(len(h.Timestamps), func(i int) bool {
+		return h.Timestamps[i].After(timestamp)
+	})
+	if index > 0 && h.Timestamps[index-1].Equal(timestamp) {
+		return rejectedDuplicate
+	}
+
+	h.Volumes = append(h.Volumes, 0)
+	copy(h.Volumes[index+1:], h.Volumes[index:])
+	h.Volumes[index] = volume
+
+	h.Timestamps = append(h.Timestamps, time.Time{})
+	copy(h.Timestamps[index+1:], h.Timestamps[index:])
+	h.Timestamps[index] = timestamp
+
+	return insertedLate
+}
+
+// evict drops points that fell out of the window measured back from the
+// watermark, then enforces the window's MaxHistorySize. Callers must hold the
+// history lock.
+func (h *VolumeHistory) evict(window WindowSettings) {
+	cutoff := h.Watermark.Add(-window.Window)
+
+	drop := sort.Search(len(h.Timestamps), func(i int) bool {
+		return !h.Timestamps[i].Before(cutoff)
+	})
+
+	if overflow := len(h.Volumes) - drop - window.MaxHistorySize(); overflow > 0 {
+		drop += overflow
+	}
+
+	if drop > 0 {
+		h.Volumes = h.Volumes[drop:]
+		h.Timestamps = h.Timestamps[drop:]
+	}
+}
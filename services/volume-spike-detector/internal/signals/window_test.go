@@ -0,0 +1,192 @@
+package signals
+
+import (
+	"crypto-trackers/pkg/events"
+	"crypto-trackers/pkg/kafkaio/kafkatest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestVolumeHistory_Insert(t *testing.T) {
+	base := time.Date(2024, 6, 16, 12, 0, 0, 0, time.UTC)
+
+	t.Run("in order events appended", func(t *testing.T) {
+		history := &VolumeHistory{}
+		history.insert(base, 1, time.Minute)
+		result := history.insert(base.Add(time.Minute), 2, time.Minute)
+
+		if result != insertedInOrder {
+			t.Errorf("expected in order insert, got %v", result)
+		}
+		if !history.Watermark.Equal(base.Add(time.Minute)) {
+			t.Errorf("expected watermark to advance, got %v", history.Watermark)
+		}
+	})
+
+	t.Run("late event within lateness reordered", func(t *testing.T) {
+		history := &VolumeHistory{}
+		history.insert(base, 1, 5*time.Minute)
+		history.insert(base.Add(4*time.Minute), 3, 5*time.Minute)
+		result := history.insert(base.Add(2*time.Minute), 2, 5*time.Minute)
+
+		if result != insertedLate {
+			t.Errorf("expected late insert, got %v", result)
+		}
+		for i, expected := range []float64{1, 2, 3} {
+			if history.Volumes[i] != expected {
+				t.Errorf("expected volume %f at %d, got %f", expected, i, history.Volumes[i])
+			}
+		}
+		if !history.Watermark.Equal(base.Add(4 * time.Minute)) {
+			t.Errorf("expected watermark unchanged by late event, got %v", history.Watermark)
+		}
+	})
+
+	t.Run("duplicate events rejected", func(t *testing.T) {
+		for _, lateness := range []time.Duration{0, 5 * time.Minute} {
+			history := &VolumeHistory{}
+			history.insert(base, 1, lateness)
+			history.insert(base.Add(2*time.Minute), 2, lateness)
+
+			if result := history.insert(base.Add(2*time.Minute), 3, lateness); result != rejectedDuplicate {
+				t.Errorf("lateness %s: expected event at the watermark rejected, got %v", lateness, result)
+			}
+			if lateness > 0 {
+				if result := history.insert(base, 3, lateness); result != rejectedDuplicate {
+					t.Errorf("lateness %s: expected late duplicate rejected, got %v", lateness, result)
+				}
+			}
+			if len(history.Volumes) != 2 || history.Volumes[1] != 2 {
+				t.Errorf("lateness %s: expected duplicates not stored, got %v", lateness, history.Volumes)
+			}
+		}
+	})
+
+	t.Run("zero lateness rejects out of order events", func(t *testing.T) {
+		history := &VolumeHistory{}
+		history.insert(base.Add(time.Minute), 1, 0)
+
+		if result := history.insert(base, 2, 0); result != rejectedLate {
+			t.Errorf("expected rejection, got %v", result)
+		}
+	})
+
+	t.Run("event beyond lateness rejected", func(t *testing.T) {
+		history := &VolumeHistory{}
+		history.insert(base.Add(time.Hour), 1, 5*time.Minute)
+		result := history.insert(base, 2, 5*time.Minute)
+
+		if result != rejectedLate {
+			t.Errorf("expected rejection, got %v", result)
+		}
+		if len(history.Volumes) != 1 {
+			t.Errorf("expected rejected event not stored, got %d volumes", len(history.Volumes))
+		}
+	})
+}
+
+func TestVolumeHistory_Evict(t *testing.T) {
+	base := time.Date(2024, 6, 16, 12, 0, 0, 0, time.UTC)
+	history := &VolumeHistory{}
+
+	for i := 0; i < 5; i++ {
+		history.insert(base.Add(time.Duration(i)*time.Hour), float64(i), 0)
+	}
+	history.evict(WindowSettings{Window: 2 * time.Hour})
+
+	if len(history.Volumes) != 3 {
+		t.Fatalf("expected 3 volumes inside window, got %d", len(history.Volumes))
+	}
+	if history.Volumes[0] != 2 {
+		t.Errorf("expected oldest retained volume 2, got %f", history.Volumes[0])
+	}
+}
+
+func TestVolumeHistory_EvictCapScalesWithWindow(t *testing.T) {
+	base := time.Date(2024, 6, 16, 12, 0, 0, 0, time.UTC)
+	window := WindowSettings{Window: 30 * 24 * time.Hour, AllowedLateness: 10 * time.Minute}
+	if size := window.MaxHistorySize(); size != 30*24*60+10 {
+		t.Fatalf("expected 30 days and 10 minutes of minute events, got %d", size)
+	}
+
+	// Ten days of minute-level events all fall inside a 30-day window
+	history := &VolumeHistory{}
+	for i := 0; i < 10*24*60; i++ {
+		history.insert(base.Add(time.Duration(i)*time.Minute), float64(i), 0)
+	}
+	history.evict(window)
+
+	if len(history.Volumes) != 10*24*60 {
+		t.Errorf("expected all %d events kept, got %d", 10*24*60, len(history.Volumes))
+	}
+
+	// Events closer together than a minute are capped at the derived size
+	window = WindowSettings{Window: time.Hour}
+	history = &VolumeHistory{}
+	for i := 0; i < 120; i++ {
+		history.insert(base.Add(time.Duration(i)*30*time.Second), float64(i), 0)
+	}
+	history.evict(window)
+
+	if len(history.Volumes) != 60 || history.Volumes[0] != 60 {
+		t.Errorf("expected the newest 60 events kept, got %d starting at %v", len(history.Volumes), history.Volumes[0])
+	}
+}
+
+func TestVolumeDetector_EventTimeReplay(t *testing.T) {
+	producer := &kafkatest.SignalProducer{}
+
+	eventsProcessed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_events_processed", Help: "test"},
+		[]string{"symbol"},
+	)
+	spikesDetected := prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "test_spikes_detected", Help: "test"},
+		[]string{"symbol"},
+	)
+	processingTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "test_processing_time", Help: "test"},
+		[]string{"symbol"},
+	)
+
+	window := WindowSettings{Window: 24 * time.Hour, AllowedLateness: time.Minute}
+	detector := NewVolumeDetector(producer, ratioSettings(1.5), window, *eventsProcessed, *spikesDetected, *processingTime)
+
+	historical := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		detector.ProcessPriceEvent(&events.PriceEvent{
+			Timestamp: historical.Add(time.Duration(i) * time.Hour),
+			Symbol:    "REPLAY",
+			Volume24h: 1000,
+		})
+	}
+
+	if len(detector.volumeHistory["REPLAY"].Volumes) != 5 {
+		t.Fatalf("expected historical events kept by event time, got %d", len(detector.volumeHistory["REPLAY"].Volumes))
+	}
+
+	detector.ProcessPriceEvent(&events.PriceEvent{
+		Timestamp: historical.Add(2 * time.Hour),
+		Symbol:    "REPLAY",
+		Volume24h: 5000,
+	})
+
+	if len(producer.Signals()) != 0 {
+		t.Errorf("expected late event not to trigger a spike, got %d signals", len(producer.Signals()))
+	}
+
+	detector.ProcessPriceEvent(&events.PriceEvent{
+		Timestamp: historical.Add(5 * time.Hour),
+		Symbol:    "REPLAY",
+		Volume24h: 5000,
+	})
+
+	if len(producer.Signals()) != 1 {
+		t.Fatalf("expected spike on in-order event, got %d signals", len(producer.Signals()))
+	}
+	if producer.Signals()[0].Details["window_hours"] != 24.0 {
+		t.Errorf("expected window_hours 24, got %v", producer.Signals()[0].Details["window_hours"])
+	}
+}